@@ -0,0 +1,247 @@
+package gorex
+
+// static analysis
+//
+// what every match of the expression has in common, found from its groups,
+// tokens and quantifier arguments without matching anything:
+//
+//  min, max, e := rex.LengthBounds()   // bytes; max is Unbounded for +, * and {n,}
+//  lit, e := rex.Literals()            // text every match starts, ends with or contains
+//  if lit.MayMatch(line) { re.FindString(line) ... }   // re compiled from Output
+//
+// case-insensitive text is never required, as strings.Contains cannot
+// look for it.
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unbounded is the maximum length of expressions with unbounded repetition
+const Unbounded = -1
+
+// Literals is the text required of every match
+type Literals struct {
+	Prefix string // every match starts with Prefix
+	Suffix string // every match ends with Suffix
+	Required []string // every match contains each, longest first
+}
+
+// MayMatch reports whether input contains every required literal; when it
+// does not, the expression cannot match input
+func (l Literals) MayMatch(input string) bool {
+	for _, r := range(l.Required) {
+		if !strings.Contains(input, r) { return false }
+	}
+
+	return true
+}
+
+// what is known of the strings a part of an expression matches
+type facts struct {
+	min int // bytes
+	max int // bytes, or Unbounded
+	exact bool // only text is matched
+	text string
+	prefix string
+	suffix string
+	required []string
+}
+
+func exactFacts(text string) facts {
+	return facts{ len(text), len(text), true, text, text, text, []string{ text } }
+}
+
+func runeBytes(r rune) int {
+	if n := utf8.RuneLen(r); n > 0 { return n }
+
+	return 3 // surrogate halves
+}
+
+// bytes matched by one of the runes of ranges; invalid utf-8 bytes are read
+// as the replacement character, one at a time
+func rangeFacts(ranges []rune) facts {
+	f := facts{ min: 4, max: 0 }
+	for i := 0; i + 1 < len(ranges); i += 2 {
+		if n := runeBytes(ranges[i]); n < f.min { f.min = n }
+		if n := runeBytes(ranges[i+1]); n > f.max { f.max = n }
+		if ranges[i] <= utf8.RuneError && utf8.RuneError <= ranges[i+1] { f.min = 1 }
+	}
+	if len(ranges) == 2 && ranges[0] == ranges[1] { return exactFacts(string(ranges[0])) }
+
+	return f
+}
+
+func addBounds(a int, b int) int {
+	if a == Unbounded || b == Unbounded { return Unbounded }
+
+	return a + b
+}
+
+func commonPrefix(a string, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] { n++ }
+	// whole runes only
+	for n > 0 && n < len(a) && !utf8.RuneStart(a[n]) { n-- }
+
+	return a[:n]
+}
+
+func commonSuffix(a string, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] { n++ }
+	for n > 0 && !utf8.RuneStart(a[len(a)-n]) { n-- }
+
+	return a[len(a)-n:]
+}
+
+// facts of a sequence of parts
+func concatFacts(parts []facts) facts {
+	f := exactFacts("")
+	prefixOpen := true // all parts so far matched exact text
+	var run string // exact text ending at the current part
+	for _, p := range(parts) {
+		f.min = addBounds(f.min, p.min)
+		f.max = addBounds(f.max, p.max)
+		f.required = append(f.required, p.required...)
+		if p.exact {
+			f.text += p.text
+			run += p.text
+			if prefixOpen { f.prefix += p.text }
+			f.suffix += p.text
+			continue
+		}
+		f.exact = false
+		if prefixOpen { f.prefix += p.prefix }
+		prefixOpen = false
+		f.required = append(f.required, run + p.prefix)
+		run = p.suffix
+		f.suffix = p.suffix
+	}
+	f.required = append(f.required, run)
+	if !f.exact { f.text = "" }
+
+	return f
+}
+
+// facts of alternatives
+func altFacts(alts []facts) facts {
+	f := alts[0]
+	for _, a := range(alts[1:]) {
+		f.min = min(f.min, a.min)
+		if f.max != Unbounded && (a.max == Unbounded || a.max > f.max) { f.max = a.max }
+		f.exact = f.exact && a.exact && f.text == a.text
+		f.prefix = commonPrefix(f.prefix, a.prefix)
+		f.suffix = commonSuffix(f.suffix, a.suffix)
+		f.required = nil
+	}
+	if !f.exact { f.text = "" }
+	if len(alts) > 1 { f.required = []string{ f.prefix, f.suffix } }
+
+	return f
+}
+
+// facts of re repeated from lo to hi times, hi being Unbounded or more
+func repeatFacts(sub facts, lo int, hi int) facts {
+	f := facts{ min: sub.min * lo, max: Unbounded }
+	if hi != Unbounded && sub.max != Unbounded { f.max = sub.max * hi }
+	if sub.max == 0 { f.max = 0 }
+	if lo == 0 { return f }
+
+	if sub.exact && lo == hi { return exactFacts(strings.Repeat(sub.text, lo)) }
+	f.prefix, f.suffix = sub.prefix, sub.suffix
+	f.required = sub.required
+
+	return f
+}
+
+func syntaxFacts(re *syntax.Regexp) facts {
+	var subs []facts
+	for _, s := range(re.Sub) { subs = append(subs, syntaxFacts(s)) }
+
+	switch(re.Op) {
+	case syntax.OpNoMatch:
+		return facts{ }
+	case syntax.OpLiteral:
+		if re.Flags & syntax.FoldCase == 0 { return exactFacts(string(re.Rune)) }
+		var parts []facts
+		for _, r := range(re.Rune) {
+			class := []rune{ r, r }
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) { class = append(class, f, f) }
+			f := rangeFacts(normalizeRanges(class))
+			if len(class) > 2 { f = facts{ min: f.min, max: f.max } }
+			parts = append(parts, f)
+		}
+		return concatFacts(parts)
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 { return facts{ } }
+		return rangeFacts(re.Rune)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return facts{ min: 1, max: utf8.UTFMax }
+	case syntax.OpCapture:
+		return subs[0]
+	case syntax.OpConcat:
+		return concatFacts(subs)
+	case syntax.OpAlternate:
+		return altFacts(subs)
+	case syntax.OpStar:
+		return repeatFacts(subs[0], 0, Unbounded)
+	case syntax.OpPlus:
+		return repeatFacts(subs[0], 1, Unbounded)
+	case syntax.OpQuest:
+		return repeatFacts(subs[0], 0, 1)
+	case syntax.OpRepeat:
+		hi := re.Max
+		if hi < 0 { hi = Unbounded }
+		return repeatFacts(subs[0], re.Min, hi)
+	}
+
+	// empty matches and anchors
+	return exactFacts("")
+}
+
+// facts of the whole expression: groups in sequence, of alternatives each
+func (g *Gorex) facts() (facts, error) {
+	groups, e := g.parseGroups()
+	if e != nil { return facts{ }, e }
+
+	var parts []facts
+	for _, alts := range(groups) {
+		var fs []facts
+		for _, re := range(alts) { fs = append(fs, syntaxFacts(re)) }
+		parts = append(parts, altFacts(fs))
+	}
+
+	return concatFacts(parts), nil
+}
+
+// LengthBounds returns the least and greatest length in bytes of a match;
+// max is Unbounded when there is no greatest
+func (g *Gorex) LengthBounds() (min int, max int, e error) {
+	f, e := g.facts()
+	if e != nil { return 0, 0, e }
+
+	return f.min, f.max, nil
+}
+
+// Literals returns the text every match starts with, ends with and contains
+func (g *Gorex) Literals() (Literals, error) {
+	f, e := g.facts()
+	if e != nil { return Literals{ }, e }
+
+	// the longest first, leaving out those within others
+	req := append([]string{ f.prefix, f.suffix }, f.required...)
+	sort.SliceStable(req, func(i, j int) bool { return len(req[i]) > len(req[j]) })
+	var kept []string
+	for _, r := range(req) {
+		if r == "" { continue }
+		within := false
+		for _, k := range(kept) { within = within || strings.Contains(k, r) }
+		if !within { kept = append(kept, r) }
+	}
+
+	return Literals{ f.prefix, f.suffix, kept }, nil
+}
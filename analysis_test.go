@@ -0,0 +1,118 @@
+package gorex
+
+import(
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testLog() *Gorex {
+	g, _ := GolangExpression()
+	g.AddFixed("ERROR: ")
+	g.AddClass(Digits)
+	g.ApplyQuantifier(MinToMax, 1, 3)
+	g.AddFixed("ms")
+
+	return g
+}
+
+func TestLengthBounds(t *testing.T) {
+	cases := []struct {
+		build func() *Gorex
+		min, max int
+	}{
+		{ testEmail, 7, Unbounded },
+		{ testLog, 10, 12 },
+		{ func() *Gorex {
+			g, _ := GolangExpression()
+			g.AddFixed("a")
+			g.AddFixedToLast("bcd")
+			g.ApplyQuantifier(Exactly, 2)
+			g.AddFixed("x?")
+			return g
+		}, 1, 5 },
+		{ func() *Gorex {
+			// any character takes up to four bytes, invalid bytes one
+			g, _ := GolangExpression()
+			g.AddFixed(".")
+			g.ApplyQuantifier(MinToMax, 2, 3)
+			return g
+		}, 2, 12 },
+		{ func() *Gorex {
+			g, _ := GolangExpression()
+			g.AddFixed("\\x{e9}")
+			g.AddFixed("k")
+			g.SetFlags(CaseInsensitive)
+			return g
+		}, 3, 5 },
+	}
+
+	for i, c := range(cases) {
+		g := c.build()
+		min, max, e := g.LengthBounds()
+		if e != nil { t.Fatalf("LengthBounds() %d unexpected error: %s\n", i, e) }
+		if min != c.min || max != c.max { t.Fatalf("LengthBounds() %d = %d, %d; expected %d, %d\n", i, min, max, c.min, c.max) }
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	cases := []struct {
+		build func() *Gorex
+		lit Literals
+	}{
+		{ testEmail, Literals{ "", "", []string{ "@", "." } } },
+		{ testLog, Literals{ "ERROR: ", "ms", []string{ "ERROR: ", "ms" } } },
+		{ func() *Gorex {
+			g, _ := GolangExpression()
+			g.AddFixed("id=")
+			g.AddFixed("[0-9]+")
+			g.AddFixed("(?:/v)")
+			g.ApplyQuantifier(Exactly, 2)
+			g.AddFixed("[a-z]*_(?:ok|fail)ed")
+			return g
+		}, Literals{ "id=", "ed", []string{ "/v/v", "id=", "ed", "_" } } },
+		{ func() *Gorex {
+			// nothing case-insensitive is required
+			g, _ := GolangExpression()
+			g.AddFixed("user")
+			g.SetFlags(CaseInsensitive)
+			g.AddFixed(":")
+			return g
+		}, Literals{ "", ":", []string{ ":" } } },
+		{ func() *Gorex {
+			g, _ := GolangExpression()
+			g.AddFixed("x")
+			g.ApplyQuantifier(ZeroOrOne)
+			g.AddFixed("Gorex")
+			g.AddFixedToLast("Gopher")
+			return g
+		}, Literals{ "", "", []string{ "Go" } } },
+	}
+
+	for i, c := range(cases) {
+		lit, e := c.build().Literals()
+		if e != nil { t.Fatalf("Literals() %d unexpected error: %s\n", i, e) }
+		if !reflect.DeepEqual(lit, c.lit) { t.Fatalf("Literals() %d = %#v; expected %#v\n", i, lit, c.lit) }
+	}
+
+	lit, _ := testLog().Literals()
+	if lit.MayMatch("INFO: 12ms") { t.Fatalf("MayMatch() unexpected match\n") }
+	if !lit.MayMatch("at 10:00 ERROR: 12ms") { t.Fatalf("MayMatch() unexpected mismatch\n") }
+}
+
+func TestAnalysisGenerated(t *testing.T) {
+	for i, g := range([]*Gorex{ testEmail(), testLog() }) {
+		min, max, _ := g.LengthBounds()
+		lit, _ := g.Literals()
+		gen, _ := g.Generator(rand.NewSource(int64(i)))
+		for n := 0; n < 200; n++ {
+			s, e := gen.Generate()
+			if e != nil { t.Fatalf("Generate() unexpected error: %s\n", e) }
+			if len(s) < min || (max != Unbounded && len(s) > max) { t.Fatalf("LengthBounds() %d, %d exclude %q\n", min, max, s) }
+			if !strings.HasPrefix(s, lit.Prefix) || !strings.HasSuffix(s, lit.Suffix) || !lit.MayMatch(s) {
+				t.Fatalf("Literals() %#v exclude %q\n", lit, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package gorex
+
+// automata
+//
+// expressions become deterministic automata over a shared alphabet of rune
+// ranges: every rune of a range takes the same transitions in every
+// automaton built together. the automata accept exactly the inputs the
+// whole expression matches; anchors and word boundaries are decided by the
+// kind of character either side of them, which the states remember.
+
+import (
+	"errors"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// largest automaton built before giving up
+const maxStates = 10000
+
+// the kind of character before or after a position, for anchors
+const (
+	kindBegin = iota // no character before
+	kindEnd // no character after
+	kindNewline
+	kindWord
+	kindOther
+)
+
+func runeKind(r rune) int {
+	if r == '\n' { return kindNewline }
+	if r <= unicode.MaxASCII && syntax.IsWordChar(r) { return kindWord }
+
+	return kindOther
+}
+
+// the empty-width assertions true between characters of kinds before and after
+func emptyContext(before int, after int) syntax.EmptyOp {
+	var op syntax.EmptyOp
+	if before == kindBegin { op |= syntax.EmptyBeginText | syntax.EmptyBeginLine }
+	if before == kindNewline { op |= syntax.EmptyBeginLine }
+	if after == kindEnd { op |= syntax.EmptyEndText | syntax.EmptyEndLine }
+	if after == kindNewline { op |= syntax.EmptyEndLine }
+	if (before == kindWord) != (after == kindWord) {
+		op |= syntax.EmptyWordBoundary
+	} else {
+		op |= syntax.EmptyNoWordBoundary
+	}
+
+	return op
+}
+
+// a range of runes read as one symbol
+type symbol struct {
+	lo rune
+	hi rune
+}
+
+func (s symbol) size() int64 { return int64(s.hi - s.lo) + 1 }
+
+// deterministic automaton; trans[state][symbol] is -1 where input is rejected
+type dfa struct {
+	symbols []symbol
+	trans [][]int
+	accept []bool
+}
+
+// the compiled program of the whole expression
+func (g *Gorex) prog() (*syntax.Prog, error) {
+	o, e := g.Output()
+	if e != nil { return nil, e }
+	re, e := syntax.Parse(o, syntax.Perl)
+	if e != nil { return nil, e }
+
+	return syntax.Compile(re.Simplify())
+}
+
+// splits the runes into ranges no instruction of the programs, nor the
+// character kinds, tell apart; surrogate halves are left out
+func alphabet(progs []*syntax.Prog) []symbol {
+	cuts := map[rune]bool{ 0: true, '\n': true, '\n' + 1: true, 0xD800: true, 0xE000: true, unicode.MaxASCII + 1: true }
+	for _, r := range([]rune{ '0', '9' + 1, 'A', 'Z' + 1, '_', '_' + 1, 'a', 'z' + 1 }) { cuts[r] = true }
+	for _, p := range(progs) {
+		for _, inst := range(p.Inst) {
+			if inst.Op != syntax.InstRune && inst.Op != syntax.InstRune1 { continue }
+			if len(inst.Rune) == 1 {
+				r := inst.Rune[0]
+				cuts[r], cuts[r + 1] = true, true
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) { cuts[f], cuts[f + 1] = true, true }
+				continue
+			}
+			for i := 0; i + 1 < len(inst.Rune); i += 2 { cuts[inst.Rune[i]], cuts[inst.Rune[i+1] + 1] = true, true }
+		}
+	}
+
+	var points []rune
+	for r := range(cuts) {
+		if r <= unicode.MaxRune { points = append(points, r) }
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+
+	var symbols []symbol
+	for i, lo := range(points) {
+		hi := rune(unicode.MaxRune)
+		if i + 1 < len(points) { hi = points[i+1] - 1 }
+		if lo >= 0xD800 && lo < 0xE000 { continue }
+		symbols = append(symbols, symbol{ lo, hi })
+	}
+
+	return symbols
+}
+
+func instMatches(inst *syntax.Inst, r rune) bool {
+	switch(inst.Op) {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	case syntax.InstRune, syntax.InstRune1:
+		return inst.MatchRune(r)
+	}
+
+	return false
+}
+
+// the instructions reading a character, or matching, reached from pcs
+// between characters of kinds before and after
+func closure(p *syntax.Prog, pcs []int, before int, after int) []int {
+	ctx := emptyContext(before, after)
+	seen := map[int]bool{ }
+	var out []int
+	var visit func(pc int)
+	visit = func(pc int) {
+		if seen[pc] { return }
+		seen[pc] = true
+		inst := &p.Inst[pc]
+		switch(inst.Op) {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			visit(int(inst.Out))
+			visit(int(inst.Arg))
+		case syntax.InstNop, syntax.InstCapture:
+			visit(int(inst.Out))
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg) &^ ctx == 0 { visit(int(inst.Out)) }
+		case syntax.InstMatch, syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			out = append(out, pc)
+		}
+	}
+	for _, pc := range(pcs) { visit(pc) }
+
+	return out
+}
+
+// subset construction; a state is the instructions to resume from and the
+// kind of the character read last
+func determinize(p *syntax.Prog, symbols []symbol) (*dfa, error) {
+	type key struct {
+		pcs string
+		kind int
+	}
+	d := &dfa{ symbols: symbols }
+	var sets [][]int
+	var kinds []int
+	index := map[key]int{ }
+	add := func(pcs []int, kind int) int {
+		sort.Ints(pcs)
+		var k strings.Builder
+		for _, pc := range(pcs) { k.WriteString(strconv.Itoa(pc) + ",") }
+		if i, ok := index[key{ k.String(), kind }]; ok { return i }
+		index[key{ k.String(), kind }] = len(sets)
+		sets = append(sets, pcs)
+		kinds = append(kinds, kind)
+		return len(sets) - 1
+	}
+
+	add([]int{ p.Start }, kindBegin)
+	for s := 0; s < len(sets); s++ {
+		if len(sets) > maxStates { return nil, errors.New("Gorex @180: automaton too large") }
+		row := make([]int, len(symbols))
+		for i, sym := range(symbols) {
+			row[i] = -1
+			seen := map[int]bool{ }
+			var next []int
+			for _, pc := range(closure(p, sets[s], kinds[s], runeKind(sym.lo))) {
+				inst := &p.Inst[pc]
+				if instMatches(inst, sym.lo) && !seen[int(inst.Out)] {
+					seen[int(inst.Out)] = true
+					next = append(next, int(inst.Out))
+				}
+			}
+			if len(next) != 0 { row[i] = add(next, runeKind(sym.lo)) }
+		}
+		d.trans = append(d.trans, row)
+
+		accept := false
+		for _, pc := range(closure(p, sets[s], kinds[s], kindEnd)) {
+			if p.Inst[pc].Op == syntax.InstMatch { accept = true }
+		}
+		d.accept = append(d.accept, accept)
+	}
+
+	return d, nil
+}
+
+// automata of the expressions over a shared alphabet
+func automata(gs ...*Gorex) ([]*dfa, error) {
+	var progs []*syntax.Prog
+	for _, g := range(gs) {
+		p, e := g.prog()
+		if e != nil { return nil, e }
+		progs = append(progs, p)
+	}
+	symbols := alphabet(progs)
+
+	var ds []*dfa
+	for _, p := range(progs) {
+		d, e := determinize(p, symbols)
+		if e != nil { return nil, e }
+		ds = append(ds, d)
+	}
+
+	return ds, nil
+}
+
+// the states from which some input is accepted
+func (d *dfa) live() []bool {
+	live := append([]bool{ }, d.accept...)
+	for changed := true; changed; {
+		changed = false
+		for s, row := range(d.trans) {
+			if live[s] { continue }
+			for _, t := range(row) {
+				if t >= 0 && live[t] {
+					live[s], changed = true, true
+					break
+				}
+			}
+		}
+	}
+
+	return live
+}
+
+// the longest accepted input, in runes, or -1 when there is no bound; the
+// automaton must accept something
+func (d *dfa) longest() int {
+	live := d.live()
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	mark := make([]int, len(d.trans))
+	depth := make([]int, len(d.trans))
+	infinite := false
+	var visit func(s int)
+	visit = func(s int) {
+		mark[s] = visiting
+		depth[s] = 0
+		for _, t := range(d.trans[s]) {
+			if t < 0 || !live[t] { continue }
+			if mark[t] == visiting { infinite = true }
+			if mark[t] == unvisited { visit(t) }
+			if depth[t] + 1 > depth[s] { depth[s] = depth[t] + 1 }
+		}
+		mark[s] = done
+	}
+	visit(0)
+	if infinite { return -1 }
+
+	return depth[0]
+}
@@ -0,0 +1,108 @@
+package gorex
+
+// boolean operations
+//
+// Intersect, Complement and Difference combine the strings expressions
+// match as a whole into a new expression, built from their automata:
+//
+//  ident, _ := GolangExpression()
+//  ident.AddFixed("[a-z_][a-z0-9_]*")
+//  reserved, _ := GolangExpression()
+//  reserved.AddFixed("if")
+//  reserved.AddFixedToLast("for")
+//  reserved.AddFixedToLast("func")
+//  names, e := Difference(ident, reserved)
+//
+// the result has a single group holding plain syntax (classes, literals,
+// non-capturing groups and the ?, * and + quantifiers); like examples, it
+// is meant to match whole inputs.
+
+import (
+	"errors"
+)
+
+// the automaton accepting the inputs for which op holds of the acceptance
+// of a and b
+func combine(a *dfa, b *dfa, op func(x bool, y bool) bool) *dfa {
+	// -1 is the state rejecting everything
+	type pair struct { a, b int }
+	accepts := func(d *dfa, s int) bool { return s >= 0 && d.accept[s] }
+	step := func(d *dfa, s int, sym int) int {
+		if s < 0 { return -1 }
+		return d.trans[s][sym]
+	}
+
+	c := &dfa{ symbols: a.symbols }
+	index := map[pair]int{ { 0, 0 }: 0 }
+	pairs := []pair{ { 0, 0 } }
+	for i := 0; i < len(pairs); i++ {
+		p := pairs[i]
+		row := make([]int, len(a.symbols))
+		for sym := range(a.symbols) {
+			q := pair{ step(a, p.a, sym), step(b, p.b, sym) }
+			if q.a < 0 && q.b < 0 && !op(false, false) {
+				row[sym] = -1
+				continue
+			}
+			if _, ok := index[q]; !ok {
+				index[q] = len(pairs)
+				pairs = append(pairs, q)
+			}
+			row[sym] = index[q]
+		}
+		c.trans = append(c.trans, row)
+		c.accept = append(c.accept, op(accepts(a, p.a), accepts(b, p.b)))
+	}
+
+	return c
+}
+
+// the expression of the inputs an automaton accepts
+func fromAutomaton(d *dfa) (*Gorex, error) {
+	g, e := GolangExpression()
+	if e != nil { return nil, e }
+
+	n := d.expression()
+	switch {
+	case n == nil:
+		// an empty class matches nothing
+		e = g.AddFixed("[^\\x00-\\x{10FFFF}]")
+	case n.op == nodeAlt && !n.optional():
+		e = g.AddFixed(n.subs[0].key)
+		for _, s := range(n.subs[1:]) {
+			if e == nil { e = g.AddFixedToLast(s.key) }
+		}
+	default:
+		e = g.AddFixed(n.key)
+	}
+	if e != nil { return nil, errors.New("Gorex @76: unable to build expression: " + e.Error()) }
+
+	return g, nil
+}
+
+// combines the automata of a and b with op
+func combineExpressions(a *Gorex, b *Gorex, op func(x bool, y bool) bool) (*Gorex, error) {
+	ds, e := automata(a, b)
+	if e != nil { return nil, e }
+
+	return fromAutomaton(combine(ds[0], ds[1], op))
+}
+
+// Intersect returns an expression matching the strings both a and b match
+func Intersect(a *Gorex, b *Gorex) (*Gorex, error) {
+	return combineExpressions(a, b, func(x bool, y bool) bool { return x && y })
+}
+
+// Difference returns an expression matching the strings a matches and b
+// does not
+func Difference(a *Gorex, b *Gorex) (*Gorex, error) {
+	return combineExpressions(a, b, func(x bool, y bool) bool { return x && !y })
+}
+
+// Complement returns an expression matching the strings a does not match
+func Complement(a *Gorex) (*Gorex, error) {
+	ds, e := automata(a)
+	if e != nil { return nil, e }
+
+	return fromAutomaton(combine(ds[0], ds[0], func(x bool, y bool) bool { return !x }))
+}
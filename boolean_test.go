@@ -0,0 +1,86 @@
+package gorex
+
+import(
+	"regexp"
+	"testing"
+)
+
+// every string of up to n characters from alphabet
+func allStrings(alphabet string, n int) []string {
+	all := []string{ "" }
+	last := []string{ "" }
+	for i := 0; i < n; i++ {
+		var next []string
+		for _, s := range(last) {
+			for _, r := range(alphabet) { next = append(next, s + string(r)) }
+		}
+		all = append(all, next...)
+		last = next
+	}
+
+	return all
+}
+
+// checks c matches exactly the inputs for which op holds of a and b
+func testBoolean(t *testing.T, name string, c *Gorex, a *Gorex, b *Gorex, op func(x bool, y bool) bool) {
+	rc, e := c.exampleRegexp()
+	if e != nil { t.Fatalf("%s: invalid result: %s\n", name, e) }
+	ra, _ := a.exampleRegexp()
+	rb, _ := b.exampleRegexp()
+	for _, s := range(allStrings("aifnorcu_0@.\n", 4)) {
+		if w := op(ra.MatchString(s), rb.MatchString(s)); rc.MatchString(s) != w {
+			o, _ := c.Output()
+			t.Fatalf("%s: %s matching %q is %v\n", name, o, s, !w)
+		}
+	}
+}
+
+func TestBoolean(t *testing.T) {
+	ident, _ := GolangExpression()
+	ident.AddFixed("[a-z_][a-z0-9_]*")
+	reserved, _ := GolangExpression()
+	reserved.AddFixed("if")
+	reserved.AddFixedToLast("for")
+	reserved.AddFixedToLast("func")
+
+	c, e := Difference(ident, reserved)
+	if e != nil { t.Fatalf("Difference() unexpected error: %s\n", e) }
+	testBoolean(t, "Difference()", c, ident, reserved, func(x bool, y bool) bool { return x && !y })
+
+	c, e = Intersect(ident, reserved)
+	if e != nil { t.Fatalf("Intersect() unexpected error: %s\n", e) }
+	if o, _ := c.Output(); o != "(if|f(?:or|unc))" { t.Fatalf("Intersect() output %s\n", o) }
+	if ok, x, _ := Equivalent(c, reserved); !ok { t.Fatalf("Intersect() differs on %q\n", x) }
+
+	c, e = Complement(reserved)
+	if e != nil { t.Fatalf("Complement() unexpected error: %s\n", e) }
+	testBoolean(t, "Complement()", c, reserved, reserved, func(x bool, y bool) bool { return !x })
+
+	// anchors, flags and repetition
+	a, _ := GolangExpression()
+	a.AddFixed("a+b+|a*c")
+	a.SetFlags(CaseInsensitive)
+	b, _ := GolangExpression()
+	b.AddFixed("^.*")
+	b.AddFixed("[^a]")
+	b.ApplyAnchor(atBeginning)
+	c, _ = Complement(a)
+	testBoolean(t, "Complement() repetition", c, a, a, func(x bool, y bool) bool { return !x })
+	c, _ = Intersect(a, b)
+	testBoolean(t, "Intersect() anchors", c, a, b, func(x bool, y bool) bool { return x && y })
+	c, _ = Difference(b, a)
+	testBoolean(t, "Difference() anchors", c, b, a, func(x bool, y bool) bool { return x && !y })
+
+	// nothing in common renders an expression matching nothing
+	c, e = Intersect(testEmail(), ident)
+	if e != nil { t.Fatalf("Intersect() unexpected error: %s\n", e) }
+	o, _ := c.Output()
+	if n, _ := c.Count(); n == nil || n.Sign() != 0 { t.Fatalf("Intersect() %s should match nothing\n", o) }
+	if _, e = regexp.Compile(o); e != nil { t.Fatalf("Intersect() invalid output %s: %s\n", o, e) }
+
+	// complement twice is the original
+	c, _ = Complement(testEmail())
+	c, _ = Complement(c)
+	if ok, x, _ := Equivalent(c, testEmail()); !ok { t.Fatalf("Complement() twice differs on %q\n", x) }
+	if o, _ = c.Output(); o != "([A-Za-z]+@[a-z]+\\.(?:com|net))" { t.Fatalf("Complement() twice output %s\n", o) }
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/dev-west/gorex"
+)
+
+// gorex build: prints the golang expression of a definition
+func runBuild(e *env, args []string) int {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil { return exitError }
+	name, ok := fileArg(e, fs.Args())
+	if !ok { return exitError }
+
+	g, err := loadDefinition(e, name)
+	if err != nil { return fail(e, err) }
+	o, err := g.Output()
+	if err != nil { return fail(e, err) }
+	fmt.Fprintln(e.stdout, o)
+
+	return exitOK
+}
+
+// gorex explain: describes a definition group by group
+func runExplain(e *env, args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil { return exitError }
+	name, ok := fileArg(e, fs.Args())
+	if !ok { return exitError }
+
+	g, err := loadDefinition(e, name)
+	if err != nil { return fail(e, err) }
+	o, err := g.Explain()
+	if err != nil { return fail(e, err) }
+	fmt.Fprint(e.stdout, o)
+
+	return exitOK
+}
+
+// gorex convert: renders a definition in another dialect or definition format
+func runConvert(e *env, args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	to := fs.String("to", "", "output `format`: go, pcre, javascript, posix, json or definition")
+	if err := fs.Parse(args); err != nil { return exitError }
+	name, ok := fileArg(e, fs.Args())
+	if !ok { return exitError }
+	if *to == "" {
+		fmt.Fprintln(e.stderr, "gorex: convert requires -to")
+		return exitError
+	}
+
+	g, err := loadDefinition(e, name)
+	if err != nil { return fail(e, err) }
+	var o string
+	switch(*to) {
+	case "json":
+		o, err = formatJSON(g)
+	case "definition":
+		o, err = g.Definition()
+	default:
+		o, err = g.OutputDialect(gorex.Dialect(*to))
+		o += "\n"
+	}
+	if err != nil { return fail(e, err) }
+	fmt.Fprint(e.stdout, o)
+
+	return exitOK
+}
+
+// gorex verify: checks the examples of each definition
+func runVerify(e *env, args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil { return exitError }
+	names := fs.Args()
+	if len(names) == 0 { names = []string{ "-" } }
+
+	status := exitOK
+	for _, name := range(names) {
+		g, err := loadDefinition(e, name)
+		if err != nil { return fail(e, err) }
+		err = g.Verify()
+		if _, ok := err.(*gorex.VerifyError); err != nil && !ok { return fail(e, fmt.Errorf("%s: %w", name, err)) }
+		if err != nil {
+			fmt.Fprintf(e.stdout, "%s: %s\n", name, err)
+			status = exitNoMatch
+			continue
+		}
+		fmt.Fprintf(e.stdout, "%s: ok\n", name)
+	}
+
+	return status
+}
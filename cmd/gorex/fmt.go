@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dev-west/gorex"
+)
+
+// indented JSON form of an expression
+func formatJSON(g *gorex.Gorex) (string, error) {
+	data, err := json.MarshalIndent(g, "", "\t")
+	if err != nil { return "", err }
+
+	return string(data) + "\n", nil
+}
+
+// canonical form of a definition, keeping JSON input as JSON; the comment
+// block heading a definition is kept, other comments are dropped
+func format(data []byte) (string, error) {
+	g, err := parseDefinition(data)
+	if err != nil { return "", err }
+	if isJSON(data) { return formatJSON(g) }
+
+	var header string
+	for _, line := range(strings.SplitAfter(string(data), "\n")) {
+		if !strings.HasPrefix(line, "#") { break }
+		header += line
+	}
+	d, err := g.Definition()
+
+	return header + d, err
+}
+
+// gorex fmt: rewrites definitions in canonical form; with no files, formats
+// stdin to stdout
+func runFmt(e *env, args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	list := fs.Bool("l", false, "list files whose formatting differs")
+	write := fs.Bool("w", false, "write the result to the source file")
+	if err := fs.Parse(args); err != nil { return exitError }
+
+	if fs.NArg() == 0 {
+		data, err := readInput(e, "-")
+		if err != nil { return fail(e, err) }
+		o, err := format(data)
+		if err != nil { return fail(e, fmt.Errorf("-: %w", err)) }
+		fmt.Fprint(e.stdout, o)
+		return exitOK
+	}
+
+	status := exitOK
+	for _, name := range(fs.Args()) {
+		data, err := os.ReadFile(name)
+		if err != nil { return fail(e, err) }
+		o, err := format(data)
+		if err != nil { return fail(e, fmt.Errorf("%s: %w", name, err)) }
+
+		changed := !bytes.Equal(data, []byte(o))
+		if *list && changed {
+			fmt.Fprintln(e.stdout, name)
+			status = exitNoMatch
+		}
+		if *write && changed {
+			if err = os.WriteFile(name, []byte(o), 0644); err != nil { return fail(e, err) }
+		}
+		if !*list && !*write { fmt.Fprint(e.stdout, o) }
+	}
+
+	return status
+}
@@ -0,0 +1,152 @@
+// gorex command line tool
+//
+// builds, explains, tests and formats gorex definition files (see
+// gorex.ParseDefinition) or their JSON form
+//
+// usage
+//  gorex build [file]                 print the golang expression
+//  gorex explain [file]               describe the expression group by group
+//  gorex test [-q] [-x] def [file...] report matches in files or stdin
+//  gorex convert -to format [file]    print the expression as another
+//                                     dialect (go, pcre, javascript, posix)
+//                                     or definition format (json, definition)
+//  gorex fmt [-l] [-w] [file...]      canonicalize definition files
+//  gorex repl [-q] [file]             build an expression interactively,
+//                                     optionally starting from a saved file
+//  gorex verify [file...]             check the examples of definitions
+//  gorex serve [-addr host:port]      web playground and JSON API, by
+//                                     default on localhost:8080
+//  gorex migrate [-w] [path...]       rewrite regexp.Compile and MustCompile
+//                                     calls of go files into gorex builders;
+//                                     without -w, print the diff. paths
+//                                     ending in /... include those below
+//
+// a missing file or "-" reads stdin. definitions starting with '{' are read
+// as JSON.
+//
+// exit codes
+//  0  success; for test, at least one match
+//  1  test found no match; fmt -l found files to reformat; verify found
+//     violated examples
+//  2  usage, definition or i/o error
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/dev-west/gorex"
+)
+
+const (
+	exitOK = 0
+	exitNoMatch = 1
+	exitError = 2
+)
+
+// command environment; replaced in tests
+type env struct {
+	stdin io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	run func(e *env, args []string) int
+	usage string
+}
+
+var commands = map[string]command { }
+
+func init() {
+	commands["build"] = command{ runBuild, "build [file]" }
+	commands["explain"] = command{ runExplain, "explain [file]" }
+	commands["test"] = command{ runTest, "test [-q] [-x] definition [file...]" }
+	commands["convert"] = command{ runConvert, "convert -to format [file]" }
+	commands["fmt"] = command{ runFmt, "fmt [-l] [-w] [file...]" }
+	commands["repl"] = command{ runRepl, "repl [-q] [file]" }
+	commands["serve"] = command{ runServe, "serve [-addr host:port]" }
+	commands["verify"] = command{ runVerify, "verify [file...]" }
+	commands["migrate"] = command{ runMigrate, "migrate [-w] [path...]" }
+}
+
+func main() {
+	os.Exit(run(&env{ os.Stdin, os.Stdout, os.Stderr }, os.Args[1:]))
+}
+
+func run(e *env, args []string) int {
+	if len(args) == 0 { return usage(e) }
+	c, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "gorex: unknown command %q\n", args[0])
+		return usage(e)
+	}
+
+	return c.run(e, args[1:])
+}
+
+func usage(e *env) int {
+	var names []string
+	for n := range(commands) { names = append(names, n) }
+	sort.Strings(names)
+
+	fmt.Fprintln(e.stderr, "usage:")
+	for _, n := range(names) { fmt.Fprintf(e.stderr, "  gorex %s\n", commands[n].usage) }
+	return exitError
+}
+
+// reads a named file, or stdin for "" and "-"
+func readInput(e *env, name string) ([]byte, error) {
+	if name == "" || name == "-" { return io.ReadAll(e.stdin) }
+	return os.ReadFile(name)
+}
+
+func isJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// parses a definition or its JSON form
+func parseDefinition(data []byte) (*gorex.Gorex, error) {
+	if isJSON(data) {
+		g, _ := gorex.GolangExpression()
+		if err := json.Unmarshal(data, g); err != nil { return nil, err }
+		return g, nil
+	}
+
+	return gorex.ParseDefinition(bytes.NewReader(data))
+}
+
+func loadDefinition(e *env, name string) (*gorex.Gorex, error) {
+	data, err := readInput(e, name)
+	if err != nil { return nil, err }
+	g, err := parseDefinition(data)
+	if err != nil {
+		if name == "" { name = "-" }
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return g, nil
+}
+
+// reports an error and returns the error exit code
+func fail(e *env, err error) int {
+	fmt.Fprintf(e.stderr, "gorex: %s\n", err)
+	return exitError
+}
+
+// the single optional file argument of build, explain and convert
+func fileArg(e *env, args []string) (string, bool) {
+	switch(len(args)) {
+	case 0:
+		return "", true
+	case 1:
+		return args[0], true
+	}
+	fmt.Fprintln(e.stderr, "gorex: too many arguments")
+
+	return "", false
+}
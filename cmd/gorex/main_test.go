@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runs the tool with stdin, returning exit code and outputs
+func runWith(stdin string, args ...string) (int, string, string) {
+	var o, x bytes.Buffer
+	c := run(&env{ strings.NewReader(stdin), &o, &x }, args)
+
+	return c, o.String(), x.String()
+}
+
+func TestBuild(t *testing.T) {
+	c, o, _ := runWith("", "build", "testdata/email.gorex")
+	w := "([A-Za-z0-9]+)(\\.|_?)([0-9A-Za-z]*)(@)([0-9A-Za-z]+)(\\.)(com|net|org)\n"
+	if c != exitOK || o != w { t.Fatalf("build: exit %d output %q != %q\n", c, o, w) }
+
+	// json from stdin
+	c, o, _ = runWith(`{"groups":[{"tokens":[{"class":"0-9","quantifier":"Exactly","args":[3]}]}]}`, "build")
+	if c != exitOK || o != "([0-9]{3})\n" { t.Fatalf("build json: exit %d output %q\n", c, o) }
+
+	// invalid definitions report the line
+	c, _, x := runWith("AddClass Uppers\nApplyQuantifier MinToMax 1\n", "build", "-")
+	if c != exitError || !strings.Contains(x, "line 2") { t.Fatalf("build invalid: exit %d stderr %q\n", c, x) }
+}
+
+func TestExplain(t *testing.T) {
+	c, o, _ := runWith("AddFixed \"com\" \"net\"\n", "explain")
+	w := "group 1: either the text \"com\", or the text \"net\"\n"
+	if c != exitOK || o != w { t.Fatalf("explain: exit %d output %q != %q\n", c, o, w) }
+}
+
+func TestTest(t *testing.T) {
+	input := "mail joe@mail.org now\nnothing here\n"
+	c, o, _ := runWith(input, "test", "testdata/email.gorex")
+	if c != exitOK { t.Fatalf("test: exit %d\n", c) }
+	if !strings.HasPrefix(o, "-:1:6: \"joe@mail.org\"\n\t1: \"joe\"\n") { t.Fatalf("test: unexpected output %q\n", o) }
+	if !strings.Contains(o, "\t7: \"org\"\n") { t.Fatalf("test: missing group in %q\n", o) }
+
+	// whole lines only
+	c, o, _ = runWith(input, "test", "-x", "testdata/email.gorex")
+	if c != exitNoMatch || o != "" { t.Fatalf("test -x: exit %d output %q\n", c, o) }
+
+	// quiet
+	c, o, _ = runWith(input, "test", "-q", "testdata/email.gorex")
+	if c != exitOK || o != "" { t.Fatalf("test -q: exit %d output %q\n", c, o) }
+
+	c, _, _ = runWith(input, "test")
+	if c != exitError { t.Fatalf("test without definition: exit %d\n", c) }
+}
+
+func TestConvert(t *testing.T) {
+	def := "AddClass Digits\nApplyQuantifier OneOrMore\nSetFlags UngreedySwap\n"
+	c, o, _ := runWith(def, "convert", "-to", "javascript")
+	if c != exitOK || o != "([0-9]+?)\n" { t.Fatalf("convert javascript: exit %d output %q\n", c, o) }
+
+	c, _, _ = runWith(def, "convert", "-to", "posix")
+	if c != exitError { t.Fatalf("convert posix: exit %d, expected flag error\n", c) }
+
+	c, o, _ = runWith(def, "convert", "-to", "json")
+	if c != exitOK || !strings.Contains(o, "\"flags\": \"U\"") { t.Fatalf("convert json: exit %d output %q\n", c, o) }
+
+	c, _, _ = runWith(def, "convert")
+	if c != exitError { t.Fatalf("convert without -to: exit %d\n", c) }
+}
+
+func TestFmt(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gorex")
+	if err != nil { t.Fatal(err) }
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "a.gorex")
+	src := "# digits\n\nAddClass  Digits # trailing\nApplyQuantifier \"+\"\n"
+	w := "# digits\nGolangExpression\nAddClass Digits\nApplyQuantifier OneOrMore\n"
+	if err = os.WriteFile(name, []byte(src), 0644); err != nil { t.Fatal(err) }
+
+	c, o, _ := runWith("", "fmt", "-l", name)
+	if c != exitNoMatch || o != name + "\n" { t.Fatalf("fmt -l: exit %d output %q\n", c, o) }
+
+	c, _, _ = runWith("", "fmt", "-w", name)
+	data, _ := os.ReadFile(name)
+	if c != exitOK || string(data) != w { t.Fatalf("fmt -w: exit %d wrote %q != %q\n", c, data, w) }
+
+	c, o, _ = runWith("", "fmt", "-l", name)
+	if c != exitOK || o != "" { t.Fatalf("fmt -l after -w: exit %d output %q\n", c, o) }
+}
+
+func TestUsage(t *testing.T) {
+	if c, _, _ := runWith(""); c != exitError { t.Fatalf("no command: exit %d\n", c) }
+	if c, _, _ := runWith("", "nope"); c != exitError { t.Fatalf("unknown command: exit %d\n", c) }
+}
+
+func TestVerify(t *testing.T) {
+	c, o, _ := runWith("", "verify", "testdata/email.gorex")
+	if c != exitOK || o != "testdata/email.gorex: ok\n" { t.Fatalf("verify: exit %d output %q\n", c, o) }
+
+	c, o, _ = runWith("AddFixed \"a\"\nShouldMatch \"b\"\n", "verify")
+	if c != exitNoMatch || !strings.Contains(o, "\"b\" should match") { t.Fatalf("verify violations: exit %d output %q\n", c, o) }
+
+	c, _, _ = runWith("AddClass Nope\n", "verify")
+	if c != exitError { t.Fatalf("verify invalid: exit %d\n", c) }
+}
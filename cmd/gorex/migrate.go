@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dev-west/gorex"
+)
+
+const (
+	gorexPath = "github.com/dev-west/gorex"
+	todo = "TODO(gorex migrate): "
+)
+
+// a replacement of the source between two offsets
+type edit struct {
+	start int
+	end int
+	text string
+}
+
+// the go files named by arguments: files, directories, or dir/... for a
+// directory and those below it, skipping testdata, vendor and hidden ones
+func goFiles(args []string) ([]string, error) {
+	var files []string
+	for _, a := range(args) {
+		recursive := strings.HasSuffix(a, "/...") || a == "..."
+		dir := strings.TrimSuffix(strings.TrimSuffix(a, "..."), "/")
+		if dir == "" { dir = "." }
+		if !recursive {
+			if info, err := os.Stat(a); err != nil {
+				return nil, err
+			} else if !info.IsDir() {
+				files = append(files, a)
+				continue
+			}
+		}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil { return err }
+			if info.IsDir() {
+				name := info.Name()
+				if path != dir && (!recursive || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") { files = append(files, path) }
+			return nil
+		})
+		if err != nil { return nil, err }
+	}
+
+	return files, nil
+}
+
+// the value of a string literal, or a sum of them
+func constantString(x ast.Expr) (string, bool) {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.STRING { return "", false }
+		s, err := strconv.Unquote(x.Value)
+		return s, err == nil
+	case *ast.ParenExpr:
+		return constantString(x.X)
+	case *ast.BinaryExpr:
+		if x.Op != token.ADD { return "", false }
+		a, ok := constantString(x.X)
+		if !ok { return "", false }
+		b, ok := constantString(x.Y)
+		return a + b, ok
+	}
+
+	return "", false
+}
+
+// the syntax of an expression with its captures unnamed
+func unnamed(expr string) string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil { return "" }
+	var clear func(re *syntax.Regexp)
+	clear = func(re *syntax.Regexp) {
+		re.Name = ""
+		for _, s := range(re.Sub) { clear(s) }
+	}
+	clear(re)
+
+	return re.Simplify().String()
+}
+
+// what to check by hand after replacing expr by the output of g; names of
+// top-level groups are kept by FromRegexp, those it leaves out are listed
+func migrationNotes(expr string, g *gorex.Gorex) []string {
+	out, _ := g.Output()
+	was, now := regexp.MustCompile(expr), regexp.MustCompile(out)
+
+	var notes []string
+	var names []string
+	for i, n := range(was.SubexpNames()) {
+		if n != "" && now.SubexpIndex(n) < 0 { names = append(names, fmt.Sprintf("%s is group %d", n, i)) }
+	}
+	if len(names) != 0 { notes = append(notes, "named groups are not kept: " + strings.Join(names, ", ")) }
+	if was.NumSubexp() != 0 && unnamed(expr) != unnamed(out) {
+		notes = append(notes, fmt.Sprintf("groups are numbered differently: %d groups were %d, see %s", now.NumSubexp(), was.NumSubexp(), strconv.Quote(out)))
+	}
+
+	return notes
+}
+
+// the line of src holding offset, up to its first non-blank character
+func lineIndent(src []byte, offset int) string {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') { end++ }
+
+	return string(src[start:end])
+}
+
+// rewrites the regexp.Compile and regexp.MustCompile calls of a file whose
+// argument is constant into gorex builder code; returns the new source and
+// the number of calls rewritten
+func migrate(name string, src []byte) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil { return nil, 0, err }
+
+	var regexpName, gorexName string
+	var regexpImport *ast.ImportSpec
+	for _, imp := range(f.Imports) {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		local := filepath.Base(path)
+		if imp.Name != nil { local = imp.Name.Name }
+		switch(path) {
+		case "regexp":
+			if local != "_" && local != "." {
+				regexpName = local
+				regexpImport = imp
+			}
+		case gorexPath:
+			gorexName = local
+		}
+	}
+	if regexpName == "" { return src, 0, nil }
+
+	var edits []edit
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 { return true }
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "Compile" && sel.Sel.Name != "MustCompile") { return true }
+		if id, ok := sel.X.(*ast.Ident); !ok || id.Name != regexpName || id.Obj != nil { return true }
+		expr, ok := constantString(call.Args[0])
+		if !ok { return true }
+
+		g, err := gorex.FromRegexp(expr)
+		if err != nil && noted(f, call) { return true }
+		if err != nil {
+			edits = append(edits, edit{ offset(call.Pos()), offset(call.Pos()), "/* " + todo + "not migrated: " + strings.ReplaceAll(err.Error(), "*/", "* /") + " */ " })
+			return true
+		}
+		code, err := g.GoExpression()
+		if err != nil { return true }
+		if gorexName != "" && gorexName != "gorex" { code = strings.ReplaceAll(code, "gorex.", gorexName + ".") }
+
+		var notes string
+		for _, n := range(migrationNotes(expr, g)) { notes += "\t// " + todo + n + "\n" }
+		code = strings.Replace(code, "{\n", "{\n" + notes, 1)
+		indent := lineIndent(src, offset(call.Pos()))
+		lines := strings.Split(code, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" { lines[i] = indent + lines[i] }
+		}
+		code = strings.Join(lines, "\n")
+		edits = append(edits, edit{ offset(call.Args[0].Pos()), offset(call.Args[0].End()), code })
+		return true
+	})
+
+	migrated := 0
+	for _, e := range(edits) {
+		if e.start != e.end { migrated++ }
+	}
+	if migrated != 0 && gorexName == "" {
+		// a group of its own after the regexp import, or its declaration
+		decl := importDecl(f, regexpImport)
+		if decl != nil && decl.Lparen.IsValid() {
+			last := decl.Specs[len(decl.Specs) - 1]
+			at := offset(last.End())
+			edits = append(edits, edit{ at, at, "\n\n" + lineIndent(src, offset(last.Pos())) + strconv.Quote(gorexPath) })
+		} else {
+			at := offset(regexpImport.End())
+			if decl != nil { at = offset(decl.End()) }
+			edits = append(edits, edit{ at, at, "\nimport " + strconv.Quote(gorexPath) })
+		}
+	}
+
+	// from the end, so that offsets stay valid
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte{ }, src...)
+	for _, e := range(edits) {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), name, out, parser.ParseComments); err != nil {
+		return nil, 0, fmt.Errorf("rewritten source does not parse: %w", err)
+	}
+
+	return out, migrated, nil
+}
+
+// whether a call follows the comment of an earlier migration
+func noted(f *ast.File, call *ast.CallExpr) bool {
+	for _, c := range(f.Comments) {
+		if c.End() <= call.Pos() && call.Pos() - c.End() <= 1 && strings.Contains(c.Text(), todo) { return true }
+	}
+
+	return false
+}
+
+func importDecl(f *ast.File, spec *ast.ImportSpec) *ast.GenDecl {
+	for _, d := range(f.Decls) {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT { continue }
+		for _, s := range(gd.Specs) {
+			if s == spec { return gd }
+		}
+	}
+
+	return nil
+}
+
+// gorex migrate: rewrites regexp calls of go files into gorex builders; by
+// default a dry run printing the changes as a unified diff
+func runMigrate(e *env, args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	write := fs.Bool("w", false, "write the result to the source files")
+	if err := fs.Parse(args); err != nil { return exitError }
+	paths := fs.Args()
+	if len(paths) == 0 { paths = []string{ "./..." } }
+
+	files, err := goFiles(paths)
+	if err != nil { return fail(e, err) }
+	for _, name := range(files) {
+		src, err := os.ReadFile(name)
+		if err != nil { return fail(e, err) }
+		out, n, err := migrate(name, src)
+		if err != nil { return fail(e, fmt.Errorf("%s: %w", name, err)) }
+		if bytes.Equal(src, out) { continue }
+
+		if *write {
+			if err = os.WriteFile(name, out, 0644); err != nil { return fail(e, err) }
+			fmt.Fprintf(e.stdout, "%s: %d expressions migrated\n", name, n)
+			continue
+		}
+		fmt.Fprint(e.stdout, unifiedDiff(name, string(src), string(out)))
+	}
+
+	return exitOK
+}
+
+// the lines of s, each with its newline
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines) - 1] == "" { lines = lines[:len(lines) - 1] }
+
+	return lines
+}
+
+// a line of a diff: ' ', '-' or '+' and the line
+type diffLine struct {
+	op byte
+	text string
+}
+
+// the shortest edit script from a to b, by Myers' algorithm
+func diffLines(a []string, b []string) []diffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2 * max + 3)
+	var trace [][]int
+	d := 0
+	for ; d <= max; d++ {
+		trace = append(trace, append([]int{ }, v...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done { break }
+	}
+
+	// back from the end
+	var script []diffLine
+	x, y := n, m
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prev := k - 1
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) { prev = k + 1 }
+		px := v[off+prev]
+		py := px - prev
+		for x > px && y > py {
+			script = append(script, diffLine{ ' ', a[x-1] })
+			x--
+			y--
+		}
+		if x == px {
+			script = append(script, diffLine{ '+', b[y-1] })
+			y--
+		} else {
+			script = append(script, diffLine{ '-', a[x-1] })
+			x--
+		}
+	}
+	for x > 0 {
+		script = append(script, diffLine{ ' ', a[x-1] })
+		x--
+	}
+	for i, j := 0, len(script) - 1; i < j; i, j = i + 1, j - 1 { script[i], script[j] = script[j], script[i] }
+
+	return script
+}
+
+// a unified diff of a file, with three lines of context
+func unifiedDiff(name string, a string, b string) string {
+	const context = 3
+	script := diffLines(splitLines(a), splitLines(b))
+
+	o := bytes.NewBufferString("")
+	fmt.Fprintf(o, "--- %s\n+++ %s\n", name, name)
+	for i := 0; i < len(script); {
+		if script[i].op == ' ' {
+			i++
+			continue
+		}
+		// a hunk from context lines before this change to context lines
+		// after the last change closer than twice the context
+		start := i - context
+		if start < 0 { start = 0 }
+		end := i
+		for j := i; j < len(script); j++ {
+			if script[j].op != ' ' { end = j + 1 }
+			if j - end >= 2 * context { break }
+		}
+		end += context
+		if end > len(script) { end = len(script) }
+
+		// line numbers where the hunk starts
+		al, bl := 1, 1
+		for _, l := range(script[:start]) {
+			if l.op != '+' { al++ }
+			if l.op != '-' { bl++ }
+		}
+		var an, bn int
+		for _, l := range(script[start:end]) {
+			if l.op != '+' { an++ }
+			if l.op != '-' { bn++ }
+		}
+		// empty ranges are numbered by the line before them
+		if an == 0 { al-- }
+		if bn == 0 { bl-- }
+		fmt.Fprintf(o, "@@ -%d,%d +%d,%d @@\n", al, an, bl, bn)
+		for _, l := range(script[start:end]) {
+			o.WriteByte(l.op)
+			o.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") { o.WriteString("\n\\ No newline at end of file\n") }
+		}
+		i = end
+	}
+
+	return o.String()
+}
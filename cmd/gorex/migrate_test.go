@@ -0,0 +1,106 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const migrateSource = `package x
+
+import (
+	"fmt"
+	re "regexp"
+)
+
+var email = re.MustCompile(` + "`^[a-z]+@[a-z0-9]+\\.(com|net)$`" + `)
+
+func f(s string) {
+	date, err := re.Compile("(?P<year>\\d{4})-" + "(\\d\\d)")
+	fmt.Println(date, err)
+	bad := re.MustCompile(` + "`a(`" + `)
+	dynamic := re.MustCompile(s)
+	_, _ = bad, dynamic
+}
+`
+
+func mustWrite(t *testing.T, name string, data string) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil { t.Fatal(err) }
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil { t.Fatal(err) }
+}
+
+func TestMigrate(t *testing.T) {
+	out, n, err := migrate("x.go", []byte(migrateSource))
+	if err != nil { t.Fatalf("migrate: unexpected error: %s\n", err) }
+	if n != 2 { t.Fatalf("migrate: %d expressions migrated, expected 2\n", n) }
+	o := string(out)
+	if _, err = parser.ParseFile(token.NewFileSet(), "x.go", out, 0); err != nil { t.Fatalf("migrate: output does not parse: %s\n", err) }
+
+	for _, w := range([]string{
+		"\t\"github.com/dev-west/gorex\"\n)",
+		"var email = re.MustCompile(func() string {\n\t// TODO(gorex migrate): groups are numbered differently",
+		"\tif e = g.AddClass(gorex.Lowers); e != nil { panic(e) }\n\tif e = g.ApplyQuantifier(gorex.OneOrMore); e != nil { panic(e) }\n\tif e = g.ApplyAnchor(\"^\"); e != nil { panic(e) }\n",
+		"\tdate, err := re.Compile(func() string {\n\t\t// TODO(gorex migrate): groups are numbered differently: 3 groups were 2",
+		"\t\tif e = g.NameGroup(\"year\"); e != nil { panic(e) }\n",
+		"\t\treturn o\n\t}())\n",
+		"bad := /* TODO(gorex migrate): not migrated: error parsing regexp",
+		"dynamic := re.MustCompile(s)",
+	}) {
+		if !strings.Contains(o, w) { t.Fatalf("migrate: output lacks %q:\n%s\n", w, o) }
+	}
+
+	// names only kept on top-level groups
+	named, _, _ := migrate("x.go", []byte("package x\n\nimport \"regexp\"\n\nvar r = regexp.MustCompile(`(?P<key>[a-z]+)=((?P<value>[0-9]+)s)`)\n"))
+	for _, w := range([]string{
+		"// TODO(gorex migrate): named groups are not kept: value is group 3\n",
+		"\tif e = g.NameGroup(\"key\"); e != nil { panic(e) }\n",
+	}) {
+		if !strings.Contains(string(named), w) { t.Fatalf("migrate: output lacks %q:\n%s\n", w, named) }
+	}
+	if strings.Contains(string(named), "key is group") { t.Fatalf("migrate: kept name noted as lost:\n%s\n", named) }
+
+	// nothing left to migrate
+	again, n, _ := migrate("x.go", out)
+	if n != 0 || string(again) != o { t.Fatalf("migrate: second run changed the source:\n%s\n", again) }
+
+	// without regexp, or with gorex already imported
+	src := "package x\n\nimport \"regexp\"\nimport \"github.com/dev-west/gorex\"\n\nvar r = regexp.MustCompile(\"a+\")\nvar _ = gorex.Digits\n"
+	out, _, _ = migrate("x.go", []byte(src))
+	if strings.Count(string(out), "github.com/dev-west/gorex") != 1 { t.Fatalf("migrate: import added again:\n%s\n", out) }
+	out, _, _ = migrate("x.go", []byte("package x\n\nvar r = regexp.MustCompile(\"a+\")\n"))
+	if string(out) != "package x\n\nvar r = regexp.MustCompile(\"a+\")\n" { t.Fatalf("migrate: changed a file without regexp\n") }
+}
+
+func TestMigrateCommand(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "sub", "x.go")
+	mustWrite(t, name, migrateSource)
+	mustWrite(t, filepath.Join(dir, "testdata", "y.go"), migrateSource)
+
+	// dry run
+	c, o, _ := runWith("", "migrate", dir + "/...")
+	if c != exitOK { t.Fatalf("migrate: exit %d\n", c) }
+	if !strings.HasPrefix(o, "--- " + name + "\n+++ " + name + "\n@@ -3,14 +3,48 @@\n") { t.Fatalf("migrate: unexpected diff:\n%s\n", o) }
+	if !strings.Contains(o, "\n-var email = re.MustCompile(`^[a-z]+@[a-z0-9]+\\.(com|net)$`)\n+var email = re.MustCompile(func() string {\n") {
+		t.Fatalf("migrate: diff lacks the change:\n%s\n", o)
+	}
+	if data, _ := os.ReadFile(name); string(data) != migrateSource { t.Fatalf("migrate: dry run changed the file\n") }
+
+	c, o, _ = runWith("", "migrate", "-w", dir + "/...")
+	if c != exitOK || o != name + ": 2 expressions migrated\n" { t.Fatalf("migrate -w: exit %d output %q\n", c, o) }
+	data, _ := os.ReadFile(name)
+	want, _, _ := migrate("x.go", []byte(migrateSource))
+	if string(data) != string(want) { t.Fatalf("migrate -w: unexpected file:\n%s\n", data) }
+	if data, _ := os.ReadFile(filepath.Join(dir, "testdata", "y.go")); string(data) != migrateSource { t.Fatalf("migrate -w: changed testdata\n") }
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+	w := "--- f\n+++ f\n@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n"
+	if d := unifiedDiff("f", a, b); d != w { t.Fatalf("unifiedDiff: %q != %q\n", d, w) }
+	if d := unifiedDiff("f", "", "x\n"); d != "--- f\n+++ f\n@@ -0,0 +1,1 @@\n+x\n" { t.Fatalf("unifiedDiff: %q\n", d) }
+}
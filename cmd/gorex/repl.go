@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dev-west/gorex"
+)
+
+const replHelp = `builder statements, as in definition files:
+  GolangExpression [Unsafe]     start over, optionally unsafe
+  AddClass name|"class"...      new class group
+  AddClassToLast name|"class"...
+  AddFixed "text"...            new fixed group
+  AddFixedToLast "text"...
+  ApplyQuantifier name [n [m]]
+  AddNumericRange min max [name...]
+  ApplyAnchor "^"
+  NameGroup "name"              name the last group
+  SetFlags name|"flags"...
+  ClearFlags name|"flags"...
+  ShouldMatch "text"...         examples checked by verify
+  ShouldNotMatch "text"...
+  ShouldMatchGroups "text" "group 1"...
+session commands:
+  undo                          remove the last statement
+  reset                         remove every statement
+  show                          print the expression
+  list                          print the session as a definition
+  explain                       describe the expression
+  test text                     match text (optionally quoted) against the expression
+  verify                        check the examples
+  save file                     save as go code (.go), JSON (.json) or a definition
+  load file                     replace the session with a saved file
+  help                          print this text
+  quit                          leave
+`
+
+// an interactive session; every statement is replayed on undo
+type session struct {
+	statements []string
+	g *gorex.Gorex
+}
+
+func newSession() *session {
+	g, _ := gorex.GolangExpression()
+	return &session{ nil, g }
+}
+
+// rebuilds the expression from statements, leaving the session untouched
+// on error
+func (s *session) replay(statements []string) error {
+	g, _ := gorex.GolangExpression()
+	for _, st := range(statements) {
+		if err := g.Exec(st); err != nil { return err }
+	}
+	s.statements = statements
+	s.g = g
+
+	return nil
+}
+
+func (s *session) exec(statement string) error {
+	return s.replay(append(s.statements[:len(s.statements):len(s.statements)], statement))
+}
+
+func (s *session) undo() bool {
+	if len(s.statements) == 0 { return false }
+	s.replay(s.statements[:len(s.statements) - 1])
+
+	return true
+}
+
+// replaces the session with the canonical statements of g
+func (s *session) load(g *gorex.Gorex) error {
+	d, err := g.Definition()
+	if err != nil { return err }
+
+	return s.replay(strings.Split(strings.TrimSuffix(d, "\n"), "\n"))
+}
+
+func (s *session) save(name string) error {
+	var o string
+	var err error
+	switch(filepath.Ext(name)) {
+	case ".go":
+		if o, err = s.g.GoSource("expression"); err == nil {
+			o = "package main\n\nimport \"github.com/dev-west/gorex\"\n\n// expression builds the saved session\n" + o
+		}
+	case ".json":
+		o, err = formatJSON(s.g)
+	default:
+		o, err = s.g.Definition()
+	}
+	if err != nil { return err }
+
+	return os.WriteFile(name, []byte(o), 0644)
+}
+
+func loadFile(name string) (*gorex.Gorex, error) {
+	data, err := os.ReadFile(name)
+	if err != nil { return nil, err }
+	if filepath.Ext(name) == ".go" { return parseGoSource(data) }
+
+	return parseDefinition(data)
+}
+
+// builder methods recognised in go source
+var builderMethods = map[string]bool {
+	"GolangExpression": true,
+	"AddClass": true,
+	"AddClassToLast": true,
+	"AddFixed": true,
+	"AddFixedToLast": true,
+	"ApplyQuantifier": true,
+	"AddNumericRange": true,
+	"ApplyAnchor": true,
+	"NameGroup": true,
+	"SetFlags": true,
+	"ClearFlags": true,
+	"ShouldMatch": true,
+	"ShouldNotMatch": true,
+	"ShouldMatchGroups": true,
+}
+
+// converts a go argument to statement words; flag sums become several words
+func goArgWords(x ast.Expr) ([]string, error) {
+	switch a := x.(type) {
+	case *ast.SelectorExpr:
+		return []string{ a.Sel.Name }, nil
+	case *ast.Ident:
+		return []string{ a.Name }, nil
+	case *ast.BasicLit:
+		if a.Kind == token.STRING {
+			v, err := strconv.Unquote(a.Value)
+			if err != nil { return nil, err }
+			return []string{ strconv.Quote(v) }, nil
+		}
+		return []string{ a.Value }, nil
+	case *ast.UnaryExpr:
+		if lit, ok := a.X.(*ast.BasicLit); ok && a.Op == token.SUB { return []string{ "-" + lit.Value }, nil }
+	case *ast.BinaryExpr:
+		if a.Op == token.ADD {
+			l, err := goArgWords(a.X)
+			if err != nil { return nil, err }
+			r, err := goArgWords(a.Y)
+			if err != nil { return nil, err }
+			return append(l, r...), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported argument %T", x)
+}
+
+// rebuilds an expression from the builder calls in go source, in source
+// order, such as the code written by save
+func parseGoSource(data []byte) (*gorex.Gorex, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", data, 0)
+	if err != nil { return nil, err }
+
+	g, _ := gorex.GolangExpression()
+	ast.Inspect(f, func(n ast.Node) bool {
+		if err != nil { return false }
+		call, ok := n.(*ast.CallExpr)
+		if !ok { return true }
+		var name string
+		switch fn := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			name = fn.Sel.Name
+		case *ast.Ident: // dot import
+			name = fn.Name
+		}
+		if !builderMethods[name] { return true }
+
+		words := []string{ name }
+		for _, a := range(call.Args) {
+			w, e := goArgWords(a)
+			if e != nil {
+				err = fmt.Errorf("%s: %w", fset.Position(a.Pos()), e)
+				return false
+			}
+			words = append(words, w...)
+		}
+		if e := g.Exec(strings.Join(words, " ")); e != nil {
+			err = fmt.Errorf("%s: %w", fset.Position(call.Pos()), e)
+		}
+		return true
+	})
+	if err != nil { return nil, err }
+
+	return g, nil
+}
+
+// matches sample text against the expression
+func (s *session) test(e *env, text string) error {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "`") {
+		t, err := strconv.Unquote(text)
+		if err != nil { return err }
+		text = t
+	}
+	o, err := s.g.Output()
+	if err != nil { return err }
+	rex, err := regexp.Compile(o)
+	if err != nil { return err }
+
+	m := rex.FindStringSubmatchIndex(text)
+	switch {
+	case m == nil:
+		fmt.Fprintln(e.stdout, "no match")
+		return nil
+	case m[0] == 0 && m[1] == len(text):
+		fmt.Fprintln(e.stdout, "matches the whole text")
+	default:
+		fmt.Fprintf(e.stdout, "matches %s at %d\n", strconv.Quote(text[m[0]:m[1]]), m[0])
+	}
+	fmt.Fprint(e.stdout, formatGroups(rex, text, m))
+
+	return nil
+}
+
+func (s *session) show(e *env) {
+	o, err := s.g.Output()
+	if err != nil {
+		fmt.Fprintf(e.stdout, "error: %s\n", err)
+		return
+	}
+	fmt.Fprintf(e.stdout, "=> %s\n", o)
+}
+
+// runs one command line, returning false to leave the session
+func (s *session) command(e *env, line string) (bool, error) {
+	line = strings.TrimSpace(line)
+	word := line
+	rest := ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		word = line[:i]
+		rest = strings.TrimSpace(line[i:])
+	}
+
+	switch(word) {
+	case "", "#":
+		return true, nil
+	case "quit", "exit":
+		return false, nil
+	case "help":
+		fmt.Fprint(e.stdout, replHelp)
+	case "undo":
+		if !s.undo() { return true, fmt.Errorf("nothing to undo") }
+		s.show(e)
+	case "reset":
+		s.replay(nil)
+		s.show(e)
+	case "show":
+		s.show(e)
+	case "list":
+		d, err := s.g.Definition()
+		if err != nil { return true, err }
+		fmt.Fprint(e.stdout, d)
+	case "explain":
+		x, err := s.g.Explain()
+		if err != nil { return true, err }
+		fmt.Fprint(e.stdout, x)
+	case "test":
+		return true, s.test(e, rest)
+	case "verify":
+		if err := s.g.Verify(); err != nil { return true, err }
+		fmt.Fprintln(e.stdout, "examples pass")
+	case "save":
+		if rest == "" { return true, fmt.Errorf("save requires a file") }
+		if err := s.save(rest); err != nil { return true, err }
+		fmt.Fprintf(e.stdout, "saved %s\n", rest)
+	case "load":
+		if rest == "" { return true, fmt.Errorf("load requires a file") }
+		g, err := loadFile(rest)
+		if err != nil { return true, err }
+		if err = s.load(g); err != nil { return true, err }
+		s.show(e)
+	default:
+		if strings.HasPrefix(word, "#") { return true, nil }
+		if word == "GolangExpression" {
+			if err := s.replay([]string{ line }); err != nil { return true, err }
+		} else if err := s.exec(line); err != nil {
+			return true, err
+		}
+		s.show(e)
+	}
+
+	return true, nil
+}
+
+// gorex repl: builds an expression statement by statement
+func runRepl(e *env, args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	quiet := fs.Bool("q", false, "no prompt or banner, for scripted input")
+	if err := fs.Parse(args); err != nil { return exitError }
+
+	s := newSession()
+	if fs.NArg() > 1 {
+		fmt.Fprintln(e.stderr, "gorex: too many arguments")
+		return exitError
+	}
+	if fs.NArg() == 1 {
+		g, err := loadFile(fs.Arg(0))
+		if err == nil { err = s.load(g) }
+		if err != nil { return fail(e, err) }
+	}
+
+	if !*quiet { fmt.Fprintln(e.stdout, "gorex repl; type help for commands") }
+	if len(s.statements) != 0 { s.show(e) }
+	in := bufio.NewScanner(e.stdin)
+	for {
+		if !*quiet { fmt.Fprint(e.stdout, "gorex> ") }
+		if !in.Scan() { break }
+		more, err := s.command(e, in.Text())
+		if err != nil { fmt.Fprintf(e.stdout, "error: %s\n", err) }
+		if !more { return exitOK }
+	}
+	if !*quiet { fmt.Fprintln(e.stdout) }
+	if err := in.Err(); err != nil { return fail(e, err) }
+
+	return exitOK
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	script := strings.Join([]string{
+		"AddClass Uppers",
+		"AddClassToLast Lowers",
+		"ApplyQuantifier OneOrMore",
+		"AddFixed \"@\"",
+		"AddClass Nope",
+		"undo",
+		"test \"say Hi there\"",
+		"quit",
+		"AddFixed \"never\"",
+	}, "\n")
+	c, o, _ := runWith(script, "repl", "-q")
+	w := strings.Join([]string{
+		"=> ([A-Z])",
+		"=> ([A-Za-z])",
+		"=> ([A-Za-z]+)",
+		"=> ([A-Za-z]+)(@)",
+		"error: Gorex @209: invalid class",
+		"=> ([A-Za-z]+)",
+		"matches \"say\" at 0",
+		"\t1: \"say\"",
+		"",
+	}, "\n")
+	if c != exitOK || o != w { t.Fatalf("repl: exit %d output\n%s\n!=\n%s\n", c, o, w) }
+
+	c, o, _ = runWith("undo\nbogus\n", "repl", "-q")
+	if c != exitOK || !strings.HasPrefix(o, "error: nothing to undo\nerror: ") || !strings.HasSuffix(o, "unknown statement \"bogus\"\n") {
+		t.Fatalf("repl errors: exit %d output %q\n", c, o)
+	}
+
+	c, o, _ = runWith("AddFixed \"a\"\nShouldMatch \"a\"\nverify\nShouldNotMatch \"a\"\nverify\n", "repl", "-q")
+	if c != exitOK || !strings.Contains(o, "examples pass\n") || !strings.HasSuffix(o, "\t\"a\" should not match\n") {
+		t.Fatalf("repl verify: exit %d output %q\n", c, o)
+	}
+}
+
+func TestReplSaveLoad(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gorex")
+	if err != nil { t.Fatal(err) }
+	defer os.RemoveAll(dir)
+
+	build := "AddClass Digits\nApplyQuantifier MinToMax 2 4\nSetFlags CaseInsensitive MultiLineMode\nAddFixed \"px\" \"em\"\n"
+	for _, ext := range([]string{ ".go", ".json", ".gorex" }) {
+		name := filepath.Join(dir, "session" + ext)
+		c, _, _ := runWith(build + "save " + name + "\n", "repl", "-q")
+		if c != exitOK { t.Fatalf("repl save %s: exit %d\n", ext, c) }
+
+		c, o, _ := runWith("reset\nload " + name + "\n", "repl", "-q")
+		w := "=> \n=> (?im)([0-9]{2,4})(?-im)(px|em)\n"
+		if c != exitOK || o != w { t.Fatalf("repl load %s: exit %d output %q != %q\n", ext, c, o, w) }
+
+		// a saved session is also a starting point
+		c, o, _ = runWith("", "repl", "-q", name)
+		w = "=> (?im)([0-9]{2,4})(?-im)(px|em)\n"
+		if c != exitOK || o != w { t.Fatalf("repl %s: exit %d output %q != %q\n", ext, c, o, w) }
+	}
+}
+
+func TestParseGoSource(t *testing.T) {
+	src := `package p
+
+import . "github.com/dev-west/gorex"
+
+func f() {
+	g, _ := GolangExpression(Unsafe)
+	g.AddClass("a-f")
+	g.AddClassToLast(Digits)
+	g.ApplyQuantifier(MinOrMore, 3)
+	g.AddFixed("x")
+	g.SetFlags(CaseInsensitive + "s")
+}
+`
+	g, err := parseGoSource([]byte(src))
+	if err != nil { t.Fatalf("parseGoSource() unexpected error: %s\n", err) }
+	o, _ := g.Output()
+	if w := "([a-f0-9]{3,})(?is)(x)"; o != w { t.Fatalf("parseGoSource() output %q != %q\n", o, w) }
+
+	_, err = parseGoSource([]byte("package p\nfunc f() { g.AddClass(classes[0]) }\n"))
+	if err == nil { t.Fatalf("parseGoSource() expected unsupported argument error\n") }
+}
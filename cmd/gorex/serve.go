@@ -0,0 +1,217 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dev-west/gorex"
+)
+
+//go:embed ui/index.html
+var indexPage []byte
+
+// constants offered by the builder UI
+type constant struct {
+	Name string `json:"name"`
+	Value string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Args int `json:"args,omitempty"`
+}
+
+type constants struct {
+	Classes []constant `json:"classes"`
+	Quantifiers []constant `json:"quantifiers"`
+	Flags []constant `json:"flags"`
+	Dialects []constant `json:"dialects"`
+}
+
+// the library's constants as the builder UI takes them
+func constantsOf(cs []gorex.Constant) []constant {
+	var out []constant
+	for _, c := range(cs) { out = append(out, constant{ c.Name, c.Value, c.Description, c.Args }) }
+
+	return out
+}
+
+var builderConstants = constants {
+	Classes: constantsOf(gorex.Classes()),
+	Quantifiers: constantsOf(gorex.Quantifiers()),
+	Flags: constantsOf(gorex.Flags()),
+	Dialects: constantsOf(gorex.Dialects()),
+}
+
+// api request: an expression as JSON groups or as a definition
+type apiRequest struct {
+	Gorex json.RawMessage `json:"gorex,omitempty"`
+	Definition string `json:"definition,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+type apiBuild struct {
+	Output string `json:"output"`
+	Explain string `json:"explain"`
+	Definition string `json:"definition"`
+	Gorex json.RawMessage `json:"gorex"`
+	Dialects map[string]string `json:"dialects"`
+}
+
+type apiGroup struct {
+	Index int `json:"index"`
+	Name string `json:"name,omitempty"`
+	Start int `json:"start"`
+	End int `json:"end"`
+	Text string `json:"text"`
+}
+
+type apiMatch struct {
+	Start int `json:"start"`
+	End int `json:"end"`
+	Text string `json:"text"`
+	Groups []apiGroup `json:"groups"`
+}
+
+type apiMatches struct {
+	Output string `json:"output"`
+	Matches []apiMatch `json:"matches"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// decodes a POSTed request and its expression; writes the error response
+// and returns nil on failure
+func readRequest(w http.ResponseWriter, r *http.Request) (*apiRequest, *gorex.Gorex) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, apiError{ "method not allowed" })
+		return nil, nil
+	}
+
+	var req apiRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1 << 20)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{ err.Error() })
+		return nil, nil
+	}
+
+	var g *gorex.Gorex
+	var err error
+	switch {
+	case len(req.Gorex) != 0:
+		g, _ = gorex.GolangExpression()
+		err = json.Unmarshal(req.Gorex, g)
+	case req.Definition != "":
+		g, err = gorex.ParseDefinition(strings.NewReader(req.Definition))
+	default:
+		err = errors.New("request requires gorex or definition")
+	}
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, apiError{ err.Error() })
+		return nil, nil
+	}
+
+	return &req, g
+}
+
+// GET /api/constants: classes, quantifiers, flags and dialects
+func handleConstants(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, builderConstants)
+}
+
+// POST /api/build: output, explanation, canonical forms and dialects
+func handleBuild(w http.ResponseWriter, r *http.Request) {
+	_, g := readRequest(w, r)
+	if g == nil { return }
+
+	var b apiBuild
+	var err error
+	if b.Output, err = g.Output(); err == nil {
+		b.Explain, err = g.Explain()
+	}
+	if err == nil { b.Definition, err = g.Definition() }
+	if err == nil { b.Gorex, err = json.Marshal(g) }
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, apiError{ err.Error() })
+		return
+	}
+	b.Dialects = map[string]string{ }
+	for _, d := range(builderConstants.Dialects) {
+		o, err := g.OutputDialect(gorex.Dialect(d.Value))
+		if err != nil { o = "error: " + err.Error() }
+		b.Dialects[d.Value] = o
+	}
+
+	writeJSON(w, http.StatusOK, b)
+}
+
+// POST /api/match: every match of the expression in text, with groups;
+// offsets are byte offsets
+func handleMatch(w http.ResponseWriter, r *http.Request) {
+	req, g := readRequest(w, r)
+	if g == nil { return }
+
+	o, err := g.Output()
+	var rex *regexp.Regexp
+	if err == nil { rex, err = regexp.Compile(o) }
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, apiError{ err.Error() })
+		return
+	}
+
+	res := apiMatches{ o, []apiMatch{ } }
+	names := rex.SubexpNames()
+	for _, m := range(rex.FindAllStringSubmatchIndex(req.Text, -1)) {
+		am := apiMatch{ m[0], m[1], req.Text[m[0]:m[1]], []apiGroup{ } }
+		for i := 1; i < len(m) / 2; i++ {
+			if m[2*i] < 0 { continue }
+			am.Groups = append(am.Groups, apiGroup{ i, names[i], m[2*i], m[2*i+1], req.Text[m[2*i]:m[2*i+1]] })
+		}
+		res.Matches = append(res.Matches, am)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexPage)
+	})
+	mux.HandleFunc("/api/constants", handleConstants)
+	mux.HandleFunc("/api/build", handleBuild)
+	mux.HandleFunc("/api/match", handleMatch)
+
+	return mux
+}
+
+// gorex serve: local web playground and its JSON API
+func runServe(e *env, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	addr := fs.String("addr", "localhost:8080", "listen `address`")
+	if err := fs.Parse(args); err != nil { return exitError }
+	if fs.NArg() != 0 {
+		fmt.Fprintln(e.stderr, "gorex: too many arguments")
+		return exitError
+	}
+
+	fmt.Fprintf(e.stdout, "serving http://%s/\n", *addr)
+	return fail(e, http.ListenAndServe(*addr, newServeMux()))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// posts body to the api, decoding the response into v
+func post(t *testing.T, srv *httptest.Server, path string, body string, v interface{}) int {
+	r, err := http.Post(srv.URL + path, "application/json", strings.NewReader(body))
+	if err != nil { t.Fatalf("POST %s: %s\n", path, err) }
+	defer r.Body.Close()
+	if err = json.NewDecoder(r.Body).Decode(v); err != nil { t.Fatalf("POST %s: decoding response: %s\n", path, err) }
+
+	return r.StatusCode
+}
+
+func TestServeIndex(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	r, err := http.Get(srv.URL + "/")
+	if err != nil { t.Fatal(err) }
+	r.Body.Close()
+	if r.StatusCode != http.StatusOK || !strings.HasPrefix(r.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /: status %d content type %q\n", r.StatusCode, r.Header.Get("Content-Type"))
+	}
+
+	r, err = http.Get(srv.URL + "/missing")
+	if err != nil { t.Fatal(err) }
+	r.Body.Close()
+	if r.StatusCode != http.StatusNotFound { t.Fatalf("GET /missing: status %d\n", r.StatusCode) }
+}
+
+func TestServeConstants(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	r, err := http.Get(srv.URL + "/api/constants")
+	if err != nil { t.Fatal(err) }
+	defer r.Body.Close()
+	var c constants
+	if err = json.NewDecoder(r.Body).Decode(&c); err != nil { t.Fatal(err) }
+	if len(c.Classes) != 14 || len(c.Quantifiers) != 13 || len(c.Flags) != 4 || len(c.Dialects) != 4 {
+		t.Fatalf("GET /api/constants: unexpected counts %d %d %d %d\n", len(c.Classes), len(c.Quantifiers), len(c.Flags), len(c.Dialects))
+	}
+	// the descriptions Explain gives
+	for _, k := range(c.Classes) {
+		if k.Name == "Blank" && k.Description != "blanks" { t.Fatalf("GET /api/constants: Blank is %q\n", k.Description) }
+	}
+}
+
+func TestServeBuild(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	var b apiBuild
+	s := post(t, srv, "/api/build", `{"gorex":{"groups":[{"tokens":[{"class":"A-Z","quantifier":"MinToMax","args":[2,3]}],"flags":"U"}]}}`, &b)
+	if s != http.StatusOK { t.Fatalf("POST /api/build: status %d\n", s) }
+	if b.Output != "(?U)([A-Z]{2,3})" { t.Fatalf("POST /api/build: output %q\n", b.Output) }
+	if b.Dialects["javascript"] != "([A-Z]{2,3}?)" { t.Fatalf("POST /api/build: javascript %q\n", b.Dialects["javascript"]) }
+	if !strings.HasPrefix(b.Dialects["posix"], "error: ") { t.Fatalf("POST /api/build: posix %q\n", b.Dialects["posix"]) }
+	if !strings.HasPrefix(b.Explain, "group 1: ") || !strings.Contains(b.Definition, "SetFlags UngreedySwap\n") {
+		t.Fatalf("POST /api/build: explain %q definition %q\n", b.Explain, b.Definition)
+	}
+
+	// definitions are accepted too, and returned as groups
+	b = apiBuild{ }
+	s = post(t, srv, "/api/build", `{"definition":"AddFixed \"com\" \"net\""}`, &b)
+	if s != http.StatusOK || b.Output != "(com|net)" || !strings.Contains(string(b.Gorex), `"fixed":"net"`) {
+		t.Fatalf("POST /api/build definition: status %d output %q gorex %s\n", s, b.Output, b.Gorex)
+	}
+
+	// builder errors
+	var e apiError
+	s = post(t, srv, "/api/build", `{"definition":"AddClass \"?!\""}`, &e)
+	if s != http.StatusUnprocessableEntity || !strings.Contains(e.Error, "invalid class") {
+		t.Fatalf("POST /api/build invalid: status %d error %q\n", s, e.Error)
+	}
+	s = post(t, srv, "/api/build", `{}`, &e)
+	if s != http.StatusUnprocessableEntity { t.Fatalf("POST /api/build empty: status %d\n", s) }
+	s = post(t, srv, "/api/build", `{`, &e)
+	if s != http.StatusBadRequest { t.Fatalf("POST /api/build malformed: status %d\n", s) }
+
+	r, err := http.Get(srv.URL + "/api/build")
+	if err != nil { t.Fatal(err) }
+	r.Body.Close()
+	if r.StatusCode != http.StatusMethodNotAllowed { t.Fatalf("GET /api/build: status %d\n", r.StatusCode) }
+}
+
+func TestServeMatch(t *testing.T) {
+	srv := httptest.NewServer(newServeMux())
+	defer srv.Close()
+
+	var m apiMatches
+	s := post(t, srv, "/api/match", `{"definition":"AddClass Digits\nApplyQuantifier OneOrMore\nAddFixed \"px\" \"em\"","text":"10px, 2em, 3pt"}`, &m)
+	if s != http.StatusOK || len(m.Matches) != 2 { t.Fatalf("POST /api/match: status %d matches %#v\n", s, m.Matches) }
+
+	w := apiMatch{ 6, 9, "2em", []apiGroup{ { 1, "", 6, 7, "2" }, { 2, "", 7, 9, "em" } } }
+	got := m.Matches[1]
+	if got.Start != w.Start || got.End != w.End || got.Text != w.Text || len(got.Groups) != 2 || got.Groups[0] != w.Groups[0] || got.Groups[1] != w.Groups[1] {
+		t.Fatalf("POST /api/match: %#v != %#v\n", got, w)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// gorex test: reports every match of a definition in files or stdin, line by
+// line, with the text of each group
+func runTest(e *env, args []string) int {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	quiet := fs.Bool("q", false, "report nothing, only set the exit code")
+	whole := fs.Bool("x", false, "match whole lines only")
+	if err := fs.Parse(args); err != nil { return exitError }
+	if fs.NArg() == 0 {
+		fmt.Fprintln(e.stderr, "gorex: test requires a definition")
+		return exitError
+	}
+
+	g, err := loadDefinition(e, fs.Arg(0))
+	if err != nil { return fail(e, err) }
+	o, err := g.Output()
+	if err != nil { return fail(e, err) }
+	if *whole { o = "^(?:" + o + ")$" }
+	rex, err := regexp.Compile(o)
+	if err != nil { return fail(e, err) }
+
+	files := fs.Args()[1:]
+	if len(files) == 0 { files = []string{ "-" } }
+	status := exitNoMatch
+	for _, name := range(files) {
+		var found bool
+		if name == "-" {
+			found, err = testReader(e, rex, name, e.stdin, *quiet)
+		} else {
+			var f *os.File
+			if f, err = os.Open(name); err != nil { return fail(e, err) }
+			found, err = testReader(e, rex, name, f, *quiet)
+			f.Close()
+		}
+		if err != nil { return fail(e, err) }
+		if found { status = exitOK }
+	}
+
+	return status
+}
+
+func testReader(e *env, rex *regexp.Regexp, name string, r io.Reader, quiet bool) (bool, error) {
+	found := false
+	w := bufio.NewWriter(e.stdout)
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1 << 20)
+	n := 0
+	for s.Scan() {
+		n++
+		line := s.Text()
+		for _, m := range(rex.FindAllStringSubmatchIndex(line, -1)) {
+			found = true
+			if quiet { continue }
+			fmt.Fprintf(w, "%s:%d:%d: %s\n", name, n, m[0] + 1, strconv.Quote(line[m[0]:m[1]]))
+			w.WriteString(formatGroups(rex, line, m))
+		}
+	}
+	if err := s.Err(); err != nil { return found, err }
+
+	return found, w.Flush()
+}
+
+// one indented line per group: index, name if any, and captured text
+func formatGroups(rex *regexp.Regexp, line string, m []int) string {
+	o := bytes.NewBufferString("")
+	names := rex.SubexpNames()
+	for i := 1; i < len(m) / 2; i++ {
+		label := strconv.Itoa(i)
+		if names[i] != "" { label += " " + names[i] }
+		if m[2*i] < 0 {
+			fmt.Fprintf(o, "\t%s: -\n", label)
+			continue
+		}
+		fmt.Fprintf(o, "\t%s: %s\n", label, strconv.Quote(line[m[2*i]:m[2*i+1]]))
+	}
+
+	return o.String()
+}
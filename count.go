@@ -0,0 +1,382 @@
+package gorex
+
+// counting and enumeration
+//
+// the strings an expression matches as a whole, as with examples: Count
+// gives how many there are and Strings lists them
+//
+//  n, e := rex.Count()                          // nil when there is no end to them
+//  it, e := rex.Strings(LexicographicOrder)
+//  for it.Next() { fmt.Println(it.Text()) }
+//
+// strings are counted and listed as valid utf-8, once each however many
+// ways the expression matches them.
+
+import (
+	"errors"
+	"math/big"
+	"regexp/syntax"
+	"unicode"
+)
+
+// Order is the order Strings lists matched strings in
+type Order int
+
+const (
+	// shortest first, strings of a length in lexicographic order; lists
+	// any expression, without end if it matches infinitely many strings
+	LengthOrder Order = iota
+	// lexicographic order of the utf-8 text; requires a finite language
+	LexicographicOrder
+)
+
+// Count returns the number of distinct strings the expression matches as a
+// whole, or nil when there are infinitely many
+//
+// the count comes from the groups, their alternatives and quantifiers; only
+// when it cannot be shown that each string matches one way, or for anchors,
+// is an automaton built, which is limited in size
+func (g *Gorex) Count() (*big.Int, error) {
+	groups, e := g.parseGroups()
+	if e != nil { return nil, e }
+	t := emptyTally()
+	for _, alts := range(groups) {
+		u := classTally(nil)
+		for _, re := range(alts) { u = unionTally(u, syntaxTally(re)) }
+		t = concatTally(t, u)
+	}
+	if t.unsupported { return g.automatonCount() }
+	if t.n != nil && t.n.Sign() == 0 { return t.n, nil }
+	// an over-count still tells whether there is no end to the strings
+	if t.n == nil || t.exact { return t.n, nil }
+
+	return g.automatonCount()
+}
+
+// counts the strings accepted by the automaton of the expression
+func (g *Gorex) automatonCount() (*big.Int, error) {
+	ds, e := automata(g)
+	if e != nil { return nil, e }
+	d := ds[0]
+
+	live := d.live()
+	if !live[0] { return big.NewInt(0), nil }
+	if d.longest() < 0 { return nil, nil }
+
+	// strings accepted from each state; the live part is acyclic
+	counts := make([]*big.Int, len(d.trans))
+	var count func(s int) *big.Int
+	count = func(s int) *big.Int {
+		if counts[s] != nil { return counts[s] }
+		n := big.NewInt(0)
+		if d.accept[s] { n.SetInt64(1) }
+		for i, t := range(d.trans[s]) {
+			if t < 0 || !live[t] { continue }
+			n.Add(n, new(big.Int).Mul(big.NewInt(d.symbols[i].size()), count(t)))
+		}
+		counts[s] = n
+		return n
+	}
+
+	return count(0), nil
+}
+
+// the strings of part of an expression, in runes
+type tally struct {
+	n *big.Int // how many, nil when infinitely many; exact or more
+	exact bool // each string matches one way, so n is exact
+	unsupported bool // holds what is not counted here, such as anchors
+	min, max int // lengths; max is -1 without bound, min -1 with no strings
+	first []rune // ranges of the runes strings start with
+	run []rune // ranges of the class every string is a run of, if any
+	cells [][]rune // ranges at each position, when strings are all of them
+}
+
+// longest cells kept to tell alternatives apart
+const maxCells = 64
+
+func emptyTally() tally {
+	return tally{ n: big.NewInt(1), exact: true, cells: [][]rune{ } }
+}
+
+// strings of one rune of ranges; surrogate halves are left out as in
+// automata
+func classTally(ranges []rune) tally {
+	n := int64(0)
+	for i := 0; i + 1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		n += int64(hi - lo) + 1
+		if lo < 0xD800 { lo = 0xD800 }
+		if hi > 0xDFFF { hi = 0xDFFF }
+		if lo <= hi { n -= int64(hi - lo) + 1 }
+	}
+	if n == 0 { return tally{ n: big.NewInt(0), exact: true, min: -1 } }
+
+	return tally{ n: big.NewInt(n), exact: true, min: 1, max: 1, first: ranges, run: ranges, cells: [][]rune{ ranges } }
+}
+
+func syntaxTally(re *syntax.Regexp) tally {
+	switch(re.Op) {
+	case syntax.OpNoMatch:
+		return classTally(nil)
+	case syntax.OpEmptyMatch:
+		return emptyTally()
+	case syntax.OpLiteral:
+		t := emptyTally()
+		for _, r := range(re.Rune) {
+			class := []rune{ r, r }
+			if re.Flags & syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) { class = append(class, f, f) }
+			}
+			t = concatTally(t, classTally(normalizeRanges(class)))
+		}
+		return t
+	case syntax.OpCharClass:
+		return classTally(re.Rune)
+	case syntax.OpAnyCharNotNL:
+		return classTally([]rune{ 0, '\n' - 1, '\n' + 1, unicode.MaxRune })
+	case syntax.OpAnyChar:
+		return classTally([]rune{ 0, unicode.MaxRune })
+	case syntax.OpCapture:
+		return syntaxTally(re.Sub[0])
+	case syntax.OpConcat:
+		t := emptyTally()
+		for _, s := range(re.Sub) { t = concatTally(t, syntaxTally(s)) }
+		return t
+	case syntax.OpAlternate:
+		t := classTally(nil)
+		for _, s := range(re.Sub) { t = unionTally(t, syntaxTally(s)) }
+		return t
+	case syntax.OpStar:
+		return repeatTally(syntaxTally(re.Sub[0]), 0, -1)
+	case syntax.OpPlus:
+		return repeatTally(syntaxTally(re.Sub[0]), 1, -1)
+	case syntax.OpQuest:
+		return repeatTally(syntaxTally(re.Sub[0]), 0, 1)
+	case syntax.OpRepeat:
+		return repeatTally(syntaxTally(re.Sub[0]), re.Min, re.Max)
+	}
+
+	// anchors and word boundaries
+	return tally{ n: big.NewInt(0), unsupported: true, min: -1 }
+}
+
+func addLengths(a int, b int) int {
+	if a < 0 || b < 0 { return -1 }
+
+	return a + b
+}
+
+// strings of a followed by those of b
+func concatTally(a tally, b tally) tally {
+	t := tally{ unsupported: a.unsupported || b.unsupported }
+	switch {
+	case a.min < 0 || b.min < 0:
+		t.n, t.exact, t.min = big.NewInt(0), true, -1
+		return t
+	case a.n == nil || b.n == nil:
+		t.n = nil
+	default:
+		t.n = new(big.Int).Mul(a.n, b.n)
+	}
+
+	// the split between a and b is plain when either has one length, or
+	// when a is a run of a class b does not start with
+	t.exact = a.exact && b.exact && (a.min == a.max || b.min == b.max ||
+			(a.run != nil && b.min > 0 && !rangesOverlap(a.run, b.first)))
+	t.min, t.max = a.min + b.min, addLengths(a.max, b.max)
+	t.first = a.first
+	if a.min == 0 { t.first = normalizeRanges(append(append([]rune{ }, a.first...), b.first...)) }
+	if a.max == 0 { t.run = b.run }
+	if b.max == 0 { t.run = a.run }
+	if a.cells != nil && b.cells != nil && len(a.cells) + len(b.cells) <= maxCells {
+		t.cells = append(append([][]rune{ }, a.cells...), b.cells...)
+	}
+
+	return t
+}
+
+// whether no string is both of a and of b
+func disjoint(a tally, b tally) bool {
+	if a.max >= 0 && a.max < b.min || b.max >= 0 && b.max < a.min { return true }
+	if a.min == 0 && b.min == 0 { return false }
+	if !rangesOverlap(a.first, b.first) { return true }
+	if a.cells != nil && len(a.cells) == len(b.cells) {
+		for i := range(a.cells) {
+			if !rangesOverlap(a.cells[i], b.cells[i]) { return true }
+		}
+	}
+
+	return false
+}
+
+// strings of a or of b
+func unionTally(a tally, b tally) tally {
+	if a.min < 0 && !a.unsupported { return b }
+	if b.min < 0 && !b.unsupported { return a }
+
+	t := tally{ exact: a.exact && b.exact && disjoint(a, b), unsupported: a.unsupported || b.unsupported }
+	if a.n != nil && b.n != nil { t.n = new(big.Int).Add(a.n, b.n) }
+	t.min = min(a.min, b.min)
+	t.max = max(a.max, b.max)
+	if a.max < 0 || b.max < 0 { t.max = -1 }
+	t.first = normalizeRanges(append(append([]rune{ }, a.first...), b.first...))
+
+	return t
+}
+
+// strings of sub repeated from lo to hi times, hi being -1 without bound
+func repeatTally(sub tally, lo int, hi int) tally {
+	if sub.unsupported { return sub }
+	if hi == 0 || sub.max == 0 || sub.min < 0 && lo == 0 { return emptyTally() }
+	if sub.min < 0 { return sub }
+
+	t := tally{ exact: sub.exact, first: sub.first, run: sub.run, min: sub.min * lo, max: -1 }
+	if hi < 0 || sub.n == nil { return t }
+
+	// repeats of one length make strings of different lengths each time
+	t.exact = t.exact && sub.min == sub.max
+	t.max = sub.max * hi
+	t.n = big.NewInt(0)
+	p := new(big.Int).Exp(sub.n, big.NewInt(int64(lo)), nil)
+	for k := lo; k <= hi; k++ {
+		t.n.Add(t.n, p)
+		p.Mul(p, sub.n)
+	}
+	if lo == hi && sub.cells != nil && len(sub.cells) * lo <= maxCells {
+		for k := 0; k < lo; k++ { t.cells = append(t.cells, sub.cells...) }
+	}
+
+	return t
+}
+
+// Iterator lists the strings an expression matches; see Strings
+type Iterator struct {
+	d *dfa
+	order Order
+	live []bool
+	exact [][]bool // exact[k][s]: some input of k runes is accepted from s
+	length int // of the strings listed, in LengthOrder
+	max int // longest string, -1 without bound
+	started bool
+	stack []iterFrame
+	text []rune
+}
+
+// a state on the current path and the next rune to try from it
+type iterFrame struct {
+	state int
+	sym int
+	r rune
+}
+
+// Strings returns an iterator over the strings the expression matches as a
+// whole, in the given order
+func (g *Gorex) Strings(order Order) (*Iterator, error) {
+	if order != LengthOrder && order != LexicographicOrder { return nil, errors.New("Gorex @83: invalid order") }
+	ds, e := automata(g)
+	if e != nil { return nil, e }
+	d := ds[0]
+
+	it := &Iterator{ d: d, order: order, live: d.live() }
+	if !it.live[0] {
+		it.max = -2 // nothing to list
+		return it, nil
+	}
+	it.max = d.longest()
+	if order == LexicographicOrder && it.max < 0 {
+		return nil, errors.New("Gorex @95: lexicographic order requires a finite language")
+	}
+
+	return it, nil
+}
+
+// whether some input of k runes is accepted from s
+func (it *Iterator) accepts(s int, k int) bool {
+	for len(it.exact) <= k {
+		n := len(it.exact)
+		row := make([]bool, len(it.d.trans))
+		for st := range(row) {
+			if n == 0 {
+				row[st] = it.d.accept[st]
+				continue
+			}
+			for _, t := range(it.d.trans[st]) {
+				if t >= 0 && it.exact[n-1][t] {
+					row[st] = true
+					break
+				}
+			}
+		}
+		it.exact = append(it.exact, row)
+	}
+
+	return it.exact[k][s]
+}
+
+// visits, in preorder, the paths allowed by the order; true at the next
+// string to list
+func (it *Iterator) walk() bool {
+	allow := func(s int, depth int) bool {
+		if it.order == LexicographicOrder { return it.live[s] }
+		return depth <= it.length && it.accepts(s, it.length - depth)
+	}
+	emit := func(s int, depth int) bool {
+		if it.order == LexicographicOrder { return it.d.accept[s] }
+		return depth == it.length && it.d.accept[s]
+	}
+
+	if !it.started {
+		it.started = true
+		it.stack = []iterFrame{ { 0, 0, it.d.symbols[0].lo } }
+		it.text = it.text[:0]
+		if allow(0, 0) && emit(0, 0) { return true }
+	}
+	for len(it.stack) != 0 {
+		top := len(it.stack) - 1
+		f := &it.stack[top]
+		child, r := -1, rune(0)
+		for f.sym < len(it.d.symbols) {
+			sym := it.d.symbols[f.sym]
+			t := it.d.trans[f.state][f.sym]
+			if t < 0 || f.r > sym.hi || !allow(t, top + 1) {
+				f.sym++
+				if f.sym < len(it.d.symbols) { f.r = it.d.symbols[f.sym].lo }
+				continue
+			}
+			child, r = t, f.r
+			f.r++
+			break
+		}
+		if child < 0 {
+			it.stack = it.stack[:top]
+			if top > 0 { it.text = it.text[:top - 1] }
+			continue
+		}
+		it.text = append(it.text[:top], r)
+		it.stack = append(it.stack, iterFrame{ child, 0, it.d.symbols[0].lo })
+		if emit(child, top + 1) { return true }
+	}
+
+	return false
+}
+
+// Next advances to the next string, reporting false when there are no more
+func (it *Iterator) Next() bool {
+	if it.max == -2 { return false }
+	for {
+		if it.walk() { return true }
+		if it.order == LexicographicOrder { break }
+		if it.max >= 0 && it.length >= it.max { break }
+		it.length++
+		it.started = false
+	}
+	it.max = -2
+
+	return false
+}
+
+// Text returns the current string
+func (it *Iterator) Text() string {
+	return string(it.text)
+}
@@ -0,0 +1,105 @@
+package gorex
+
+import(
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	var g *Gorex
+
+	// country codes and digit runs
+	g, _ = GolangExpression()
+	g.AddFixed("us")
+	g.AddFixedToLast("uk")
+	g.AddFixedToLast("de")
+	g.AddClass(Digits)
+	g.ApplyQuantifier(MinToMax, 2, 3)
+	n, e := g.Count()
+	if e != nil || n.Cmp(big.NewInt(3 * 1100)) != 0 { t.Fatalf("Count() %v, %v != 3300\n", n, e) }
+
+	// strings matched more than one way count once
+	g, _ = GolangExpression()
+	g.AddFixed("a|ab")
+	g.AddFixed("c|bc")
+	if n, _ = g.Count(); n.Int64() != 3 { t.Fatalf("Count() ambiguous %v != 3\n", n) }
+
+	// case folding, k also folding to the kelvin sign
+	g, _ = GolangExpression()
+	g.AddFixed("ok")
+	g.SetFlags(CaseInsensitive)
+	if n, _ = g.Count(); n.Int64() != 6 { t.Fatalf("Count() folded %v != 6\n", n) }
+
+	// more than fits in an int64
+	g, _ = GolangExpression()
+	g.AddClass(HexDigits)
+	g.ApplyQuantifier(Exactly, 20)
+	w := new(big.Int).Exp(big.NewInt(22), big.NewInt(20), nil)
+	if n, _ = g.Count(); n.Cmp(w) != 0 { t.Fatalf("Count() %v != %v\n", n, w) }
+
+	// beyond what an automaton is built for
+	g, _ = GolangExpression()
+	for i := 0; i < 12; i++ {
+		g.AddClass(Digits)
+		g.ApplyQuantifier(Exactly, 1000)
+	}
+	w = new(big.Int).Exp(big.NewInt(10), big.NewInt(12000), nil)
+	if n, e = g.Count(); e != nil || n.Cmp(w) != 0 { t.Fatalf("Count() large bounded %v\n", e) }
+
+	// infinite and empty
+	if n, e = testEmail().Count(); n != nil || e != nil { t.Fatalf("Count() infinite %v, %v\n", n, e) }
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.AddFixed("b")
+	g.ApplyAnchor(atBeginning)
+	if n, _ = g.Count(); n.Sign() != 0 { t.Fatalf("Count() unmatchable %v != 0\n", n) }
+}
+
+func list(t *testing.T, g *Gorex, order Order, max int) []string {
+	it, e := g.Strings(order)
+	if e != nil { t.Fatalf("Strings() unexpected error: %s\n", e) }
+	var s []string
+	for len(s) < max && it.Next() { s = append(s, it.Text()) }
+
+	return s
+}
+
+func TestStrings(t *testing.T) {
+	g, _ := GolangExpression()
+	g.AddFixed("b")
+	g.AddFixedToLast("ab")
+	g.AddFixedToLast("a")
+	g.AddFixed("[0-2]")
+	g.ApplyQuantifier(ZeroOrOne)
+
+	lex := list(t, g, LexicographicOrder, 100)
+	w := "a a0 a1 a2 ab ab0 ab1 ab2 b b0 b1 b2"
+	if strings.Join(lex, " ") != w { t.Fatalf("Strings() lexicographic %v != %s\n", lex, w) }
+
+	length := list(t, g, LengthOrder, 100)
+	w = "a b a0 a1 a2 ab b0 b1 b2 ab0 ab1 ab2"
+	if strings.Join(length, " ") != w { t.Fatalf("Strings() length order %v != %s\n", length, w) }
+
+	// every listed string matches, once, and the count agrees
+	g, _ = GolangExpression()
+	g.AddFixed("a|ab")
+	g.AddFixed("c|bc")
+	g.AddClass(Digits)
+	g.ApplyQuantifier(MinToMax, 0, 2)
+	rex, _ := g.exampleRegexp()
+	n, _ := g.Count()
+	all := list(t, g, LexicographicOrder, 10000)
+	if int64(len(all)) != n.Int64() { t.Fatalf("Strings() listed %d != Count() %v\n", len(all), n) }
+	if !sort.StringsAreSorted(all) { t.Fatalf("Strings() not sorted\n") }
+	for i, s := range(all) {
+		if !rex.MatchString(s) { t.Fatalf("Strings() %q does not match\n", s) }
+		if i > 0 && all[i-1] == s { t.Fatalf("Strings() %q listed twice\n", s) }
+	}
+
+	// infinite languages list by length only
+	length = list(t, testEmail(), LengthOrder, 3)
+	if len(length) != 3 || length[0] != "A@a.com" { t.Fatalf("Strings() infinite %v\n", length) }
+	if _, e := testEmail().Strings(LexicographicOrder); e == nil { t.Fatalf("Strings() expected error for infinite lexicographic order\n") }
+}
@@ -0,0 +1,538 @@
+package gorex
+
+// definition files
+//
+// a definition is the sequence of builder calls that produces an expression,
+// one call per line, named after the builder methods and constants:
+//
+//  # e-mail user name
+//  GolangExpression
+//  AddClass Uppers
+//  AddClassToLast Lowers
+//  ApplyQuantifier OneOrMore
+//  AddFixed "."
+//  AddFixedToLast "_"
+//  ApplyQuantifier ZeroOrOne
+//  SetFlags CaseInsensitive
+//  ShouldMatch "Joe."
+//  ShouldNotMatch "_joe"
+//
+// classes, quantifiers and flags may be given by constant name or as quoted
+// go strings; fixed strings, anchors, group names and examples are always
+// quoted. the same expression can be stored as JSON through
+// MarshalJSON/UnmarshalJSON.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type namedConst struct {
+	name string
+	value string
+}
+
+// class constants, longest first so that splitting prefers fewer names
+var classNames = []namedConst {
+	{ "Punctuation", Punctuation },
+	{ "Words", Words },
+	{ "AlphaNumerics", AlphaNumerics },
+	{ "HexDigits", HexDigits },
+	{ "Control", Control },
+	{ "Alphabetics", Alphabetics },
+	{ "Whitespace", Whitespace },
+	{ "Ascii", Ascii },
+	{ "Digits", Digits },
+	{ "Graphical", Graphical },
+	{ "Lowers", Lowers },
+	{ "Printable", Printable },
+	{ "Uppers", Uppers },
+	{ "Blank", Blank },
+}
+
+var quantifierNames = []namedConst {
+	{ "Single", string(Single) },
+	{ "ZeroOrMore", string(ZeroOrMore) },
+	{ "OneOrMore", string(OneOrMore) },
+	{ "ZeroOrOne", string(ZeroOrOne) },
+	{ "MinToMax", string(MinToMax) },
+	{ "MinOrMore", string(MinOrMore) },
+	{ "Exactly", string(Exactly) },
+	{ "ZeroOrMorePrefFewer", string(ZeroOrMorePrefFewer) },
+	{ "OneOrMorePrefFewer", string(OneOrMorePrefFewer) },
+	{ "ZeroOrOnePrefFewer", string(ZeroOrOnePrefFewer) },
+	{ "MinToMaxPrefFewer", string(MinToMaxPrefFewer) },
+	{ "MinOrMorePrefFewer", string(MinOrMorePrefFewer) },
+	{ "ExactlyPrefFewer", string(ExactlyPrefFewer) },
+}
+
+var flagNames = []namedConst {
+	{ "CaseInsensitive", CaseInsensitive },
+	{ "MultiLineMode", MultiLineMode },
+	{ "PeriodMatchesNewline", PeriodMatchesNewline },
+	{ "UngreedySwap", UngreedySwap },
+}
+
+func lookupName(table []namedConst, name string) (string, bool) {
+	for _, n := range(table) {
+		if n.name == name { return n.value, true }
+	}
+
+	return "", false
+}
+
+func lookupValue(table []namedConst, value string) (string, bool) {
+	for _, n := range(table) {
+		if n.value == value { return n.name, true }
+	}
+
+	return "", false
+}
+
+// splits a class string into the class constants it was built from,
+// returns nil if the class is not a concatenation of constants
+func splitClass(c string) []string {
+	if c == NoClass { return nil }
+	for _, n := range(classNames) {
+		if !strings.HasPrefix(c, n.value) { continue }
+		if len(c) == len(n.value) { return []string{ n.name } }
+		if rest := splitClass(c[len(n.value):]); rest != nil {
+			return append([]string{ n.name }, rest...)
+		}
+	}
+
+	return nil
+}
+
+// splits a definition line into its words; quoted words are unquoted
+func splitStatement(line string) ([]string, error) {
+	var words []string
+	for {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line == "" || line[0] == '#' { return words, nil }
+		if line[0] == '"' || line[0] == '`' {
+			end := 1
+			for end < len(line) && line[end] != line[0] {
+				if line[0] == '"' && line[end] == '\\' { end++ }
+				end++
+			}
+			if end >= len(line) { return nil, errors.New("Gorex @123: invalid quoted string") }
+			w, e := strconv.Unquote(line[:end+1])
+			if e != nil { return nil, errors.New("Gorex @125: invalid quoted string") }
+			words = append(words, w)
+			line = line[end+1:]
+			continue
+		}
+		end := strings.IndexFunc(line, unicode.IsSpace)
+		if end < 0 { end = len(line) }
+		words = append(words, line[:end])
+		line = line[end:]
+	}
+}
+
+func constArg(table []namedConst, w string) string {
+	if v, ok := lookupName(table, w); ok { return v }
+	return w
+}
+
+// Exec applies a single definition statement to the expression
+func (g *Gorex) Exec(statement string) error {
+	words, e := splitStatement(statement)
+	if e != nil { return e }
+	if len(words) == 0 { return nil }
+
+	args := words[1:]
+	switch(words[0]) {
+	case "GolangExpression":
+		if len(g.groups) != 0 { return errors.New("Gorex @151: GolangExpression must be the first statement") }
+		n, e := GolangExpression(args...)
+		if e != nil { return e }
+		g.unsafe = n.unsafe
+		return nil
+	case "AddClass", "AddClassToLast":
+		if len(args) == 0 { return errors.New("Gorex @157: missing class") }
+		for i, a := range(args) {
+			c := constArg(classNames, a)
+			if i == 0 && words[0] == "AddClass" {
+				e = g.AddClass(c)
+			} else {
+				e = g.AddClassToLast(c)
+			}
+			if e != nil { return e }
+		}
+		return nil
+	case "AddFixed", "AddFixedToLast":
+		if len(args) == 0 { return errors.New("Gorex @169: missing fixed string") }
+		for i, a := range(args) {
+			if i == 0 && words[0] == "AddFixed" {
+				e = g.AddFixed(a)
+			} else {
+				e = g.AddFixedToLast(a)
+			}
+			if e != nil { return e }
+		}
+		return nil
+	case "ApplyQuantifier":
+		if len(args) == 0 { return errors.New("Gorex @180: missing quantifier") }
+		var argv []int
+		for _, a := range(args[1:]) {
+			n, e := strconv.Atoi(a)
+			if e != nil { return errors.New("Gorex @184: invalid quantifier argument") }
+			argv = append(argv, n)
+		}
+		return g.ApplyQuantifier(Quantifier(constArg(quantifierNames, args[0])), argv...)
+	case "AddNumericRange":
+		if len(args) < 2 { return errors.New("Gorex @186: missing numeric range") }
+		min, e := strconv.Atoi(args[0])
+		if e != nil { return errors.New("Gorex @187: invalid numeric range") }
+		max, e := strconv.Atoi(args[1])
+		if e != nil { return errors.New("Gorex @188: invalid numeric range") }
+		return g.AddNumericRange(min, max, args[2:]...)
+	case "ApplyAnchor":
+		if len(args) != 1 { return errors.New("Gorex @189: invalid anchor") }
+		return g.ApplyAnchor(Anchor(args[0]))
+	case "NameGroup":
+		if len(args) != 1 { return errors.New("Gorex @191: invalid group name") }
+		return g.NameGroup(args[0])
+	case "SetFlags", "ClearFlags":
+		if len(args) == 0 { return errors.New("Gorex @192: missing flag") }
+		var flags string
+		for _, a := range(args) { flags += constArg(flagNames, a) }
+		if words[0] == "SetFlags" { return g.SetFlags(flags) }
+		return g.ClearFlags(flags)
+	case "ShouldMatch":
+		g.ShouldMatch(args...)
+		return nil
+	case "ShouldNotMatch":
+		g.ShouldNotMatch(args...)
+		return nil
+	case "ShouldMatchGroups":
+		if len(args) == 0 { return errors.New("Gorex @196: missing example") }
+		g.ShouldMatchGroups(args[0], args[1:]...)
+		return nil
+	}
+
+	return fmt.Errorf("Gorex @199: unknown statement %q", words[0])
+}
+
+// ParseDefinition builds an expression from a definition, returning the first
+// failing statement with its line number
+func ParseDefinition(r io.Reader) (*Gorex, error) {
+	g, _ := GolangExpression()
+	s := bufio.NewScanner(r)
+	n := 0
+	for s.Scan() {
+		n++
+		if e := g.Exec(s.Text()); e != nil {
+			return nil, fmt.Errorf("line %d: %w", n, e)
+		}
+	}
+	if e := s.Err(); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// a builder call; names are bare, strings quoted and numbers plain
+type statement struct {
+	method string
+	args []string
+}
+
+// the builder calls that produce the expression
+func (g *Gorex) statements() ([]statement, error) {
+	var st []statement
+	if g.unsafe {
+		st = append(st, statement{ "GolangExpression", []string{ Unsafe } })
+	} else {
+		st = append(st, statement{ "GolangExpression", nil })
+	}
+
+	for _, gr := range(g.groups) {
+		// a numeric range is one statement, followed by what was added to it
+		start := gr.numericTokens()
+		if start != 0 { st = append(st, statement{ "AddNumericRange", gr.numeric }) }
+		for i := start; i < len(gr.tokens); i++ {
+			tk := gr.tokens[i]
+			if tk.class != NoClass {
+				if len(tk.fixed) != 0 { return nil, errors.New("Gorex @237: invalid token error") }
+				if i != 0 { return nil, errors.New("Gorex @238: class token cannot be defined") }
+				names := splitClass(tk.class)
+				if names == nil {
+					if !g.unsafe { return nil, errors.New("Gorex @241: invalid class") }
+					names = []string{ strconv.Quote(tk.class) }
+				}
+				st = append(st, statement{ "AddClass", names[:1] })
+				if len(names) > 1 { st = append(st, statement{ "AddClassToLast", names[1:] }) }
+			} else if i == 0 {
+				st = append(st, statement{ "AddFixed", []string{ strconv.Quote(tk.fixed) } })
+			} else {
+				st = append(st, statement{ "AddFixedToLast", []string{ strconv.Quote(tk.fixed) } })
+			}
+
+			if tk.quantifier.regexp != Single {
+				name, ok := lookupValue(quantifierNames, string(tk.quantifier.regexp))
+				if !ok { return nil, errors.New("Gorex @254: invalid quantifier") }
+				args := []string{ name }
+				for n := 0; n < strings.Count(string(tk.quantifier.regexp), "%d"); n++ {
+					args = append(args, strconv.Itoa(tk.quantifier.argv[n]))
+				}
+				st = append(st, statement{ "ApplyQuantifier", args })
+			}
+		}
+
+		var flags []string
+		if gr.flags.i { flags = append(flags, "CaseInsensitive") }
+		if gr.flags.m { flags = append(flags, "MultiLineMode") }
+		if gr.flags.s { flags = append(flags, "PeriodMatchesNewline") }
+		if gr.flags.U { flags = append(flags, "UngreedySwap") }
+		if len(flags) != 0 { st = append(st, statement{ "SetFlags", flags }) }
+		if gr.anchor != "" { st = append(st, statement{ "ApplyAnchor", []string{ strconv.Quote(string(gr.anchor)) } }) }
+		if gr.name != "" { st = append(st, statement{ "NameGroup", []string{ strconv.Quote(gr.name) } }) }
+	}
+
+	for _, x := range(g.examples) {
+		switch {
+		case !x.match:
+			st = append(st, statement{ "ShouldNotMatch", []string{ strconv.Quote(x.input) } })
+		case x.groups == nil:
+			st = append(st, statement{ "ShouldMatch", []string{ strconv.Quote(x.input) } })
+		default:
+			args := []string{ strconv.Quote(x.input) }
+			for _, gr := range(x.groups) { args = append(args, strconv.Quote(gr)) }
+			st = append(st, statement{ "ShouldMatchGroups", args })
+		}
+	}
+
+	return st, nil
+}
+
+// Definition produces the canonical definition of the expression; parsing it
+// with ParseDefinition yields an identical expression
+func (g *Gorex) Definition() (string, error) {
+	st, e := g.statements()
+	if e != nil { return "", e }
+
+	o := bytes.NewBufferString("")
+	for _, s := range(st) {
+		o.WriteString(s.method)
+		for _, a := range(s.args) { o.WriteString(" " + a) }
+		o.WriteString("\n")
+	}
+
+	return o.String(), nil
+}
+
+// go form of a statement argument
+func goArg(a string) string {
+	if strings.HasPrefix(a, "\"") { return a }
+	if _, e := strconv.Atoi(a); e == nil { return a }
+
+	return "gorex." + a
+}
+
+// writes the builder calls of st to o, each line indented by indent; fail
+// is the statement run when a call returns the error e
+func writeGoCalls(o *bytes.Buffer, st []statement, indent string, fail string) {
+	for _, s := range(st) {
+		var calls []string
+		switch(s.method) {
+		case "GolangExpression":
+			var args []string
+			for _, a := range(s.args) { args = append(args, goArg(a)) }
+			fmt.Fprintf(o, "%sg, e := gorex.GolangExpression(%s)\n", indent, strings.Join(args, ", "))
+			fmt.Fprintf(o, "%sif e != nil { %s }\n", indent, fail)
+			continue
+		case "AddClassToLast":
+			for _, a := range(s.args) { calls = append(calls, goArg(a)) }
+		case "SetFlags":
+			var flags []string
+			for _, a := range(s.args) { flags = append(flags, goArg(a)) }
+			calls = append(calls, strings.Join(flags, " + "))
+		case "ApplyQuantifier", "AddNumericRange":
+			var args []string
+			for _, a := range(s.args) { args = append(args, goArg(a)) }
+			calls = append(calls, strings.Join(args, ", "))
+		case "ShouldMatch", "ShouldNotMatch", "ShouldMatchGroups":
+			fmt.Fprintf(o, "%sg.%s(%s)\n", indent, s.method, strings.Join(s.args, ", "))
+			continue
+		default:
+			calls = append(calls, goArg(s.args[0]))
+		}
+		for _, c := range(calls) {
+			fmt.Fprintf(o, "%sif e = g.%s(%s); e != nil { %s }\n", indent, s.method, c, fail)
+		}
+	}
+}
+
+// GoSource produces a go function named fn that builds the expression with
+// the builder methods, checking every error; the code refers to the package
+// as gorex
+func (g *Gorex) GoSource(fn string) (string, error) {
+	st, e := g.statements()
+	if e != nil { return "", e }
+
+	o := bytes.NewBufferString("")
+	fmt.Fprintf(o, "func %s() (*gorex.Gorex, error) {\n", fn)
+	writeGoCalls(o, st, "\t", "return nil, e")
+	o.WriteString("\n\treturn g, nil\n}\n")
+
+	return o.String(), nil
+}
+
+// GoExpression produces a go expression, a function literal called in
+// place, that builds the expression and evaluates to its output; the calls
+// of an expression that builds do not fail, and would panic if they did.
+// the code refers to the package as gorex and ends without a newline
+func (g *Gorex) GoExpression() (string, error) {
+	st, e := g.statements()
+	if e != nil { return "", e }
+
+	o := bytes.NewBufferString("func() string {\n")
+	writeGoCalls(o, st, "\t", "panic(e)")
+	o.WriteString("\to, e := g.Output()\n\tif e != nil { panic(e) }\n\n\treturn o\n}()")
+
+	return o.String(), nil
+}
+
+// json form of an expression
+type jsonGorex struct {
+	Unsafe bool `json:"unsafe,omitempty"`
+	Groups []jsonGroup `json:"groups"`
+	Examples []jsonExample `json:"examples,omitempty"`
+}
+
+type jsonExample struct {
+	Input string `json:"input"`
+	Match bool `json:"match"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// a group AddNumericRange made keeps its arguments in Numeric, and in
+// Tokens the tokens added after them
+type jsonGroup struct {
+	Numeric []string `json:"numeric,omitempty"`
+	Tokens []jsonToken `json:"tokens"`
+	Flags string `json:"flags,omitempty"`
+	Anchor string `json:"anchor,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type jsonToken struct {
+	Class string `json:"class,omitempty"`
+	Fixed string `json:"fixed,omitempty"`
+	Quantifier string `json:"quantifier,omitempty"`
+	Args []int `json:"args,omitempty"`
+}
+
+// MarshalJSON stores the groups of the expression; quantifiers are stored
+// by constant name
+func (g *Gorex) MarshalJSON() ([]byte, error) {
+	j := jsonGorex{ Unsafe: g.unsafe, Groups: []jsonGroup{ } }
+	for _, gr := range(g.groups) {
+		jg := jsonGroup{ Flags: gr.flagString(), Anchor: string(gr.anchor), Name: gr.name, Tokens: []jsonToken{ } }
+		start := gr.numericTokens()
+		if start != 0 { jg.Numeric = gr.numeric }
+		for _, tk := range(gr.tokens[start:]) {
+			jt := jsonToken{ Class: tk.class, Fixed: tk.fixed }
+			if tk.quantifier.regexp != Single {
+				name, ok := lookupValue(quantifierNames, string(tk.quantifier.regexp))
+				if !ok { return nil, errors.New("Gorex @298: invalid quantifier") }
+				jt.Quantifier = name
+				jt.Args = append(jt.Args, tk.quantifier.argv[:strings.Count(string(tk.quantifier.regexp), "%d")]...)
+			}
+			jg.Tokens = append(jg.Tokens, jt)
+		}
+		j.Groups = append(j.Groups, jg)
+	}
+	for _, x := range(g.examples) {
+		j.Examples = append(j.Examples, jsonExample{ x.input, x.match, x.groups })
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON replaces the expression with the stored groups, applying the
+// same checks as the builder methods
+func (g *Gorex) UnmarshalJSON(data []byte) error {
+	var j jsonGorex
+	if e := json.Unmarshal(data, &j); e != nil { return e }
+
+	var opts []string
+	if j.Unsafe { opts = append(opts, Unsafe) }
+	n, _ := GolangExpression(opts...)
+	for gi, jg := range(j.Groups) {
+		if len(jg.Numeric) != 0 {
+			if e := n.Exec("AddNumericRange " + strings.Join(jg.Numeric, " ")); e != nil { return fmt.Errorf("group %d: %w", gi, e) }
+		} else if len(jg.Tokens) == 0 {
+			return fmt.Errorf("Gorex @320: group %d: invalid token index", gi)
+		}
+		for ti, jt := range(jg.Tokens) {
+			var e error
+			first := ti == 0 && len(jg.Numeric) == 0
+			if jt.Class != NoClass && jt.Fixed != "" { return fmt.Errorf("Gorex @323: group %d: invalid token error", gi) }
+			if jt.Class != NoClass {
+				e = n.addJSONClass(jt.Class, first)
+			} else if first {
+				e = n.AddFixed(jt.Fixed)
+			} else {
+				e = n.AddFixedToLast(jt.Fixed)
+			}
+			if e == nil && jt.Quantifier != "" {
+				q, ok := lookupName(quantifierNames, jt.Quantifier)
+				if !ok { q = jt.Quantifier }
+				e = n.ApplyQuantifier(Quantifier(q), jt.Args...)
+			}
+			if e != nil { return fmt.Errorf("group %d: %w", gi, e) }
+		}
+		if jg.Flags != "" {
+			if e := n.SetFlags(jg.Flags); e != nil { return fmt.Errorf("group %d: %w", gi, e) }
+		}
+		if jg.Anchor != "" {
+			if e := n.ApplyAnchor(Anchor(jg.Anchor)); e != nil { return fmt.Errorf("group %d: %w", gi, e) }
+		}
+		if jg.Name != "" {
+			if e := n.NameGroup(jg.Name); e != nil { return fmt.Errorf("group %d: %w", gi, e) }
+		}
+	}
+
+	for _, x := range(j.Examples) {
+		if x.Match && x.Groups != nil {
+			n.ShouldMatchGroups(x.Input, x.Groups...)
+		} else if x.Match {
+			n.ShouldMatch(x.Input)
+		} else {
+			n.ShouldNotMatch(x.Input)
+		}
+	}
+
+	*g = *n
+	return nil
+}
+
+// adds a stored class, decomposing it into constants for safe expressions
+func (g *Gorex) addJSONClass(c string, first bool) error {
+	if !first { return errors.New("Gorex @352: class token cannot be defined") }
+	parts := []string{ c }
+	if !g.unsafe {
+		names := splitClass(c)
+		if names == nil { return errors.New("Gorex @356: invalid class") }
+		parts = parts[:0]
+		for _, name := range(names) {
+			v, _ := lookupName(classNames, name)
+			parts = append(parts, v)
+		}
+	}
+	if e := g.AddClass(parts[0]); e != nil { return e }
+	for _, p := range(parts[1:]) {
+		if e := g.AddClassToLast(p); e != nil { return e }
+	}
+
+	return nil
+}
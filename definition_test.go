@@ -0,0 +1,137 @@
+package gorex
+
+import(
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testDefinition = `# e-mail user name
+GolangExpression
+AddClass Uppers
+AddClassToLast Lowers Digits
+ApplyQuantifier OneOrMore
+AddFixed "\\." "_"
+ApplyQuantifier ZeroOrOne
+AddClass Digits
+ApplyQuantifier MinToMax 2 3
+SetFlags CaseInsensitive "s"
+ApplyAnchor "^"
+`
+
+func TestParseDefinition(t *testing.T) {
+	g, e := ParseDefinition(strings.NewReader(testDefinition))
+	if e != nil { t.Fatalf("ParseDefinition() unexpected error: %s\n", e) }
+
+	w := "([A-Za-z0-9]+)(\\.|_?)(?is)(^[0-9]{2,3})"
+	o, _ := g.Output()
+	if o != w { t.Fatalf("ParseDefinition() output not correct \"%s\" != \"%s\"\n", o, w) }
+
+	// errors report the failing line
+	_, e = ParseDefinition(strings.NewReader("AddClass Uppers\nAddClass \"?!\"\n"))
+	if e == nil || !strings.HasPrefix(e.Error(), "line 2:") { t.Fatalf("ParseDefinition() expected line 2 error: %v\n", e) }
+
+	// GolangExpression must come first
+	_, e = ParseDefinition(strings.NewReader("AddFixed \"a\"\nGolangExpression\n"))
+	if e == nil { t.Fatalf("ParseDefinition() expected misplaced GolangExpression error\n") }
+
+	// unknown statements and broken quotes
+	if e = g.Exec("AddNothing"); e == nil { t.Fatalf("Exec(\"AddNothing\") expected error\n") }
+	if e = g.Exec("AddFixed \"com"); e == nil { t.Fatalf("Exec() expected unterminated quote error\n") }
+
+	// missing and invalid arguments, each with its own error
+	if e = g.Exec("NameGroup \"a\" \"b\""); e == nil || e.Error() != "Gorex @191: invalid group name" { t.Fatalf("Exec(\"NameGroup\") expected error: %v\n", e) }
+	if e = g.Exec("SetFlags"); e == nil || e.Error() != "Gorex @192: missing flag" { t.Fatalf("Exec(\"SetFlags\") expected error: %v\n", e) }
+	if e = g.Exec("ShouldMatchGroups"); e == nil || e.Error() != "Gorex @196: missing example" { t.Fatalf("Exec(\"ShouldMatchGroups\") expected error: %v\n", e) }
+	if e = g.Exec("AddNumericRange 1"); e == nil || e.Error() != "Gorex @186: missing numeric range" { t.Fatalf("Exec(\"AddNumericRange\") expected error: %v\n", e) }
+	if e = g.Exec("AddNumericRange 1 x"); e == nil || e.Error() != "Gorex @188: invalid numeric range" { t.Fatalf("Exec(\"AddNumericRange\") expected error: %v\n", e) }
+	if e = g.Exec("Bogus"); e == nil || e.Error() != "Gorex @199: unknown statement \"Bogus\"" { t.Fatalf("Exec(\"Bogus\") expected error: %v\n", e) }
+	if e = g.Exec("NameGroup \"tail\""); e != nil { t.Fatalf("Exec(\"NameGroup\") unexpected error: %s\n", e) }
+	if o, _ = g.Output(); !strings.HasSuffix(o, "(?P<tail>^[0-9]{2,3})") { t.Fatalf("Exec(\"NameGroup\") output not correct \"%s\"\n", o) }
+
+	// unsafe classes require the option
+	g, e = ParseDefinition(strings.NewReader("GolangExpression Unsafe\nAddClass \"a-f\"\n"))
+	if e != nil { t.Fatalf("ParseDefinition() unsafe class unexpected error: %s\n", e) }
+	if o, _ = g.Output(); o != "([a-f])" { t.Fatalf("ParseDefinition() unsafe output not correct \"%s\"\n", o) }
+}
+
+func TestDefinition(t *testing.T) {
+	g, _ := ParseDefinition(strings.NewReader(testDefinition))
+	d, e := g.Definition()
+	if e != nil { t.Fatalf("Definition() unexpected error: %s\n", e) }
+
+	w := `GolangExpression
+AddClass Alphabetics
+AddClassToLast Digits
+ApplyQuantifier OneOrMore
+AddFixed "\\."
+AddFixedToLast "_"
+ApplyQuantifier ZeroOrOne
+AddClass Digits
+ApplyQuantifier MinToMax 2 3
+SetFlags CaseInsensitive PeriodMatchesNewline
+ApplyAnchor "^"
+`
+	if d != w { t.Fatalf("Definition() not canonical:\n%s\n!=\n%s\n", d, w) }
+
+	// the canonical definition rebuilds the same expression
+	r, e := ParseDefinition(strings.NewReader(d))
+	if e != nil { t.Fatalf("ParseDefinition(Definition()) unexpected error: %s\n", e) }
+	o1, _ := g.Output()
+	o2, _ := r.Output()
+	if o1 != o2 { t.Fatalf("Definition() round trip \"%s\" != \"%s\"\n", o2, o1) }
+
+	// unsafe classes are quoted
+	g, _ = GolangExpression(Unsafe)
+	g.AddClass("a-f")
+	if d, _ = g.Definition(); d != "GolangExpression Unsafe\nAddClass \"a-f\"\n" { t.Fatalf("Definition() unsafe class: %q\n", d) }
+}
+
+func TestJSON(t *testing.T) {
+	g, _ := ParseDefinition(strings.NewReader(testDefinition))
+	data, e := json.Marshal(g)
+	if e != nil { t.Fatalf("json.Marshal() unexpected error: %s\n", e) }
+
+	r, _ := GolangExpression()
+	if e = json.Unmarshal(data, r); e != nil { t.Fatalf("json.Unmarshal(%s) unexpected error: %s\n", data, e) }
+	o1, _ := g.Output()
+	o2, _ := r.Output()
+	if o1 != o2 { t.Fatalf("json round trip \"%s\" != \"%s\"\n", o2, o1) }
+
+	// stored values are checked like builder arguments
+	bad := []string{
+		`{"groups":[{"tokens":[{"class":"?!"}]}]}`,
+		`{"groups":[{"tokens":[]}]}`,
+		`{"groups":[{"tokens":[{"fixed":"a","quantifier":"MinToMax","args":[1]}]}]}`,
+		`{"groups":[{"tokens":[{"fixed":"a"}],"flags":"x"}]}`,
+		`{"groups":[{"tokens":[{"fixed":"a","class":"0-9"}]}]}`,
+	}
+	for _, b := range(bad) {
+		if e = json.Unmarshal([]byte(b), r); e == nil { t.Fatalf("json.Unmarshal(%s) expected error\n", b) }
+	}
+}
+
+func TestGoSource(t *testing.T) {
+	g, _ := ParseDefinition(strings.NewReader(testDefinition))
+	o, e := g.GoSource("userName")
+	if e != nil { t.Fatalf("GoSource() unexpected error: %s\n", e) }
+
+	w := `func userName() (*gorex.Gorex, error) {
+	g, e := gorex.GolangExpression()
+	if e != nil { return nil, e }
+	if e = g.AddClass(gorex.Alphabetics); e != nil { return nil, e }
+	if e = g.AddClassToLast(gorex.Digits); e != nil { return nil, e }
+	if e = g.ApplyQuantifier(gorex.OneOrMore); e != nil { return nil, e }
+	if e = g.AddFixed("\\."); e != nil { return nil, e }
+	if e = g.AddFixedToLast("_"); e != nil { return nil, e }
+	if e = g.ApplyQuantifier(gorex.ZeroOrOne); e != nil { return nil, e }
+	if e = g.AddClass(gorex.Digits); e != nil { return nil, e }
+	if e = g.ApplyQuantifier(gorex.MinToMax, 2, 3); e != nil { return nil, e }
+	if e = g.SetFlags(gorex.CaseInsensitive + gorex.PeriodMatchesNewline); e != nil { return nil, e }
+	if e = g.ApplyAnchor("^"); e != nil { return nil, e }
+
+	return g, nil
+}
+`
+	if o != w { t.Fatalf("GoSource() not correct:\n%s\n!=\n%s\n", o, w) }
+}
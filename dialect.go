@@ -0,0 +1,145 @@
+package gorex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// output dialects
+//
+// the builder produces golang (RE2) syntax by default; the other dialects
+// re-render the same groups for engines with different flag and quantifier
+// support
+type Dialect string
+
+const (
+	Golang Dialect = "go"
+	PCRE Dialect = "pcre"
+	JavaScript Dialect = "javascript"
+	POSIX Dialect = "posix"
+)
+
+var dialectNames = []namedConst {
+	{ "Golang", string(Golang) },
+	{ "PCRE", string(PCRE) },
+	{ "JavaScript", string(JavaScript) },
+	{ "POSIX", string(POSIX) },
+}
+
+var dialectDescriptions = map[Dialect]string {
+	Golang: "go (RE2)",
+	PCRE: "PCRE",
+	JavaScript: "JavaScript",
+	POSIX: "POSIX extended",
+}
+
+func verifyDialect(d Dialect) bool {
+	if		d == Golang ||
+			d == PCRE ||
+			d == JavaScript ||
+			d == POSIX {
+		return true
+	}
+
+	return false
+}
+
+// swaps greedy and lazy quantifiers (used to emulate the U flag)
+var ungreedySwap = map[Quantifier]Quantifier {
+	ZeroOrMore: ZeroOrMorePrefFewer,
+	OneOrMore: OneOrMorePrefFewer,
+	ZeroOrOne: ZeroOrOnePrefFewer,
+	MinToMax: MinToMaxPrefFewer,
+	MinOrMore: MinOrMorePrefFewer,
+	Exactly: ExactlyPrefFewer,
+	ZeroOrMorePrefFewer: ZeroOrMore,
+	OneOrMorePrefFewer: OneOrMore,
+	ZeroOrOnePrefFewer: ZeroOrOne,
+	MinToMaxPrefFewer: MinToMax,
+	MinOrMorePrefFewer: MinOrMore,
+	ExactlyPrefFewer: Exactly,
+}
+
+func isPrefFewer(q Quantifier) bool {
+	return q == ZeroOrMorePrefFewer ||
+			q == OneOrMorePrefFewer ||
+			q == ZeroOrOnePrefFewer ||
+			q == MinToMaxPrefFewer ||
+			q == MinOrMorePrefFewer ||
+			q == ExactlyPrefFewer
+}
+
+// renders a token quantifier with its arguments
+func quantifierString(q rexQuan) (string, error) {
+	argCount := regexp.MustCompile("%d") // only permits numbers
+	switch(len(argCount.FindAllString(string(q.regexp), -1))) {
+	case 0:
+		return string(q.regexp), nil
+	case 1:
+		return fmt.Sprintf(string(q.regexp), q.argv[0]), nil
+	case 2:
+		return fmt.Sprintf(string(q.regexp), q.argv[0], q.argv[1]), nil
+	}
+
+	return "", errors.New("Gorex @72: invalid argument count")
+}
+
+// OutputDialect renders the expression for the requested engine
+//
+// Golang and PCRE share the inline flag syntax and produce the same string
+// as Output. JavaScript has no persistent inline flags, so flagged groups
+// are wrapped in (?ims:...) modifier groups and the U flag is emulated by
+// swapping greedy and lazy quantifiers; named groups are written (?<name>).
+// POSIX extended expressions support neither flags, lazy quantifiers nor
+// named groups, and an error is returned for any of them.
+func (g *Gorex) OutputDialect(d Dialect) (string, error) {
+	if !verifyDialect(d) { return "", errors.New("Gorex @83: invalid dialect") }
+	if d == Golang || d == PCRE { return g.Output() }
+
+	o := bytes.NewBufferString("")
+	for _, gr := range(g.groups) {
+		var mods string
+		if gr.flags.i { mods += CaseInsensitive }
+		if gr.flags.m { mods += MultiLineMode }
+		if gr.flags.s { mods += PeriodMatchesNewline }
+		if d == POSIX && (mods != "" || gr.flags.U) {
+			return "", errors.New("Gorex @93: flags unsupported by dialect")
+		}
+
+		if mods != "" { o.WriteString("(?" + mods + ":") }
+		if d == POSIX && gr.name != "" {
+			return "", errors.New("Gorex @97: named groups unsupported by dialect")
+		}
+		if gr.name != "" {
+			o.WriteString("(?<" + gr.name + ">")
+		} else {
+			o.WriteString("(")
+		}
+		if gr.anchor != "" { o.WriteString(string(gr.anchor)) }
+		for i, tk := range(gr.tokens) {
+			if tk.class != NoClass && len(tk.fixed) != 0 {
+				return "", errors.New("Gorex @101: invalid token error")
+			}
+			if tk.class != NoClass {
+				o.WriteString("[" + tk.class + "]")
+			}
+			if len(tk.fixed) != 0 { o.WriteString(tk.fixed) }
+			if gr.endsAlternative(i) { o.WriteString("|") }
+
+			q := tk.quantifier
+			if gr.flags.U && q.regexp != Single { q.regexp = ungreedySwap[q.regexp] }
+			if d == POSIX && isPrefFewer(q.regexp) {
+				return "", errors.New("Gorex @114: lazy quantifier unsupported by dialect")
+			}
+			s, e := quantifierString(q)
+			if e != nil { return "", e }
+			o.WriteString(s)
+		}
+		o.WriteString(")")
+		if mods != "" { o.WriteString(")") }
+	}
+
+	return o.String(), nil
+}
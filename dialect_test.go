@@ -0,0 +1,55 @@
+package gorex
+
+import(
+	"testing"
+)
+
+func TestOutputDialect(t *testing.T) {
+	var g *Gorex
+	var e error
+	var o string
+
+	g, _ = GolangExpression()
+	g.AddFixed("COM")
+	g.SetFlags(CaseInsensitive)
+	g.AddClass(Digits)
+	g.ApplyQuantifier(OneOrMore)
+	g.SetFlags(UngreedySwap)
+	g.AddFixed("org")
+	g.ApplyQuantifier(ExactlyPrefFewer, 2)
+
+	// invalid dialect
+	_, e = g.OutputDialect("perl6")
+	if e == nil { t.Fatalf("OutputDialect(\"perl6\") expected error\n") }
+
+	// golang and pcre share the syntax
+	w, _ := g.Output()
+	for _, d := range([]Dialect{ Golang, PCRE }) {
+		o, e = g.OutputDialect(d)
+		if e != nil || o != w { t.Fatalf("OutputDialect(\"%s\") \"%s\" != \"%s\" (%v)\n", d, o, w, e) }
+	}
+
+	// javascript scopes flags to their group and swaps greediness for U
+	o, e = g.OutputDialect(JavaScript)
+	w = "(?i:(COM))([0-9]+?)(org{2}?)"
+	if e != nil || o != w { t.Fatalf("OutputDialect(\"%s\") \"%s\" != \"%s\" (%v)\n", JavaScript, o, w, e) }
+
+	// posix has neither flags nor lazy quantifiers
+	_, e = g.OutputDialect(POSIX)
+	if e == nil { t.Fatalf("OutputDialect(\"%s\") expected flag error\n", POSIX) }
+
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.ApplyQuantifier(OneOrMorePrefFewer)
+	_, e = g.OutputDialect(POSIX)
+	if e == nil { t.Fatalf("OutputDialect(\"%s\") expected lazy quantifier error\n", POSIX) }
+
+	g, _ = GolangExpression()
+	g.AddClass(Lowers)
+	g.ApplyQuantifier(MinToMax, 1, 4)
+	g.AddFixed("com")
+	g.AddFixedToLast("net")
+	o, e = g.OutputDialect(POSIX)
+	w = "([a-z]{1,4})(com|net)"
+	if e != nil || o != w { t.Fatalf("OutputDialect(\"%s\") \"%s\" != \"%s\" (%v)\n", POSIX, o, w, e) }
+}
@@ -0,0 +1,466 @@
+package gorex
+
+// state elimination
+//
+// automata become expressions again by removing states one at a time,
+// labelling the edges left with the expressions of the paths through the
+// removed state. the labels simplify as they are built so the expression
+// rendered stays readable: alternatives merge classes and factor common
+// prefixes and suffixes, and repetition absorbs what it repeats.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expression nodes; nil matches nothing
+const (
+	nodeEmpty = iota // the empty string
+	nodeClass
+	nodeConcat
+	nodeAlt
+	nodeStar
+)
+
+type node struct {
+	op int
+	ranges []rune // class lo, hi pairs, sorted and disjoint
+	subs []*node
+	key string // rendering, for comparison
+}
+
+var emptyNode = &node{ op: nodeEmpty }
+
+// normalizes lo, hi pairs into sorted disjoint ranges
+func normalizeRanges(r []rune) []rune {
+	type span struct { lo, hi rune }
+	var spans []span
+	for i := 0; i + 1 < len(r); i += 2 { spans = append(spans, span{ r[i], r[i+1] }) }
+	sort.Slice(spans, func(i, j int) bool { return spans[i].lo < spans[j].lo })
+
+	var out []rune
+	for _, s := range(spans) {
+		if n := len(out); n != 0 && s.lo <= out[n-1] + 1 {
+			if s.hi > out[n-1] { out[n-1] = s.hi }
+			continue
+		}
+		out = append(out, s.lo, s.hi)
+	}
+
+	return out
+}
+
+func classNode(ranges []rune) *node {
+	n := &node{ op: nodeClass, ranges: normalizeRanges(ranges) }
+	n.key = n.render()
+
+	return n
+}
+
+func concatNode(subs ...*node) *node {
+	var flat []*node
+	for _, s := range(subs) {
+		if s == nil { return nil }
+		switch(s.op) {
+		case nodeEmpty:
+		case nodeConcat:
+			flat = append(flat, s.subs...)
+		default:
+			flat = append(flat, s)
+		}
+	}
+	if len(flat) == 0 { return emptyNode }
+	if len(flat) == 1 { return flat[0] }
+	n := &node{ op: nodeConcat, subs: flat }
+	n.key = n.render()
+
+	return n
+}
+
+func starNode(s *node) *node {
+	if s == nil || s.op == nodeEmpty { return emptyNode }
+	if s.op == nodeStar { return s }
+	if s.op == nodeAlt {
+		// (x|y|)* is (x|y)*, and (x*|y)* is (x|y)*
+		var subs []*node
+		for _, a := range(s.subs) {
+			if a.op == nodeStar { a = a.subs[0] }
+			if a.op != nodeEmpty { subs = append(subs, a) }
+		}
+		s = altNode(subs...)
+		if s.op == nodeStar || s.op == nodeEmpty { return starNode(s) }
+	}
+	n := &node{ op: nodeStar, subs: []*node{ s } }
+	n.key = n.render()
+
+	return n
+}
+
+// the elements of a concatenation, or the node alone
+func (n *node) elements() []*node {
+	if n.op == nodeConcat { return n.subs }
+	if n.op == nodeEmpty { return nil }
+
+	return []*node{ n }
+}
+
+func altNode(subs ...*node) *node {
+	var items []*node
+	hasEmpty := false
+	var class []rune
+	classAt := -1
+	seen := map[string]bool{ }
+	var add func(s *node)
+	add = func(s *node) {
+		switch {
+		case s == nil:
+		case s.op == nodeAlt:
+			for _, a := range(s.subs) { add(a) }
+		case s.op == nodeEmpty:
+			hasEmpty = true
+		case s.op == nodeClass:
+			class = append(class, s.ranges...)
+			if classAt < 0 {
+				classAt = len(items)
+				items = append(items, s)
+			}
+		case !seen[s.key]:
+			seen[s.key] = true
+			items = append(items, s)
+		}
+	}
+	for _, s := range(subs) { add(s) }
+	if classAt >= 0 { items[classAt] = classNode(class) }
+	if len(items) == 0 && !hasEmpty { return nil }
+
+	items = factor(items, true)
+	items = factor(items, false)
+
+	// the empty string is redundant beside a star
+	if hasEmpty {
+		for _, s := range(items) {
+			if s.op == nodeStar { hasEmpty = false }
+		}
+	}
+	if hasEmpty { items = append(items, emptyNode) }
+	if len(items) == 1 { return items[0] }
+	n := &node{ op: nodeAlt, subs: items }
+	n.key = n.render()
+
+	return n
+}
+
+// factors the common first (or last) element out of alternatives sharing it
+func factor(items []*node, prefix bool) []*node {
+	end := func(s *node) *node {
+		e := s.elements()
+		if prefix { return e[0] }
+		return e[len(e) - 1]
+	}
+	rest := func(s *node) *node {
+		e := s.elements()
+		if prefix { return concatNode(e[1:]...) }
+		return concatNode(e[:len(e) - 1]...)
+	}
+
+	groups := map[string][]*node{ }
+	var order []string
+	for _, s := range(items) {
+		k := end(s).key
+		if _, ok := groups[k]; !ok { order = append(order, k) }
+		groups[k] = append(groups[k], s)
+	}
+	if len(order) == len(items) { return items }
+
+	var out []*node
+	for _, k := range(order) {
+		g := groups[k]
+		if len(g) == 1 {
+			out = append(out, g[0])
+			continue
+		}
+		var rests []*node
+		for _, s := range(g) { rests = append(rests, rest(s)) }
+		if prefix {
+			out = append(out, concatNode(end(g[0]), altNode(rests...)))
+		} else {
+			out = append(out, concatNode(altNode(rests...), end(g[0])))
+		}
+	}
+
+	return out
+}
+
+// escapes a rune for use outside (or, in a class, inside) brackets, in ascii
+func escapeRune(r rune, inClass bool) string {
+	switch {
+	case r < 0x20 || r == 0x7F:
+		return fmt.Sprintf("\\x%02X", r)
+	case r > 0x7F:
+		return fmt.Sprintf("\\x{%X}", r)
+	case inClass && strings.ContainsRune("\\]^-[", r):
+		return "\\" + string(r)
+	case !inClass && strings.ContainsRune("\\.+*?()|[]{}^$", r):
+		return "\\" + string(r)
+	}
+
+	return string(r)
+}
+
+func rangesString(ranges []rune) string {
+	var b strings.Builder
+	for i := 0; i + 1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		b.WriteString(escapeRune(lo, true))
+		if hi > lo + 1 { b.WriteString("-") }
+		if hi > lo { b.WriteString(escapeRune(hi, true)) }
+	}
+
+	return b.String()
+}
+
+// renders a class, negated when that is shorter; surrogate halves, which
+// never match, count as members either way
+func classString(ranges []rune) string {
+	if len(ranges) == 2 && ranges[0] == ranges[1] { return escapeRune(ranges[0], false) }
+
+	with := normalizeRanges(append(append([]rune{ }, ranges...), 0xD800, 0xDFFF))
+	var neg []rune
+	next := rune(0)
+	for i := 0; i + 1 < len(with); i += 2 {
+		if with[i] > next { neg = append(neg, next, with[i] - 1) }
+		next = with[i+1] + 1
+	}
+	if next <= unicode.MaxRune { neg = append(neg, next, unicode.MaxRune) }
+
+	if len(neg) == 0 { return "[\\s\\S]" }
+	pos := "[" + rangesString(ranges) + "]"
+	if n := "[^" + rangesString(neg) + "]"; len(n) < len(pos) { return n }
+
+	return pos
+}
+
+// whether an alternation matches the empty string, rendering as x?
+func (n *node) optional() bool {
+	return n.op == nodeAlt && n.subs[len(n.subs) - 1].op == nodeEmpty
+}
+
+// whether the rendering needs no parentheses to take a quantifier
+func (n *node) atomic() bool {
+	return n.op == nodeClass
+}
+
+func (n *node) repeat(q string) string {
+	if n.atomic() { return n.key + q }
+
+	return "(?:" + n.key + ")" + q
+}
+
+func (n *node) render() string {
+	switch(n.op) {
+	case nodeClass:
+		return classString(n.ranges)
+	case nodeStar:
+		return n.subs[0].repeat("*")
+	case nodeAlt:
+		var alts []string
+		optional := false
+		for _, s := range(n.subs) {
+			if s.op == nodeEmpty {
+				optional = true
+				continue
+			}
+			alts = append(alts, s.key)
+		}
+		if !optional { return strings.Join(alts, "|") }
+		if len(alts) == 1 && n.subs[0].op != nodeConcat { return n.subs[0].repeat("?") }
+		return "(?:" + strings.Join(alts, "|") + ")?"
+	case nodeConcat:
+		var b strings.Builder
+		subs := n.subs
+		for i := 0; i < len(subs); i++ {
+			s := subs[i]
+			// x x* and x* x are x+, x being one or more elements
+			if s.op == nodeStar {
+				e := s.subs[0].elements()
+				if i + 1 + len(e) <= len(subs) && sameNodes(e, subs[i+1:i+1+len(e)]) {
+					b.WriteString(s.subs[0].repeat("+"))
+					i += len(e)
+					continue
+				}
+			}
+			if e := n.starAfter(i); e > 0 {
+				b.WriteString(subs[i+e].subs[0].repeat("+"))
+				i += e
+				continue
+			}
+			if s.op == nodeAlt && !s.optional() {
+				b.WriteString("(?:" + s.key + ")")
+				continue
+			}
+			b.WriteString(s.key)
+		}
+		return b.String()
+	}
+
+	return ""
+}
+
+// the length of the elements from i repeated by the star right after them
+func (n *node) starAfter(i int) int {
+	for j := i + 1; j < len(n.subs); j++ {
+		s := n.subs[j]
+		if s.op != nodeStar { continue }
+		e := s.subs[0].elements()
+		if len(e) == j - i && sameNodes(e, n.subs[i:j]) { return j - i }
+	}
+
+	return 0
+}
+
+func sameNodes(a []*node, b []*node) bool {
+	if len(a) != len(b) { return false }
+	for i := range(a) {
+		if a[i].key != b[i].key { return false }
+	}
+
+	return true
+}
+
+// minimal automaton accepting the same inputs; states not reachable, or
+// from which nothing is accepted, are removed. the start state is 0, and
+// nil is returned when nothing is accepted
+func (d *dfa) minimize() *dfa {
+	live := d.live()
+	if !live[0] { return nil }
+	reach := map[int]bool{ 0: true }
+	order := []int{ 0 }
+	for i := 0; i < len(order); i++ {
+		for _, t := range(d.trans[order[i]]) {
+			if t >= 0 && live[t] && !reach[t] {
+				reach[t] = true
+				order = append(order, t)
+			}
+		}
+	}
+
+	// refine the partition of accepting and rejecting states until the
+	// states of each block agree on the block of every transition
+	block := map[int]int{ }
+	for _, s := range(order) {
+		if d.accept[s] { block[s] = 1 }
+	}
+	for blocks := 0; ; {
+		index := map[string]int{ }
+		next := map[int]int{ }
+		for _, s := range(order) {
+			var k strings.Builder
+			k.WriteString(strconv.Itoa(block[s]))
+			for _, t := range(d.trans[s]) {
+				b := -1
+				if t >= 0 && reach[t] { b = block[t] }
+				k.WriteString("," + strconv.Itoa(b))
+			}
+			if _, ok := index[k.String()]; !ok { index[k.String()] = len(index) }
+			next[s] = index[k.String()]
+		}
+		block = next
+		if len(index) == blocks { break }
+		blocks = len(index)
+	}
+
+	m := &dfa{ symbols: d.symbols }
+	number := map[int]int{ }
+	for _, s := range(order) {
+		if _, ok := number[block[s]]; ok { continue }
+		number[block[s]] = len(m.trans)
+		m.trans = append(m.trans, nil)
+		m.accept = append(m.accept, d.accept[s])
+	}
+	for _, s := range(order) {
+		n := number[block[s]]
+		if m.trans[n] != nil { continue }
+		row := make([]int, len(d.symbols))
+		for i, t := range(d.trans[s]) {
+			row[i] = -1
+			if t >= 0 && reach[t] { row[i] = number[block[t]] }
+		}
+		m.trans[n] = row
+	}
+
+	return m
+}
+
+// an expression for the inputs the automaton accepts, nil if none
+func (d *dfa) expression() *node {
+	m := d.minimize()
+	if m == nil { return nil }
+
+	// states of m, then a start and a final state
+	n := len(m.trans)
+	start, final := n, n + 1
+	out := make([]map[int]*node, n + 2)
+	in := make([]map[int]*node, n + 2)
+	for i := range(out) {
+		out[i] = map[int]*node{ }
+		in[i] = map[int]*node{ }
+	}
+	link := func(p int, q int, label *node) {
+		if label == nil { return }
+		l := altNode(out[p][q], label)
+		out[p][q], in[q][p] = l, l
+	}
+
+	link(start, 0, emptyNode)
+	for s, row := range(m.trans) {
+		if m.accept[s] { link(s, final, emptyNode) }
+		ranges := map[int][]rune{ }
+		var targets []int
+		for i, t := range(row) {
+			if t < 0 { continue }
+			if _, ok := ranges[t]; !ok { targets = append(targets, t) }
+			ranges[t] = append(ranges[t], m.symbols[i].lo, m.symbols[i].hi)
+		}
+		for _, t := range(targets) { link(s, t, classNode(ranges[t])) }
+	}
+
+	// remove the state with the fewest paths through it first
+	removed := make([]bool, n)
+	for left := n; left > 0; left-- {
+		q, cost := -1, 0
+		for s := 0; s < n; s++ {
+			if removed[s] { continue }
+			self := 0
+			if _, ok := out[s][s]; ok { self = 1 }
+			c := (len(in[s]) - self) * (len(out[s]) - self)
+			if q < 0 || c < cost { q, cost = s, c }
+		}
+		removed[q] = true
+
+		loop := emptyNode
+		if l, ok := out[q][q]; ok { loop = starNode(l) }
+		delete(out[q], q)
+		delete(in[q], q)
+		for _, p := range(sortedKeys(in[q])) {
+			for _, r := range(sortedKeys(out[q])) {
+				link(p, r, concatNode(in[q][p], loop, out[q][r]))
+			}
+		}
+		for p := range(in[q]) { delete(out[p], q) }
+		for r := range(out[q]) { delete(in[r], q) }
+		out[q], in[q] = nil, nil
+	}
+
+	return out[start][final]
+}
+
+func sortedKeys(m map[int]*node) []int {
+	var k []int
+	for i := range(m) { k = append(k, i) }
+	sort.Ints(k)
+
+	return k
+}
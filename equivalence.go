@@ -0,0 +1,64 @@
+package gorex
+
+// equivalence
+//
+// Equivalent and Subsumes compare the strings two expressions match as a
+// whole, by walking their automata together; on a difference they return
+// a shortest string telling them apart:
+//
+//  ok, x, e := Equivalent(before, after)
+//  if e == nil && !ok { fmt.Printf("%q matched by only one\n", x) }
+
+// breadth-first search of the product of the automata of a and b for a
+// state pair where differ holds; the shortest input reaching it, if any
+func search(a *Gorex, b *Gorex, differ func(x bool, y bool) bool) (bool, string, error) {
+	ds, e := automata(a, b)
+	if e != nil { return false, "", e }
+	da, db := ds[0], ds[1]
+
+	// -1 is the state rejecting everything
+	type pair struct { a, b int }
+	accepts := func(d *dfa, s int) bool { return s >= 0 && d.accept[s] }
+	step := func(d *dfa, s int, sym int) int {
+		if s < 0 { return -1 }
+		return d.trans[s][sym]
+	}
+
+	// each pair reached and the pair and rune it was reached from
+	type from struct {
+		prev pair
+		r rune
+	}
+	seen := map[pair]from{ { 0, 0 }: { } }
+	queue := []pair{ { 0, 0 } }
+	for len(queue) != 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if differ(accepts(da, p.a), accepts(db, p.b)) {
+			var text []rune
+			for q := p; q != (pair{ 0, 0 }); q = seen[q].prev { text = append([]rune{ seen[q].r }, text...) }
+			return false, string(text), nil
+		}
+		for i, sym := range(da.symbols) {
+			q := pair{ step(da, p.a, i), step(db, p.b, i) }
+			if q.a < 0 && q.b < 0 { continue }
+			if _, ok := seen[q]; ok { continue }
+			seen[q] = from{ p, sym.lo }
+			queue = append(queue, q)
+		}
+	}
+
+	return true, "", nil
+}
+
+// Equivalent reports whether a and b match the same strings; when they do
+// not, counterexample is a shortest string only one of them matches
+func Equivalent(a *Gorex, b *Gorex) (ok bool, counterexample string, e error) {
+	return search(a, b, func(x bool, y bool) bool { return x != y })
+}
+
+// Subsumes reports whether a matches every string b matches; when it does
+// not, counterexample is a shortest string b matches and a does not
+func Subsumes(a *Gorex, b *Gorex) (ok bool, counterexample string, e error) {
+	return search(a, b, func(x bool, y bool) bool { return y && !x })
+}
@@ -0,0 +1,62 @@
+package gorex
+
+import(
+	"testing"
+)
+
+func TestEquivalent(t *testing.T) {
+	var a, b *Gorex
+
+	// same language, different output
+	a, _ = GolangExpression()
+	a.AddClass(Digits)
+	a.ApplyQuantifier(OneOrMore)
+	b, _ = GolangExpression()
+	b.AddClass(Digits)
+	b.AddClass(Digits)
+	b.ApplyQuantifier(ZeroOrMore)
+	ok, x, e := Equivalent(a, b)
+	if e != nil || !ok || x != "" { t.Fatalf("Equivalent() %v %q %v, expected equivalent\n", ok, x, e) }
+
+	// a shortest counterexample
+	b.ApplyQuantifier(MinOrMore, 2)
+	ok, x, e = Equivalent(a, b)
+	if e != nil || ok || x != "0" { t.Fatalf("Equivalent() %v %q %v, expected counterexample \"0\"\n", ok, x, e) }
+
+	// alternatives in any order
+	a = testEmail()
+	b, _ = GolangExpression()
+	b.AddFixed("[A-Za-z]+@[a-z]+\\.(?:net|com)")
+	if ok, x, _ = Equivalent(a, b); !ok { t.Fatalf("Equivalent() email counterexample %q\n", x) }
+
+	// flags and anchors
+	a, _ = GolangExpression()
+	a.AddFixed("ab")
+	a.SetFlags(CaseInsensitive)
+	b, _ = GolangExpression()
+	b.AddFixed("^[aA][bB]$")
+	if ok, x, _ = Equivalent(a, b); !ok { t.Fatalf("Equivalent() flags counterexample %q\n", x) }
+	b.ApplyAnchor(atBeginning)
+	if ok, x, _ = Equivalent(a, b); !ok { t.Fatalf("Equivalent() anchored counterexample %q\n", x) }
+}
+
+func TestSubsumes(t *testing.T) {
+	a, _ := GolangExpression()
+	a.AddClass(AlphaNumerics)
+	a.ApplyQuantifier(ZeroOrMore)
+	b := testEmail()
+
+	ok, x, e := Subsumes(b, a)
+	if e != nil || ok || x != "" { t.Fatalf("Subsumes() %v %q %v, expected counterexample \"\"\n", ok, x, e) }
+
+	b, _ = GolangExpression()
+	b.AddClass(Digits)
+	b.AddFixed("x")
+	b.AddFixedToLast("y")
+	if ok, x, _ = Subsumes(a, b); !ok { t.Fatalf("Subsumes() unexpected counterexample %q\n", x) }
+	if ok, x, _ = Subsumes(b, a); ok || x != "" { t.Fatalf("Subsumes() %v %q, expected counterexample \"\"\n", ok, x) }
+
+	// a shortest counterexample
+	b.AddFixed("-")
+	if ok, x, _ = Subsumes(a, b); ok || x != "0x-" { t.Fatalf("Subsumes() %v %q, expected counterexample \"0x-\"\n", ok, x) }
+}
@@ -0,0 +1,137 @@
+package gorex
+
+// examples
+//
+// positive and negative examples travel with the expression and are checked
+// by Verify, or from a go test by gorextest.TestExamples:
+//
+//  rex.ShouldMatch("joe@mail.org", "john_doe@co.net")
+//  rex.ShouldNotMatch("goat@mail")
+//  rex.ShouldMatchGroups("finn@co.net", "finn", "", "", "@", "co", ".", "net")
+//  if e := rex.Verify(); e != nil { ... }
+//
+// an example matches when the expression matches the whole input
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type rexExample struct {
+	input string
+	match bool
+	groups []string // expected captures of groups 1..n, if any
+}
+
+// Example is an example added by ShouldMatch, ShouldNotMatch or
+// ShouldMatchGroups; Groups are the expected captures of groups 1..n
+type Example struct {
+	Input string
+	Match bool
+	Groups []string
+}
+
+// Violation is an example the expression does not satisfy; Group is zero
+// unless the input matched with an unexpected capture, and Missing is set
+// when the expected group does not exist
+type Violation struct {
+	Input string
+	ShouldMatch bool
+	Group int
+	Want string
+	Got string
+	Missing bool
+}
+
+func (v Violation) String() string {
+	if v.Missing {
+		return fmt.Sprintf("%s: group %d does not exist", strconv.Quote(v.Input), v.Group)
+	}
+	if v.Group != 0 {
+		return fmt.Sprintf("%s: group %d is %s, expected %s", strconv.Quote(v.Input), v.Group, strconv.Quote(v.Got), strconv.Quote(v.Want))
+	}
+	if v.ShouldMatch { return strconv.Quote(v.Input) + " should match" }
+
+	return strconv.Quote(v.Input) + " should not match"
+}
+
+// VerifyError lists every violated example
+type VerifyError struct {
+	Violations []Violation
+}
+
+func (e *VerifyError) Error() string {
+	o := bytes.NewBufferString("")
+	fmt.Fprintf(o, "Gorex @60: %d example violations", len(e.Violations))
+	for _, v := range(e.Violations) { o.WriteString("\n\t" + v.String()) }
+
+	return o.String()
+}
+
+// ShouldMatch adds inputs the expression must match
+func (g *Gorex) ShouldMatch(inputs ...string) {
+	for _, in := range(inputs) { g.examples = append(g.examples, rexExample{ in, true, nil }) }
+}
+
+// ShouldNotMatch adds inputs the expression must not match
+func (g *Gorex) ShouldNotMatch(inputs ...string) {
+	for _, in := range(inputs) { g.examples = append(g.examples, rexExample{ in, false, nil }) }
+}
+
+// ShouldMatchGroups adds an input the expression must match, capturing the
+// given text in groups 1, 2, ... in order
+func (g *Gorex) ShouldMatchGroups(input string, groups ...string) {
+	g.examples = append(g.examples, rexExample{ input, true, append([]string{ }, groups...) })
+}
+
+// the expression anchored to whole inputs
+func (g *Gorex) exampleRegexp() (*regexp.Regexp, error) {
+	o, e := g.Output()
+	if e != nil { return nil, e }
+
+	return regexp.Compile("^(?:" + o + ")$")
+}
+
+func (x rexExample) check(rex *regexp.Regexp) []Violation {
+	m := rex.FindStringSubmatchIndex(x.input)
+	if (m != nil) != x.match { return []Violation{ { Input: x.input, ShouldMatch: x.match } } }
+
+	// unset groups capture the empty string, as with FindStringSubmatch
+	var v []Violation
+	for i, w := range(x.groups) {
+		n := i + 1
+		if n >= len(m) / 2 {
+			v = append(v, Violation{ x.input, true, n, w, "", true })
+			continue
+		}
+		var got string
+		if m[2*n] >= 0 { got = x.input[m[2*n]:m[2*n+1]] }
+		if got != w { v = append(v, Violation{ x.input, true, n, w, got, false }) }
+	}
+
+	return v
+}
+
+// VerifyEach checks every example in order, calling f with each and its
+// violations, none when it holds; it returns the error preventing the check
+func (g *Gorex) VerifyEach(f func(x Example, v []Violation)) error {
+	rex, e := g.exampleRegexp()
+	if e != nil { return e }
+
+	for _, x := range(g.examples) { f(Example{ x.input, x.match, append([]string(nil), x.groups...) }, x.check(rex)) }
+
+	return nil
+}
+
+// Verify checks every example, returning a *VerifyError listing all
+// violations, or the error preventing the check
+func (g *Gorex) Verify() error {
+	var v []Violation
+	e := g.VerifyEach(func(x Example, xv []Violation) { v = append(v, xv...) })
+	if e != nil { return e }
+	if len(v) != 0 { return &VerifyError{ v } }
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package gorex
+
+import(
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ([A-Za-z]+)(@)([a-z]+)(\.)(com|net)
+func testEmail() *Gorex {
+	g, _ := GolangExpression()
+	g.AddClass(Alphabetics)
+	g.ApplyQuantifier(OneOrMore)
+	g.AddFixed("@")
+	g.AddClass(Lowers)
+	g.ApplyQuantifier(OneOrMore)
+	g.AddFixed("\\.")
+	g.AddFixed("com")
+	g.AddFixedToLast("net")
+
+	return g
+}
+
+func TestVerify(t *testing.T) {
+	var g *Gorex
+	var e error
+
+	// no examples
+	g = testEmail()
+	if e = g.Verify(); e != nil { t.Fatalf("Verify() without examples unexpected error: %s\n", e) }
+
+	// passing examples
+	g.ShouldMatch("joe@mail.com", "Ann@co.net")
+	g.ShouldNotMatch("joe@mail.org", "x joe@mail.com")
+	g.ShouldMatchGroups("joe@mail.com", "joe", "@", "mail", ".", "com")
+	if e = g.Verify(); e != nil { t.Fatalf("Verify() unexpected error: %s\n", e) }
+
+	// every violation is reported
+	g.ShouldMatch("joe@MAIL.com")
+	g.ShouldNotMatch("a@b.net")
+	g.ShouldMatchGroups("a@b.net", "a", "@", "c", ".", "net", "x")
+	e = g.Verify()
+	v, ok := e.(*VerifyError)
+	if !ok { t.Fatalf("Verify() expected *VerifyError: %#v\n", e) }
+	w := []Violation{
+		{ Input: "joe@MAIL.com", ShouldMatch: true },
+		{ Input: "a@b.net", ShouldMatch: false },
+		{ Input: "a@b.net", ShouldMatch: true, Group: 3, Want: "c", Got: "b" },
+		{ Input: "a@b.net", ShouldMatch: true, Group: 6, Want: "x", Missing: true },
+	}
+	if len(v.Violations) != len(w) { t.Fatalf("Verify() violations %#v != %#v\n", v.Violations, w) }
+	for i := range(w) {
+		if v.Violations[i] != w[i] { t.Fatalf("Verify() violation %d %#v != %#v\n", i, v.Violations[i], w[i]) }
+	}
+	if !strings.Contains(e.Error(), "\"a@b.net\": group 3 is \"b\", expected \"c\"") { t.Fatalf("Verify() error text: %s\n", e) }
+
+	// unset optional groups capture the empty string
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.ApplyQuantifier(ZeroOrOne)
+	g.AddFixed("b")
+	g.AddFixedToLast("c")
+	g.ShouldMatchGroups("b", "", "b")
+	if e = g.Verify(); e != nil { t.Fatalf("Verify() unset group unexpected error: %s\n", e) }
+
+	// expressions that cannot be output
+	g.AddFixed("d")
+	g.AddClassToLast(Digits)
+	if e = g.Verify(); e == nil { t.Fatalf("Verify() expected invalid token error\n") }
+}
+
+func TestExamplesDefinition(t *testing.T) {
+	g := testEmail()
+	g.ShouldMatch("joe@mail.com")
+	g.ShouldNotMatch("joe@mail")
+	g.ShouldMatchGroups("a@b.net", "a", "@", "b", ".", "net")
+
+	d, _ := g.Definition()
+	if !strings.HasSuffix(d, "ShouldMatch \"joe@mail.com\"\nShouldNotMatch \"joe@mail\"\nShouldMatchGroups \"a@b.net\" \"a\" \"@\" \"b\" \".\" \"net\"\n") {
+		t.Fatalf("Definition() examples missing:\n%s\n", d)
+	}
+	r, e := ParseDefinition(strings.NewReader(d))
+	if e != nil { t.Fatalf("ParseDefinition() unexpected error: %s\n", e) }
+	if d2, _ := r.Definition(); d2 != d { t.Fatalf("Definition() round trip:\n%s\n!=\n%s\n", d2, d) }
+
+	data, _ := json.Marshal(g)
+	r, _ = GolangExpression()
+	if e = json.Unmarshal(data, r); e != nil { t.Fatalf("json.Unmarshal() unexpected error: %s\n", e) }
+	if d2, _ := r.Definition(); d2 != d { t.Fatalf("json round trip:\n%s\n!=\n%s\n", d2, d) }
+
+	o, _ := g.GoSource("email")
+	if !strings.Contains(o, "\tg.ShouldMatchGroups(\"a@b.net\", \"a\", \"@\", \"b\", \".\", \"net\")\n") { t.Fatalf("GoSource() examples missing:\n%s\n", o) }
+}
+
+func TestVerifyEach(t *testing.T) {
+	g := testEmail()
+	g.ShouldMatch("joe@mail.com")
+	g.ShouldNotMatch("joe@mail.com")
+	var inputs []string
+	var failed []int
+	e := g.VerifyEach(func(x Example, v []Violation) {
+		inputs = append(inputs, x.Input)
+		failed = append(failed, len(v))
+	})
+	if e != nil || len(inputs) != 2 || failed[0] != 0 || failed[1] != 1 { t.Fatalf("VerifyEach() saw %q with %v violations (%v)\n", inputs, failed, e) }
+}
+
+func ExampleGorex_Verify() {
+	g := testEmail()
+	g.ShouldMatch("joe@mail.com", "joe.bloggs@mail.com")
+	g.ShouldNotMatch("goat@mail", "finn@.net")
+
+	fmt.Println(g.Verify())
+	// Output:
+	// Gorex @60: 1 example violations
+	// 	"joe.bloggs@mail.com" should match
+}
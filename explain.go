@@ -0,0 +1,210 @@
+package gorex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// class descriptions, by constant name
+var classDescriptions = map[string]string {
+	"Ascii": "ascii characters",
+	"Blank": "blanks",
+	"Control": "control characters",
+	"Digits": "digits",
+	"Graphical": "graphical characters",
+	"Lowers": "lower-case letters",
+	"Printable": "printable characters",
+	"Punctuation": "punctuation",
+	"Whitespace": "whitespace",
+	"Uppers": "upper-case letters",
+	"Words": "word characters",
+	"HexDigits": "hexadecimal digits",
+	"AlphaNumerics": "alphanumerics",
+	"Alphabetics": "letters",
+}
+
+// quantifier descriptions; %d verbs match the quantifier arguments
+var quantifierDescriptions = map[Quantifier]string {
+	ZeroOrMore: "zero or more times",
+	OneOrMore: "one or more times",
+	ZeroOrOne: "zero or one time",
+	MinToMax: "%d to %d times",
+	MinOrMore: "%d or more times",
+	Exactly: "exactly %d times",
+	ZeroOrMorePrefFewer: "zero or more times, preferring fewer",
+	OneOrMorePrefFewer: "one or more times, preferring fewer",
+	ZeroOrOnePrefFewer: "zero or one time, preferring fewer",
+	MinToMaxPrefFewer: "%d to %d times, preferring fewer",
+	MinOrMorePrefFewer: "%d or more times, preferring fewer",
+	ExactlyPrefFewer: "exactly %d times, preferring fewer",
+}
+
+var flagDescriptions = []namedConst {
+	{ CaseInsensitive, "case insensitive" },
+	{ MultiLineMode, "multi-line" },
+	{ PeriodMatchesNewline, "period matches newline" },
+	{ UngreedySwap, "ungreedy" },
+}
+
+// Constant is a builder constant with the description Explain gives it;
+// Args is the number of arguments a quantifier takes
+type Constant struct {
+	Name string
+	Value string
+	Description string
+	Args int
+}
+
+// Classes lists the class constants
+func Classes() []Constant {
+	var cs []Constant
+	for _, n := range(classNames) { cs = append(cs, Constant{ n.name, n.value, classDescriptions[n.name], 0 }) }
+
+	return cs
+}
+
+// Quantifiers lists the quantifier constants; the arguments of their
+// descriptions read n
+func Quantifiers() []Constant {
+	var cs []Constant
+	for _, n := range(quantifierNames) {
+		d, ok := quantifierDescriptions[Quantifier(n.value)]
+		if !ok { d = "once" }
+		cs = append(cs, Constant{ n.name, n.value, strings.ReplaceAll(d, "%d", "n"), strings.Count(n.value, "%d") })
+	}
+
+	return cs
+}
+
+// Flags lists the flag constants
+func Flags() []Constant {
+	var cs []Constant
+	for _, n := range(flagNames) {
+		d, _ := lookupName(flagDescriptions, n.value)
+		cs = append(cs, Constant{ n.name, n.value, d, 0 })
+	}
+
+	return cs
+}
+
+// Dialects lists the output dialects
+func Dialects() []Constant {
+	var cs []Constant
+	for _, n := range(dialectNames) { cs = append(cs, Constant{ n.name, n.value, dialectDescriptions[Dialect(n.value)], 0 }) }
+
+	return cs
+}
+
+func explainClass(c string) string {
+	names := splitClass(c)
+	if names == nil { return "one character of " + strconv.Quote("[" + c + "]") }
+
+	var d []string
+	for _, n := range(names) { d = append(d, classDescriptions[n]) }
+	return "one character of " + strings.Join(d, " or ") + " " + strconv.Quote("[" + c + "]")
+}
+
+func explainFixed(f string) string {
+	re, e := syntax.Parse(f, syntax.Perl)
+	if e == nil && re.Op == syntax.OpLiteral && re.Flags & syntax.FoldCase == 0 {
+		return "the text " + strconv.Quote(string(re.Rune))
+	}
+
+	return "the expression " + strconv.Quote(f)
+}
+
+func explainQuantifier(q rexQuan) string {
+	d, ok := quantifierDescriptions[q.regexp]
+	if !ok { return "" }
+	switch(strings.Count(d, "%d")) {
+	case 1:
+		d = fmt.Sprintf(d, q.argv[0])
+	case 2:
+		d = fmt.Sprintf(d, q.argv[0], q.argv[1])
+	}
+
+	return d
+}
+
+// Explain describes the expression in plain words, one line per group
+//
+// a class token is followed by the tokens after it up to the next fixed
+// string, and each fixed string ends an alternative, so the group
+// ([A-Z]com|net) reads as an upper-case letter followed by "com", or "net";
+// an empty fixed string ends the classes before it, as in numeric ranges.
+// a quantifier on a fixed string of more than one character is reported as
+// applying to its final character, as it does in the produced expression.
+func (g *Gorex) Explain() (string, error) {
+	o := bytes.NewBufferString("")
+	for gi, gr := range(g.groups) {
+		var alts []string
+		var parts []string
+		for _, tk := range(gr.tokens) {
+			if tk.class != NoClass && len(tk.fixed) != 0 {
+				return "", errors.New("Gorex @98: invalid token error")
+			}
+			if tk.class == NoClass && len(tk.fixed) == 0 && len(parts) != 0 {
+				// only ends the alternative of the classes before it
+				alts = append(alts, strings.Join(parts, " followed by "))
+				parts = nil
+				continue
+			}
+			var p string
+			if tk.class != NoClass {
+				p = explainClass(tk.class)
+			} else {
+				p = explainFixed(tk.fixed)
+			}
+			if q := explainQuantifier(tk.quantifier); q != "" {
+				if tk.class == NoClass && len(tk.fixed) > 1 {
+					p += ", its final character " + q
+				} else {
+					p += " " + q
+				}
+			}
+			parts = append(parts, p)
+			if tk.class == NoClass {
+				alts = append(alts, strings.Join(parts, " followed by "))
+				parts = nil
+			}
+		}
+		if len(parts) != 0 { alts = append(alts, strings.Join(parts, " followed by ")) }
+
+		if gr.name != "" {
+			fmt.Fprintf(o, "group %d (%s): ", gi + 1, gr.name)
+		} else {
+			fmt.Fprintf(o, "group %d: ", gi + 1)
+		}
+		if gr.anchor == atBeginning {
+			o.WriteString("at the beginning, ")
+		} else if gr.anchor != "" {
+			fmt.Fprintf(o, "after the anchor %q, ", string(gr.anchor))
+		}
+		if len(alts) > 1 { o.WriteString("either ") }
+		o.WriteString(strings.Join(alts, ", or "))
+
+		var flags []string
+		for _, f := range(flagDescriptions) {
+			if strings.Contains(gr.flagString(), f.name) { flags = append(flags, f.value) }
+		}
+		if len(flags) != 0 { o.WriteString(" (" + strings.Join(flags, ", ") + ")") }
+		o.WriteString("\n")
+	}
+
+	return o.String(), nil
+}
+
+// the letters of the flags set on a group
+func (gr rexGroup) flagString() string {
+	var f string
+	if gr.flags.i { f += CaseInsensitive }
+	if gr.flags.m { f += MultiLineMode }
+	if gr.flags.s { f += PeriodMatchesNewline }
+	if gr.flags.U { f += UngreedySwap }
+
+	return f
+}
@@ -0,0 +1,64 @@
+package gorex
+
+import(
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var g *Gorex
+
+	g, _ = GolangExpression()
+	g.AddClass(Uppers)
+	g.AddClassToLast(Digits)
+	g.ApplyQuantifier(MinToMax, 2, 3)
+	g.AddFixed("\\.")
+	g.AddFixedToLast("c.m")
+	g.AddFixed("net")
+	g.ApplyQuantifier(ZeroOrOnePrefFewer)
+	g.SetFlags(CaseInsensitive)
+	g.ApplyAnchor(atBeginning)
+
+	w := "group 1: one character of upper-case letters or digits \"[A-Z0-9]\" 2 to 3 times\n" +
+		"group 2: either the text \".\", or the expression \"c.m\"\n" +
+		"group 3: at the beginning, the text \"net\", its final character zero or one time, preferring fewer (case insensitive)\n"
+	o, e := g.Explain()
+	if e != nil { t.Fatalf("Explain() unexpected error: %s\n", e) }
+	if o != w { t.Fatalf("Explain() not correct:\n%s\n!=\n%s\n", o, w) }
+
+	// a class followed by fixed strings
+	g, _ = GolangExpression()
+	g.AddClass(Digits)
+	g.AddFixedToLast("px")
+	g.AddFixedToLast("em")
+	w = "group 1: either one character of digits \"[0-9]\" followed by the text \"px\", or the text \"em\"\n"
+	if o, _ = g.Explain(); o != w { t.Fatalf("Explain() not correct:\n%s\n!=\n%s\n", o, w) }
+
+	// named groups
+	g, _ = GolangExpression()
+	g.AddClass(Digits)
+	g.ApplyQuantifier(Exactly, 4)
+	g.NameGroup("year")
+	w = "group 1 (year): one character of digits \"[0-9]\" exactly 4 times\n"
+	if o, _ = g.Explain(); o != w { t.Fatalf("Explain() not correct:\n%s\n!=\n%s\n", o, w) }
+
+	// tokens holding both a class and a fixed string
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.AddClassToLast(Digits)
+	if _, e = g.Explain(); e == nil { t.Fatalf("Explain() expected invalid token error\n") }
+}
+
+func TestConstants(t *testing.T) {
+	for _, c := range(Classes()) {
+		if !verifyClass(c.Value) || c.Description == "" { t.Fatalf("Classes() has %+v\n", c) }
+	}
+	qs := Quantifiers()
+	if len(qs) != 13 || qs[0] != (Constant{ "Single", "", "once", 0 }) { t.Fatalf("Quantifiers() = %+v\n", qs) }
+	for _, q := range(qs) {
+		if q.Name == "MinToMax" && (q.Description != "n to n times" || q.Args != 2) { t.Fatalf("Quantifiers() has %+v\n", q) }
+	}
+	if fs := Flags(); len(fs) != 4 || fs[0] != (Constant{ "CaseInsensitive", CaseInsensitive, "case insensitive", 0 }) { t.Fatalf("Flags() = %+v\n", fs) }
+	for _, d := range(Dialects()) {
+		if !verifyDialect(Dialect(d.Value)) || d.Description == "" { t.Fatalf("Dialects() has %+v\n", d) }
+	}
+}
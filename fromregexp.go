@@ -0,0 +1,215 @@
+package gorex
+
+// expressions from regular expressions
+//
+// FromRegexp builds an expression from a golang regular expression, one
+// group for each part of the top-level sequence:
+//
+//  rex, e := FromRegexp(`^id=(\d+)(com|net)?$`)   // (^id=)([0-9]+)(com|net?)...
+//
+// simple parts become classes, fixed strings and quantifiers; anything else
+// is kept as the fixed string of its golang syntax. the result matches the
+// same strings, although its groups capture differently; only the names of
+// top-level captures are kept, on their groups.
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// golang syntax with characters outside ascii escaped, as AddFixed wants
+func asciiSyntax(s string) string {
+	o := strings.Builder{ }
+	for _, r := range(s) {
+		if r > unicode.MaxASCII {
+			fmt.Fprintf(&o, "\\x{%x}", r)
+			continue
+		}
+		o.WriteRune(r)
+	}
+
+	return o.String()
+}
+
+// the class constants whose union is exactly ranges, nil if there are none
+func classConstants(ranges []rune) []string {
+	want := rangesString(normalizeRanges(ranges))
+	var pick func(from int, names []string) []string
+	pick = func(from int, names []string) []string {
+		if len(names) != 0 && rangesString(namesRanges(names)) == want { return names }
+		if len(names) == 3 { return nil }
+		for i := from; i < len(classNames); i++ {
+			if r := pick(i + 1, append(append([]string{ }, names...), classNames[i].name)); r != nil { return r }
+		}
+		return nil
+	}
+	names := pick(0, nil)
+
+	// in the order AddClassToLast keeps them apart
+	var values []string
+	for _, n := range(names) {
+		v, _ := lookupName(classNames, n)
+		values = append(values, v)
+	}
+	if splitClass(strings.Join(values, "")) == nil { return nil }
+
+	return values
+}
+
+// the golang syntax of a part, with anchors as they are usually written
+func partSyntax(re *syntax.Regexp) string {
+	switch(re.Op) {
+	case syntax.OpBeginText:
+		return "^"
+	case syntax.OpEndText:
+		if re.Flags & syntax.WasDollar != 0 { return "$" }
+	case syntax.OpAnyCharNotNL:
+		return "."
+	case syntax.OpLiteral:
+		if re.Flags & syntax.FoldCase == 0 { return asciiSyntax(regexp.QuoteMeta(string(re.Rune))) }
+	}
+
+	return asciiSyntax(re.String())
+}
+
+// whether a part is matched by one token a quantifier can follow
+func atom(re *syntax.Regexp) bool {
+	switch(re.Op) {
+	case syntax.OpLiteral:
+		return len(re.Rune) == 1
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	}
+
+	return false
+}
+
+// the quantifier of a repetition, false for other parts
+func repetition(re *syntax.Regexp) (Quantifier, []int, bool) {
+	lazy := re.Flags & syntax.NonGreedy != 0
+	pick := func(greedy Quantifier, fewer Quantifier) Quantifier {
+		if lazy { return fewer }
+		return greedy
+	}
+
+	switch(re.Op) {
+	case syntax.OpStar:
+		return pick(ZeroOrMore, ZeroOrMorePrefFewer), nil, true
+	case syntax.OpPlus:
+		return pick(OneOrMore, OneOrMorePrefFewer), nil, true
+	case syntax.OpQuest:
+		return pick(ZeroOrOne, ZeroOrOnePrefFewer), nil, true
+	case syntax.OpRepeat:
+		if re.Max == re.Min { return pick(Exactly, ExactlyPrefFewer), []int{ re.Min }, true }
+		if re.Max < 0 { return pick(MinOrMore, MinOrMorePrefFewer), []int{ re.Min }, true }
+		return pick(MinToMax, MinToMaxPrefFewer), []int{ re.Min, re.Max }, true
+	}
+
+	return Single, nil, false
+}
+
+// adds the single token of a part as a new group
+func (g *Gorex) addPart(re *syntax.Regexp) error {
+	if re.Op == syntax.OpCharClass {
+		if names := classConstants(re.Rune); names != nil {
+			if e := g.AddClass(names[0]); e != nil { return e }
+			for _, n := range(names[1:]) {
+				if e := g.AddClassToLast(n); e != nil { return e }
+			}
+			return nil
+		}
+	}
+	fixed := partSyntax(re)
+	fold := re.Op == syntax.OpLiteral && re.Flags & syntax.FoldCase != 0
+	if fold { fixed = asciiSyntax(regexp.QuoteMeta(strings.ToLower(string(re.Rune)))) }
+	if e := g.AddFixed(fixed); e != nil { return e }
+	if fold { return g.SetFlags(CaseInsensitive) }
+
+	return nil
+}
+
+// adds a group for one part of the top-level sequence
+func (g *Gorex) addGroup(re *syntax.Regexp) error {
+	if re.Op == syntax.OpCapture { re = re.Sub[0] }
+
+	if re.Op == syntax.OpAlternate {
+		if e := g.AddFixed(partSyntax(re.Sub[0])); e != nil { return e }
+		for _, s := range(re.Sub[1:]) {
+			if e := g.AddFixedToLast(partSyntax(s)); e != nil { return e }
+		}
+		return nil
+	}
+	if q, args, ok := repetition(re); ok && atom(re.Sub[0]) {
+		if e := g.addPart(re.Sub[0]); e != nil { return e }
+		return g.ApplyQuantifier(q, args...)
+	}
+
+	return g.addPart(re)
+}
+
+// the syntax of re without captures, for comparing what expressions match
+func uncaptured(re *syntax.Regexp) (string, error) {
+	var strip func(re *syntax.Regexp) *syntax.Regexp
+	strip = func(re *syntax.Regexp) *syntax.Regexp {
+		for re.Op == syntax.OpCapture { re = re.Sub[0] }
+		for i, s := range(re.Sub) { re.Sub[i] = strip(s) }
+		return re
+	}
+
+	// parsed again, as removing captures can leave literals to merge
+	n, e := syntax.Parse(strip(re).String(), syntax.Perl)
+	if e != nil { return "", e }
+
+	return n.Simplify().String(), nil
+}
+
+// FromRegexp builds an expression from golang regular expression syntax;
+// the expression matches the same strings, which is checked on the parsed
+// syntax, but its groups are the parts of the top-level sequence
+func FromRegexp(expr string) (*Gorex, error) {
+	re, e := syntax.Parse(expr, syntax.Perl)
+	if e != nil { return nil, e }
+	parts := []*syntax.Regexp{ re }
+	if re.Op == syntax.OpConcat { parts = re.Sub }
+	names := make([]string, len(parts))
+	for i, p := range(parts) {
+		if p.Op == syntax.OpCapture { names[i] = p.Name }
+	}
+	want, e := uncaptured(re)
+	if e != nil { return nil, e }
+
+	g, _ := GolangExpression()
+	name := func(i int) error {
+		if names[i] == "" { return nil }
+		return g.NameGroup(names[i])
+	}
+	for i := 0; i < len(parts); i++ {
+		p := parts[i]
+		// a beginning anchor leads the group after it, unless that group
+		// has alternatives the anchor would not lead
+		if p.Op == syntax.OpBeginText && i + 1 < len(parts) && parts[i+1].Op != syntax.OpAlternate &&
+				!(parts[i+1].Op == syntax.OpCapture && parts[i+1].Sub[0].Op == syntax.OpAlternate) {
+			if e = g.addGroup(parts[i+1]); e != nil { return nil, e }
+			if e = g.ApplyAnchor(atBeginning); e != nil { return nil, e }
+			if e = name(i + 1); e != nil { return nil, e }
+			i++
+			continue
+		}
+		if e = g.addGroup(p); e != nil { return nil, e }
+		if e = name(i); e != nil { return nil, e }
+	}
+
+	o, e := g.Output()
+	if e != nil { return nil, e }
+	out, e := syntax.Parse(o, syntax.Perl)
+	if e != nil { return nil, e }
+	got, e := uncaptured(out)
+	if e != nil { return nil, e }
+	if got != want { return nil, errors.New("Gorex @200: unable to represent " + expr + " as groups") }
+
+	return g, nil
+}
@@ -0,0 +1,60 @@
+package gorex
+
+import(
+	"strings"
+	"testing"
+)
+
+func TestFromRegexp(t *testing.T) {
+	cases := []struct {
+		expr, output string
+	}{
+		{ `^id=(\d+)(com|net)?$`, `(^id=)([0-9]+)((?:com|net)?)($)` },
+		{ `[a-z]+@[a-zA-Z0-9]+\.(com|net)`, `([a-z]+)(@)([0-9A-Za-z]+)(\.)(com|net)` },
+		{ `(?i)hello world`, `(?i)(hello world)` },
+		{ `a.b*?c{2,}(?:xy)+`, `(a)(.)(b*?)(c{2,})((?:xy)+)` },
+		{ `é+[^a]`, `(\x{e9}+)([^a])` },
+		{ `(?P<year>\d{4})-(?P<month>\d\d)`, `(?P<year>[0-9]{4})(-)(?P<month>[0-9][0-9])` },
+		{ `^(?P<id>\d+)(x(?P<n>\d))`, `(?P<id>^[0-9]+)(x[0-9])` },
+		{ `(?U)a+b`, `(a+?)(b)` },
+		{ `\bfoo\b|bar`, `(\bfoo\b|bar)` },
+		{ ``, `((?:))` },
+	}
+
+	for _, c := range(cases) {
+		g, e := FromRegexp(c.expr)
+		if e != nil { t.Fatalf("FromRegexp(%q) unexpected error: %s\n", c.expr, e) }
+		o, _ := g.Output()
+		if o != c.output { t.Fatalf("FromRegexp(%q) = %q; expected %q\n", c.expr, o, c.output) }
+
+		// the same strings as a whole
+		want, _ := GolangExpression()
+		want.AddFixed(asciiSyntax(c.expr))
+		if ok, x, e := Equivalent(g, want); !ok || e != nil { t.Fatalf("FromRegexp(%q) not equivalent: %q %v\n", c.expr, x, e) }
+	}
+
+	if _, e := FromRegexp(`a(`); e == nil { t.Fatalf("FromRegexp() expected error\n") }
+}
+
+func TestGoExpression(t *testing.T) {
+	g, _ := FromRegexp(`^[0-9]{2,3}(?i:x)`)
+	o, e := g.GoExpression()
+	if e != nil { t.Fatalf("GoExpression() unexpected error: %s\n", e) }
+	w := `func() string {
+	g, e := gorex.GolangExpression()
+	if e != nil { panic(e) }
+	if e = g.AddClass(gorex.Digits); e != nil { panic(e) }
+	if e = g.ApplyQuantifier(gorex.MinToMax, 2, 3); e != nil { panic(e) }
+	if e = g.ApplyAnchor("^"); e != nil { panic(e) }
+	if e = g.AddFixed("x"); e != nil { panic(e) }
+	if e = g.SetFlags(gorex.CaseInsensitive); e != nil { panic(e) }
+	o, e := g.Output()
+	if e != nil { panic(e) }
+
+	return o
+}()`
+	if o != w { t.Fatalf("GoExpression() = %s\nexpected %s\n", o, w) }
+	if s, _ := g.GoSource("build"); !strings.HasPrefix(s, "func build() (*gorex.Gorex, error) {\n\tg, e := gorex.GolangExpression()\n\tif e != nil { return nil, e }\n") {
+		t.Fatalf("GoSource() unexpected source %s\n", s)
+	}
+}
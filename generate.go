@@ -0,0 +1,237 @@
+package gorex
+
+// sample generation
+//
+// a Generator walks the groups of an expression, picking one of the
+// alternatives added with AddFixed/AddFixedToLast, characters from class
+// ranges and repetition counts within the quantifier bounds:
+//
+//  gen, e := rex.Generator(rand.NewSource(1))
+//  s, e := gen.Generate()          // matches rex
+//  n, e := gen.GenerateNegative()  // a small edit of a sample, not matching rex
+//
+// generated samples match the whole of the output expression.
+
+import (
+	"errors"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxRepeat bounds unbounded quantifiers unless the generator says
+// otherwise
+const DefaultMaxRepeat = 8
+
+// attempts at producing a sample before giving up, for anchors and empty
+// languages that rule samples out
+const generateAttempts = 100
+
+// Generator produces random strings matched, or nearly matched, by an
+// expression; it keeps the expression as it was when created
+type Generator struct {
+	// repetitions allowed beyond the minimum of +, * and {n,}
+	MaxRepeat int
+
+	rand *rand.Rand
+	groups [][]*syntax.Regexp // alternatives of each group
+	anchored *regexp.Regexp
+}
+
+// the alternatives of a group, split where Output separates them; the
+// anchor leads the first
+func (gr rexGroup) alternatives() ([]string, error) {
+	var alts []string
+	alt := string(gr.anchor)
+	for i, tk := range(gr.tokens) {
+		if tk.class != NoClass {
+			if len(tk.fixed) != 0 { return nil, errors.New("Gorex @51: invalid token error") }
+			alt += "[" + tk.class + "]"
+		}
+		if tk.class == NoClass {
+			alt += tk.fixed
+			if gr.endsAlternative(i) {
+				alts = append(alts, alt)
+				alt = ""
+			}
+		}
+		q, e := quantifierString(tk.quantifier)
+		if e != nil { return nil, e }
+		alt += q
+	}
+
+	return append(alts, alt), nil
+}
+
+// parses the alternatives of every group under the group's flags
+func (g *Gorex) parseGroups() ([][]*syntax.Regexp, error) {
+	var groups [][]*syntax.Regexp
+	for _, gr := range(g.groups) {
+		alts, e := gr.alternatives()
+		if e != nil { return nil, e }
+		var flags string
+		if f := gr.flagString(); f != "" { flags = "(?" + f + ")" }
+
+		var res []*syntax.Regexp
+		for _, a := range(alts) {
+			re, e := syntax.Parse(flags + a, syntax.Perl)
+			if e != nil { return nil, e }
+			res = append(res, re)
+		}
+		groups = append(groups, res)
+	}
+
+	return groups, nil
+}
+
+// Generator prepares a generator for the expression using src
+func (g *Gorex) Generator(src rand.Source) (*Generator, error) {
+	groups, e := g.parseGroups()
+	if e != nil { return nil, e }
+	rex, e := g.exampleRegexp()
+	if e != nil { return nil, e }
+
+	return &Generator{ DefaultMaxRepeat, rand.New(src), groups, rex }, nil
+}
+
+// picks a rune from class ranges (lo, hi pairs), preferring ascii when the
+// class has any
+func (gen *Generator) pickRune(ranges []rune) (rune, bool) {
+	if len(ranges) == 0 { return 0, false }
+
+	if gen.rand.Intn(8) != 0 {
+		var ascii []rune
+		for i := 0; i < len(ranges); i += 2 {
+			if ranges[i] > unicode.MaxASCII { break }
+			hi := ranges[i+1]
+			if hi > unicode.MaxASCII { hi = unicode.MaxASCII }
+			ascii = append(ascii, ranges[i], hi)
+		}
+		if len(ascii) != 0 { ranges = ascii }
+	}
+
+	var total int64
+	for i := 0; i < len(ranges); i += 2 { total += int64(ranges[i+1] - ranges[i]) + 1 }
+	for {
+		n := gen.rand.Int63n(total)
+		for i := 0; i < len(ranges); i += 2 {
+			size := int64(ranges[i+1] - ranges[i]) + 1
+			if n < size {
+				r := ranges[i] + rune(n)
+				if utf8.ValidRune(r) { return r, true }
+				break // surrogate half, pick again
+			}
+			n -= size
+		}
+	}
+}
+
+// repetition count between min and max, max < 0 being unbounded
+func (gen *Generator) repeat(min int, max int) int {
+	if max < 0 {
+		max = min + gen.MaxRepeat
+		if gen.MaxRepeat < 0 { max = min }
+	}
+	if max <= min { return min }
+
+	return min + gen.rand.Intn(max - min + 1)
+}
+
+var anyCharNotNL = []rune{ 0, '\n' - 1, '\n' + 1, unicode.MaxRune }
+var anyChar = []rune{ 0, unicode.MaxRune }
+
+// appends a random string matched by re to b; false if re matches nothing
+func (gen *Generator) walk(re *syntax.Regexp, b []rune) ([]rune, bool) {
+	ok := true
+	switch(re.Op) {
+	case syntax.OpNoMatch:
+		return b, false
+	case syntax.OpLiteral:
+		for _, r := range(re.Rune) {
+			if re.Flags & syntax.FoldCase != 0 {
+				folds := []rune{ r }
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) { folds = append(folds, f) }
+				r = folds[gen.rand.Intn(len(folds))]
+			}
+			b = append(b, r)
+		}
+	case syntax.OpCharClass, syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		ranges := re.Rune
+		if re.Op == syntax.OpAnyCharNotNL { ranges = anyCharNotNL }
+		if re.Op == syntax.OpAnyChar { ranges = anyChar }
+		var r rune
+		if r, ok = gen.pickRune(ranges); ok { b = append(b, r) }
+	case syntax.OpCapture:
+		return gen.walk(re.Sub[0], b)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch(re.Op) {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		for n := gen.repeat(min, max); n > 0 && ok; n-- { b, ok = gen.walk(re.Sub[0], b) }
+	case syntax.OpConcat:
+		for _, s := range(re.Sub) {
+			if b, ok = gen.walk(s, b); !ok { break }
+		}
+	case syntax.OpAlternate:
+		return gen.walk(re.Sub[gen.rand.Intn(len(re.Sub))], b)
+	}
+
+	return b, ok
+}
+
+// Generate produces a random string matched by the whole expression
+func (gen *Generator) Generate() (string, error) {
+	for n := 0; n < generateAttempts; n++ {
+		var b []rune
+		ok := true
+		for _, alts := range(gen.groups) {
+			if b, ok = gen.walk(alts[gen.rand.Intn(len(alts))], b); !ok { break }
+		}
+		// anchors are ignored by the walk; check their placement here
+		if ok && gen.anchored.MatchString(string(b)) { return string(b), nil }
+	}
+
+	return "", errors.New("Gorex @203: unable to generate a matching sample")
+}
+
+// mutates s by a single random edit
+func (gen *Generator) mutate(s []rune) []rune {
+	r, _ := gen.pickRune([]rune{ ' ', '~' })
+	if len(s) == 0 { return []rune{ r } }
+
+	i := gen.rand.Intn(len(s))
+	switch(gen.rand.Intn(5)) {
+	case 0: // delete
+		return append(s[:i:i], s[i+1:]...)
+	case 1: // insert
+		return append(append(s[:i:i], r), s[i:]...)
+	case 2: // replace
+		return append(append(s[:i:i], r), s[i+1:]...)
+	case 3: // duplicate
+		return append(append(s[:i:i], s[i]), s[i:]...)
+	}
+	// truncate
+	return s[:i:i]
+}
+
+// GenerateNegative produces a near miss: a generated sample changed by one
+// or two small edits so that the expression no longer matches it
+func (gen *Generator) GenerateNegative() (string, error) {
+	for n := 0; n < generateAttempts; n++ {
+		s, e := gen.Generate()
+		if e != nil { return "", e }
+		b := gen.mutate([]rune(s))
+		if gen.rand.Intn(2) == 0 { b = gen.mutate(b) }
+		if !gen.anchored.MatchString(string(b)) { return string(b), nil }
+	}
+
+	return "", errors.New("Gorex @237: unable to generate a near miss")
+}
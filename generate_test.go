@@ -0,0 +1,89 @@
+package gorex
+
+import(
+	"math/rand"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerate(t *testing.T) {
+	g := testEmail()
+	rex, _ := g.exampleRegexp()
+	gen, e := g.Generator(rand.NewSource(1))
+	if e != nil { t.Fatalf("Generator() unexpected error: %s\n", e) }
+
+	seen := map[string]bool{ }
+	for i := 0; i < 200; i++ {
+		s, e := gen.Generate()
+		if e != nil { t.Fatalf("Generate() unexpected error: %s\n", e) }
+		if !rex.MatchString(s) { t.Fatalf("Generate() %q does not match\n", s) }
+		seen[s[len(s) - 3:]] = true
+	}
+	if !seen["com"] || !seen["net"] { t.Fatalf("Generate() did not pick every alternative: %v\n", seen) }
+
+	// the same source gives the same samples
+	a, _ := g.Generator(rand.NewSource(7))
+	b, _ := g.Generator(rand.NewSource(7))
+	for i := 0; i < 10; i++ {
+		x, _ := a.Generate()
+		y, _ := b.Generate()
+		if x != y { t.Fatalf("Generate() not deterministic: %q != %q\n", x, y) }
+	}
+}
+
+func TestGenerateQuantifiers(t *testing.T) {
+	g, _ := GolangExpression()
+	g.AddClass(Digits)
+	g.ApplyQuantifier(MinToMax, 2, 4)
+	g.AddClass(Lowers)
+	g.ApplyQuantifier(OneOrMore)
+	g.SetFlags(CaseInsensitive)
+	g.AddFixed("x")
+	g.AddFixedToLast("y")
+	g.ApplyQuantifier(Exactly, 3)
+
+	gen, _ := g.Generator(rand.NewSource(2))
+	gen.MaxRepeat = 2
+	rex := regexp.MustCompile(`^[0-9]{2,4}(?i:[a-z]{1,3})(?:x|yyy)$`)
+	for i := 0; i < 200; i++ {
+		s, e := gen.Generate()
+		if e != nil { t.Fatalf("Generate() unexpected error: %s\n", e) }
+		if !rex.MatchString(s) { t.Fatalf("Generate() %q outside bounds\n", s) }
+	}
+
+	// negated classes stay valid utf-8
+	g, _ = GolangExpression()
+	g.AddFixed("[^a]")
+	g.ApplyQuantifier(Exactly, 5)
+	gen, _ = g.Generator(rand.NewSource(3))
+	for i := 0; i < 200; i++ {
+		s, e := gen.Generate()
+		if e != nil || !utf8.ValidString(s) || utf8.RuneCountInString(s) != 5 { t.Fatalf("Generate() %q, %v\n", s, e) }
+	}
+
+	// an anchor in the middle rules out every sample
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.AddFixed("b")
+	g.ApplyAnchor(atBeginning)
+	gen, _ = g.Generator(rand.NewSource(4))
+	if _, e := gen.Generate(); e == nil { t.Fatalf("Generate() expected error for unmatchable expression\n") }
+}
+
+func TestGenerateNegative(t *testing.T) {
+	g := testEmail()
+	rex, _ := g.exampleRegexp()
+	gen, _ := g.Generator(rand.NewSource(5))
+	for i := 0; i < 200; i++ {
+		s, e := gen.GenerateNegative()
+		if e != nil { t.Fatalf("GenerateNegative() unexpected error: %s\n", e) }
+		if rex.MatchString(s) { t.Fatalf("GenerateNegative() %q matches\n", s) }
+	}
+
+	// everything matches
+	g, _ = GolangExpression()
+	g.AddFixed("(?s:.*)")
+	gen, _ = g.Generator(rand.NewSource(6))
+	if _, e := gen.GenerateNegative(); e == nil { t.Fatalf("GenerateNegative() expected error\n") }
+}
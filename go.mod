@@ -1,3 +1,3 @@
 module github.com/dev-west/gorex
 
-go 1.16
+go 1.21
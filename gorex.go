@@ -47,12 +47,15 @@ import (
 type Gorex struct {
 	groups []rexGroup // expression details
 	unsafe bool
+	examples []rexExample // checked by Verify
 }
 
 type rexGroup struct {
 	tokens []rexToken
 	flags rexFlag
 	anchor Anchor
+	name string
+	numeric []string // the AddNumericRange arguments that made the group
 }
 
 type rexToken struct {
@@ -202,7 +205,11 @@ func (g *Gorex) Output() (string, error) {
 		}
 		if flagParens { o.WriteString(")") }
 
-		o.WriteString("(")
+		if gr.name != "" {
+			o.WriteString("(?P<" + gr.name + ">")
+		} else {
+			o.WriteString("(")
+		}
 		// add token data
 		if gr.anchor != "" { o.WriteString(string(gr.anchor)) }
 		for i, tk := range(gr.tokens) {
@@ -220,8 +227,8 @@ func (g *Gorex) Output() (string, error) {
 					return "", errors.New("Gorex @149: invalid token error")
 				}
 				o.WriteString(tk.fixed)
-				if(len(gr.tokens) > i + 1) { o.WriteString("|") }
 			}
+			if(gr.endsAlternative(i)) { o.WriteString("|") }
 
 			// add class quantity
 			argCount := regexp.MustCompile("%d") // only permits numbers
@@ -233,7 +240,7 @@ func (g *Gorex) Output() (string, error) {
 			}
 			switch(argc) {
 			case 0:
-				o.WriteString(fmt.Sprintf(string(tk.quantifier.regexp)))
+				o.WriteString(string(tk.quantifier.regexp))
 			case 1:
 				o.WriteString(fmt.Sprintf(string(tk.quantifier.regexp), tk.quantifier.argv[0]))
 			case 2:
@@ -249,6 +256,15 @@ func (g *Gorex) Output() (string, error) {
 	return o.String(), nil
 }
 
+// whether token i ends an alternative, with tokens after it: a fixed
+// string does, and an empty one only after a class, as AddNumericRange
+// ends its alternatives
+func (gr rexGroup) endsAlternative(i int) bool {
+	if gr.tokens[i].class != NoClass || len(gr.tokens) <= i + 1 { return false }
+
+	return len(gr.tokens[i].fixed) != 0 || (i > 0 && gr.tokens[i - 1].class != NoClass)
+}
+
 func verifyClass(a string) bool {
 	if		a == NoClass ||
 			a == Ascii ||
@@ -400,6 +416,29 @@ func (g *Gorex) ApplyAnchor(m Anchor) error {
 	return nil
 }
 
+// whether a group name is a word, as golang and the other dialects accept
+func verifyName(n string) bool {
+	for i, ch := range(n) {
+		letter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !letter && !(i > 0 && ch >= '0' && ch <= '9') { return false }
+	}
+
+	return n != ""
+}
+
+// NameGroup names the last group, so that its match can be looked up by name
+func (g *Gorex) NameGroup(n string) error {
+	if len(g.groups) == 0 { return errors.New("Gorex @420: invalid group index") }
+	if !verifyName(n) { return errors.New("Gorex @421: invalid group name") }
+	for _, gr := range(g.groups[:len(g.groups)-1]) {
+		if gr.name == n { return errors.New("Gorex @423: duplicate group name") }
+	}
+
+	g.groups[len(g.groups)-1].name = n
+
+	return nil
+}
+
 func verifyFlags(c string) bool {
 	for _, ch := range(c) {
 		if	string(ch) != CaseInsensitive &&
@@ -3,6 +3,7 @@ package gorex
 import(
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -139,6 +140,25 @@ func TestAddClassToLast(t *testing.T) {
 	}
 }
 
+func TestEmptyFixed(t *testing.T) {
+	// an empty fixed string adds nothing
+	g, _ := GolangExpression()
+	g.AddFixed("")
+	g.AddFixedToLast("a")
+	g.AddFixedToLast("")
+	g.AddFixedToLast("b")
+	o, _ := g.Output()
+	if o != "(a|b)" { t.Fatalf("AddFixed(\"\") output %s\n", o) }
+
+	// but ends the classes before it
+	g, _ = GolangExpression()
+	g.AddClass(Uppers)
+	g.AddFixedToLast("")
+	g.AddFixedToLast("b")
+	o, _ = g.Output()
+	if o != "([A-Z]|b)" { t.Fatalf("AddFixedToLast(\"\") after a class output %s\n", o) }
+}
+
 func TestAddFixed(t *testing.T) {
 	var g *Gorex
 	var e error
@@ -320,6 +340,11 @@ func TestFlags(t *testing.T) {
 	if !r.MatchString(s+s) { t.Fatalf("r.MatchString(\"%s\") failed to match", s) }
 	// test against (?i)(COM)(?-i)(com) against "comCOM"
 	if r.MatchString(s+f) { t.Fatalf("r.MatchString(\"%s\") unexpectedly matched", s) }
+	// same language as spelling out both cases of the first group
+	c, _ := GolangExpression()
+	c.AddFixed("[cC][oO][mM]")
+	c.AddFixed(s)
+	if ok, x, _ := Equivalent(g, c); !ok { t.Fatalf("Equivalent(\"%s\") differs on %q", o, x) }
 
 	// test MultiLineMode flag TODO--cannot test without ^$ support
 
@@ -390,7 +415,51 @@ func TestApplyAnchor(t *testing.T) {
 	if o != w { t.Fatalf("ApplyAnchor(\"%s\") output not correct \"%s\" != \"%s\"", a, o, w) }
 }
 
-func ExampleEmail() {
+func TestNameGroup(t *testing.T) {
+	var g *Gorex
+	var e error
+	var o string
+
+	g, _ = GolangExpression()
+
+	// test NameGroup without groups
+	e = g.NameGroup("year")
+	if e == nil { t.Fatalf("NameGroup(\"year\") without group expects error\n") }
+
+	g.AddClass(Digits)
+	g.ApplyQuantifier(Exactly, 4)
+
+	// test invalid names
+	for _, n := range([]string{ "", "1st", "a-b", "y\u00e9ar" }) {
+		if e = g.NameGroup(n); e == nil { t.Fatalf("NameGroup(%q) invalid name expects error\n", n) }
+	}
+
+	// test valid NameGroup
+	e = g.NameGroup("year")
+	if e != nil { t.Fatalf("NameGroup(\"year\") unexpected error: %s\n", e) }
+	g.AddFixed("-")
+	if e = g.NameGroup("year"); e == nil { t.Fatalf("NameGroup(\"year\") duplicate name expects error\n") }
+
+	// test output
+	o, e = g.Output()
+	if e != nil || o != "(?P<year>[0-9]{4})(-)" { t.Fatalf("NameGroup output not correct \"%s\" (%v)\n", o, e) }
+	o, e = g.OutputDialect(JavaScript)
+	if e != nil || o != "(?<year>[0-9]{4})(-)" { t.Fatalf("NameGroup JavaScript output not correct \"%s\" (%v)\n", o, e) }
+	if _, e = g.OutputDialect(POSIX); e == nil { t.Fatalf("NameGroup POSIX output expects error\n") }
+
+	// test the name is kept by definitions and JSON
+	d, e := g.Definition()
+	if e != nil || !strings.Contains(d, "NameGroup \"year\"\n") { t.Fatalf("NameGroup definition lacks the name:\n%s\n", d) }
+	n, e := ParseDefinition(strings.NewReader(d))
+	if e != nil { t.Fatalf("NameGroup definition failed: %s\n", e) }
+	if o, _ = n.Output(); o != "(?P<year>[0-9]{4})(-)" { t.Fatalf("NameGroup definition output \"%s\"\n", o) }
+	j, _ := g.MarshalJSON()
+	n = &Gorex{ }
+	if e = n.UnmarshalJSON(j); e != nil { t.Fatalf("NameGroup JSON failed: %s\n", e) }
+	if o, _ = n.Output(); o != "(?P<year>[0-9]{4})(-)" { t.Fatalf("NameGroup JSON output \"%s\"\n", o) }
+}
+
+func Example_email() {
 	var g *Gorex
 	var e error
 
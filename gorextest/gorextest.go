@@ -0,0 +1,52 @@
+// Package gorextest checks gorex expressions from go tests
+//
+// the examples attached to an expression run as subtests, one per example,
+// and generated samples seed fuzz tests:
+//
+//  func TestEmail(t *testing.T) {
+//  	rex := buildEmail()
+//  	gorextest.TestExamples(t, rex, "email")
+//  }
+//
+//  func FuzzParse(f *testing.F) {
+//  	gen, _ := buildEmail().Generator(rand.NewSource(1))
+//  	if e := gorextest.AddCorpus(f, gen, 20); e != nil { f.Fatal(e) }
+//  	f.Fuzz(func(t *testing.T, s string) { ... })
+//  }
+//
+// it is kept apart from package gorex so that programs using expressions do
+// not link package testing.
+package gorextest
+
+import (
+	"testing"
+
+	"github.com/dev-west/gorex"
+)
+
+// TestExamples runs the examples of g as a subtest of t named name, one
+// subtest per example, and reports whether they all passed
+func TestExamples(t *testing.T, g *gorex.Gorex, name string) bool {
+	return t.Run(name, func(t *testing.T) {
+		e := g.VerifyEach(func(x gorex.Example, v []gorex.Violation) {
+			t.Run(x.Input, func(t *testing.T) {
+				for _, vi := range(v) { t.Error(vi) }
+			})
+		})
+		if e != nil { t.Fatal(e) }
+	})
+}
+
+// AddCorpus adds n samples of gen and n near misses to the seed corpus of a
+// fuzz test
+func AddCorpus(f *testing.F, gen *gorex.Generator, n int) error {
+	for i := 0; i < n; i++ {
+		s, e := gen.Generate()
+		if e != nil { return e }
+		f.Add(s)
+		if s, e = gen.GenerateNegative(); e != nil { return e }
+		f.Add(s)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package gorextest
+
+import(
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/dev-west/gorex"
+)
+
+// a whole e-mail address of .com
+func testEmail() *gorex.Gorex {
+	g, _ := gorex.GolangExpression()
+	g.AddClass(gorex.Words)
+	g.ApplyQuantifier(gorex.OneOrMore)
+	g.AddFixed("@")
+	g.AddClass(gorex.Lowers)
+	g.ApplyQuantifier(gorex.OneOrMore)
+	g.AddFixed("\\.com")
+
+	return g
+}
+
+func TestTestExamples(t *testing.T) {
+	g := testEmail()
+	g.ShouldMatch("joe@mail.com")
+	g.ShouldNotMatch("joe@mail.org")
+	g.ShouldMatchGroups("ann@co.com", "ann", "@", "co", ".com")
+	if !TestExamples(t, g, "email") { t.Fatalf("TestExamples() failed\n") }
+}
+
+func FuzzGenerated(f *testing.F) {
+	o, _ := testEmail().Output()
+	rex := regexp.MustCompile("^(?:" + o + ")$")
+	gen, _ := testEmail().Generator(rand.NewSource(8))
+	if e := AddCorpus(f, gen, 10); e != nil { f.Fatal(e) }
+	f.Fuzz(func(t *testing.T, s string) {
+		g := testEmail()
+		g.ShouldMatch(s)
+		v, ok := g.Verify().(*gorex.VerifyError)
+		if ok != !rex.MatchString(s) || (ok && len(v.Violations) != 1) { t.Fatalf("Verify() disagrees on %q\n", s) }
+	})
+}
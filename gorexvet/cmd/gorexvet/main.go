@@ -0,0 +1,15 @@
+// gorexvet checks the use of gorex builders (see package gorexvet)
+//
+// usage
+//  gorexvet [packages]                  run alone, as singlechecker does
+//  go vet -vettool=$(which gorexvet) ./...
+package main
+
+import (
+	"github.com/dev-west/gorex/gorexvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(gorexvet.Analyzer)
+}
@@ -0,0 +1,314 @@
+// Package gorexvet defines an analyzer checking the use of gorex builders
+//
+// it reports calls to gorex functions whose error is dropped, and where the
+// builder calls on an expression can be followed from GolangExpression with
+// constant arguments, it replays them to report what would fail or mislead
+// at run time: quantifiers given the wrong number of arguments, classes that
+// need the Unsafe option, and literal text with unescaped metacharacters
+// given to AddFixed.
+//
+//  go install github.com/dev-west/gorex/gorexvet/cmd/gorexvet
+//  go vet -vettool=$(which gorexvet) ./...
+package gorexvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/dev-west/gorex"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const gorexPath = "github.com/dev-west/gorex"
+
+var Analyzer = &analysis.Analyzer{
+	Name: "gorex",
+	Doc: "check gorex builder calls for dropped errors and invalid arguments",
+	URL: "https://pkg.go.dev/github.com/dev-west/gorex/gorexvet",
+	Run: run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range(pass.Files) {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ExprStmt:
+				checkDropped(pass, n)
+			case *ast.CallExpr:
+				checkArguments(pass, n)
+			case *ast.BlockStmt:
+				replayBlock(pass, n.List)
+			case *ast.CaseClause:
+				replayBlock(pass, n.Body)
+			case *ast.CommClause:
+				replayBlock(pass, n.Body)
+			}
+			return true
+		})
+	}
+
+	return nil, nil
+}
+
+// the gorex function or method called, if any
+func gorexCallee(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != gorexPath { return nil }
+
+	return fn
+}
+
+func returnsError(fn *types.Func) bool {
+	res := fn.Type().(*types.Signature).Results()
+	if res.Len() == 0 { return false }
+
+	return types.Identical(res.At(res.Len() - 1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// gorex calls used as statements drop their error
+func checkDropped(pass *analysis.Pass, stmt *ast.ExprStmt) {
+	call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+	if !ok { return }
+	fn := gorexCallee(pass, call)
+	if fn == nil || !returnsError(fn) { return }
+
+	pass.Reportf(call.Pos(), "error returned by gorex %s is not checked", fn.Name())
+}
+
+// the constant values of args, or nil if one is not constant
+func constants(pass *analysis.Pass, args []ast.Expr) []constant.Value {
+	vs := []constant.Value{ }
+	for _, a := range(args) {
+		v := pass.TypesInfo.Types[a].Value
+		if v == nil { return nil }
+		vs = append(vs, v)
+	}
+
+	return vs
+}
+
+func stringArg(v constant.Value) (string, bool) {
+	if v.Kind() != constant.String { return "", false }
+
+	return constant.StringVal(v), true
+}
+
+// checks of single calls, needing no other call of the expression
+func checkArguments(pass *analysis.Pass, call *ast.CallExpr) {
+	fn := gorexCallee(pass, call)
+	if fn == nil || fn.Type().(*types.Signature).Recv() == nil { return }
+
+	switch(fn.Name()) {
+	case "ApplyQuantifier":
+		if len(call.Args) == 0 || call.Ellipsis.IsValid() { return }
+		v := pass.TypesInfo.Types[call.Args[0]].Value
+		if v == nil { return }
+		q, _ := stringArg(v)
+		want, got := strings.Count(q, "%d"), len(call.Args) - 1
+		if want != got {
+			pass.Reportf(call.Pos(), "quantifier %s takes %d arguments, not %d", types.ExprString(call.Args[0]), want, got)
+		}
+	case "AddFixed", "AddFixedToLast":
+		vs := constants(pass, call.Args)
+		if len(vs) != 1 { return }
+		s, ok := stringArg(vs[0])
+		if !ok { return }
+		g, _ := gorex.GolangExpression()
+		if g.AddFixed(s) != nil { return }
+		for _, w := range(g.Lint()) {
+			if w.Kind == gorex.UnescapedMetacharacter { pass.Reportf(call.Args[0].Pos(), "metacharacter in literal text: %s", w.Message) }
+		}
+	}
+}
+
+// builder calls replayed on an expression, by name
+var builders = map[string]func(g *gorex.Gorex, args []constant.Value) error {
+	"AddClass": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.AddClass(s)
+	},
+	"AddClassToLast": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.AddClassToLast(s)
+	},
+	"AddFixed": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.AddFixed(s)
+	},
+	"AddFixedToLast": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.AddFixedToLast(s)
+	},
+	"ApplyQuantifier": func(g *gorex.Gorex, args []constant.Value) error {
+		q, _ := stringArg(args[0])
+		var n []int
+		for _, a := range(args[1:]) {
+			i, _ := constant.Int64Val(a)
+			n = append(n, int(i))
+		}
+		return g.ApplyQuantifier(gorex.Quantifier(q), n...)
+	},
+	"ApplyAnchor": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.ApplyAnchor(gorex.Anchor(s))
+	},
+	"NameGroup": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.NameGroup(s)
+	},
+	"SetFlags": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.SetFlags(s)
+	},
+	"ClearFlags": func(g *gorex.Gorex, args []constant.Value) error {
+		s, _ := stringArg(args[0])
+		return g.ClearFlags(s)
+	},
+}
+
+// an expression followed through a block
+type replay struct {
+	g *gorex.Gorex
+	unsafe bool
+}
+
+// the gorex call of a statement: a call statement, an assignment of its
+// results or the init statement of an if
+func statementCall(stmt ast.Stmt) *ast.CallExpr {
+	var x ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		x = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 { x = s.Rhs[0] }
+	case *ast.IfStmt:
+		if s.Init != nil { return statementCall(s.Init) }
+	}
+	call, _ := ast.Unparen(x).(*ast.CallExpr)
+
+	return call
+}
+
+// the expression variable a GolangExpression call defines or assigns
+func definedExpression(pass *analysis.Pass, stmt ast.Stmt) types.Object {
+	var lhs ast.Expr
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		if len(s.Lhs) == 0 { return nil }
+		lhs = s.Lhs[0]
+	case *ast.DeclStmt:
+		gd, ok := s.Decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR || len(gd.Specs) != 1 { return nil }
+		vs := gd.Specs[0].(*ast.ValueSpec)
+		if len(vs.Values) != 1 { return nil }
+		lhs = vs.Names[0]
+	}
+	id, ok := lhs.(*ast.Ident)
+	if !ok { return nil }
+
+	return pass.TypesInfo.ObjectOf(id)
+}
+
+// whether n refers to obj
+func uses(pass *analysis.Pass, n ast.Node, obj types.Object) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == obj { found = true }
+		return !found
+	})
+
+	return found
+}
+
+// follows expressions defined in a block through the builder calls made
+// on them in the same block; any other use, or a call with arguments that
+// are not constant, ends the replay of that expression
+func replayBlock(pass *analysis.Pass, stmts []ast.Stmt) {
+	exprs := map[types.Object]*replay{ }
+	for _, stmt := range(stmts) {
+		call := statementCall(stmt)
+		if d, ok := stmt.(*ast.DeclStmt); ok && call == nil && len(d.Decl.(*ast.GenDecl).Specs) == 1 {
+			if vs, ok := d.Decl.(*ast.GenDecl).Specs[0].(*ast.ValueSpec); ok && len(vs.Values) == 1 {
+				call, _ = ast.Unparen(vs.Values[0]).(*ast.CallExpr)
+			}
+		}
+
+		// a new expression
+		if call != nil {
+			if fn := gorexCallee(pass, call); fn != nil && fn.Name() == "GolangExpression" {
+				obj := definedExpression(pass, stmt)
+				if obj == nil { continue }
+				delete(exprs, obj)
+				vs := constants(pass, call.Args)
+				if vs == nil || call.Ellipsis.IsValid() { continue }
+				var opts []string
+				for _, v := range(vs) {
+					s, _ := stringArg(v)
+					opts = append(opts, s)
+				}
+				g, e := gorex.GolangExpression(opts...)
+				if e != nil { continue }
+				exprs[obj] = &replay{ g, len(opts) == 1 && opts[0] == gorex.Unsafe }
+				continue
+			}
+		}
+
+		// a builder call on one of them
+		var target types.Object
+		if call != nil {
+			if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+				if id, ok := ast.Unparen(sel.X).(*ast.Ident); ok { target = pass.TypesInfo.Uses[id] }
+			}
+		}
+		r, tracked := exprs[target]
+		build, builder := builders[methodName(call)]
+		if tracked && builder && gorexCallee(pass, call) != nil {
+			vs := constants(pass, call.Args)
+			if vs != nil && !call.Ellipsis.IsValid() && len(vs) > 0 {
+				replayCall(pass, call, r, build, vs)
+				// the rest of the statement, as the body of an if
+				for obj := range(exprs) {
+					if st, ok := stmt.(*ast.IfStmt); ok && uses(pass, st.Body, obj) { delete(exprs, obj) }
+				}
+				continue
+			}
+		}
+
+		for obj := range(exprs) {
+			if uses(pass, stmt, obj) { delete(exprs, obj) }
+		}
+	}
+}
+
+func methodName(call *ast.CallExpr) string {
+	if call == nil { return "" }
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok { return sel.Sel.Name }
+
+	return ""
+}
+
+// replays one builder call, reporting its failure
+func replayCall(pass *analysis.Pass, call *ast.CallExpr, r *replay, build func(*gorex.Gorex, []constant.Value) error, vs []constant.Value) {
+	name := methodName(call)
+	if v := vs[0]; v.Kind() != constant.String { return }
+	if name == "ApplyQuantifier" {
+		// counts are reported by checkArguments
+		q, _ := stringArg(vs[0])
+		if strings.Count(q, "%d") != len(vs) - 1 { return }
+	}
+
+	e := build(r.g, vs)
+	if e == nil { return }
+	if (name == "AddClass" || name == "AddClassToLast") && !r.unsafe {
+		u, _ := gorex.GolangExpression(gorex.Unsafe)
+		if u.AddFixed("x") == nil && build(u, vs) == nil {
+			pass.Reportf(call.Pos(), "class %s is only accepted by expressions with the Unsafe option", types.ExprString(call.Args[0]))
+			return
+		}
+	}
+	pass.Reportf(call.Pos(), "gorex %s fails: %s", name, e)
+}
@@ -0,0 +1,59 @@
+package a
+
+import (
+	"fmt"
+
+	"github.com/dev-west/gorex"
+)
+
+func dropped() {
+	rex, _ := gorex.GolangExpression()
+	rex.AddClass(gorex.Digits) // want "error returned by gorex AddClass is not checked"
+	_ = rex.AddFixed("com")
+	rex.ShouldMatch("1com")
+	if e := rex.ApplyQuantifier(gorex.OneOrMore); e != nil { fmt.Println(e) }
+	gorex.GolangExpression() // want "error returned by gorex GolangExpression is not checked"
+}
+
+func arguments() error {
+	rex, e := gorex.GolangExpression()
+	if e != nil { return e }
+	if e := rex.AddClass(gorex.Lowers); e != nil { return e }
+	if e := rex.ApplyQuantifier(gorex.Exactly, 2, 3); e != nil { return e } // want `quantifier gorex.Exactly takes 1 arguments, not 2`
+	if e := rex.ApplyQuantifier(gorex.MinToMax, 2, 3); e != nil { return e }
+	if e := rex.AddFixed("gmail.com"); e != nil { return e } // want `metacharacter in literal text: "gmail.com"`
+	if e := rex.AddFixedToLast("gmail\\.com"); e != nil { return e }
+	return nil
+}
+
+func unsafeClasses(class string) error {
+	var rex, e = gorex.GolangExpression()
+	if e != nil { return e }
+	if e := rex.AddClass("^a-z"); e != nil { return e } // want `class "\^a-z" is only accepted by expressions with the Unsafe option`
+	if e := rex.AddClass(gorex.Digits); e != nil { return e }
+
+	unsafe, e := gorex.GolangExpression(gorex.Unsafe)
+	if e != nil { return e }
+	if e := unsafe.AddClass("^a-z"); e != nil { return e }
+
+	// not followed: the class is not constant
+	other, _ := gorex.GolangExpression()
+	if e := other.AddClass(class); e != nil { return e }
+	if e := other.AddClass("^a-z"); e != nil { return e }
+	return nil
+}
+
+func chain() error {
+	rex, e := gorex.GolangExpression()
+	if e != nil { return e }
+	if e := rex.ApplyQuantifier(gorex.OneOrMore); e != nil { return e } // want `gorex ApplyQuantifier fails: Gorex @289: invalid group index`
+	if e := rex.AddFixed("a"); e != nil { return e }
+	if e := rex.SetFlags("x"); e != nil { return e } // want `gorex SetFlags fails: .*invalid flag`
+	return nil
+}
+
+func declarations() {
+	var ()
+	var x, y = 1, 2
+	_, _ = x, y
+}
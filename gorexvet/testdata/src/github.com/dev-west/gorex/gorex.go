@@ -0,0 +1,34 @@
+// stub of the gorex API for the analyzer tests; constants keep their values
+package gorex
+
+type Gorex struct{}
+
+type Quantifier string
+
+type Anchor string
+
+const (
+	OneOrMore Quantifier = "+"
+	ZeroOrOne Quantifier = "?"
+	MinToMax Quantifier = "{%d,%d}"
+	Exactly Quantifier = "{%d}"
+)
+
+const (
+	Digits string = "0-9"
+	Lowers string = "a-z"
+)
+
+const Unsafe string = "Unsafe"
+
+func GolangExpression(opts ...string) (*Gorex, error) { return &Gorex{}, nil }
+
+func (g *Gorex) Output() (string, error) { return "", nil }
+func (g *Gorex) AddClass(c string) error { return nil }
+func (g *Gorex) AddClassToLast(c string) error { return nil }
+func (g *Gorex) AddFixed(a string) error { return nil }
+func (g *Gorex) AddFixedToLast(a string) error { return nil }
+func (g *Gorex) ApplyQuantifier(q Quantifier, args ...int) error { return nil }
+func (g *Gorex) ApplyAnchor(m Anchor) error { return nil }
+func (g *Gorex) SetFlags(c string) error { return nil }
+func (g *Gorex) ShouldMatch(inputs ...string) {}
@@ -0,0 +1,261 @@
+package gorex
+
+// lint
+//
+// Lint looks for builder mistakes that still produce a valid expression:
+//
+//  rex.AddFixed("gmail.com")            // . matches any character
+//  rex.AddFixed("com")
+//  rex.AddFixedToLast("net")
+//  rex.ApplyQuantifier(ZeroOrOne)       // (com|net?), not (com|net)?
+//  for _, w := range(rex.Lint()) { fmt.Println(w) }
+//
+// warnings count groups from 1, as Explain does, and tokens from 1 in the
+// order they were added; Token is 0 for warnings about a whole group.
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// LintKind is the kind of mistake a Warning reports
+type LintKind string
+
+const (
+	UnescapedMetacharacter LintKind = "unescaped metacharacter"
+	QuantifiedAlternative LintKind = "quantifier on the last alternative"
+	QuantifiedCharacter LintKind = "quantifier on the last character"
+	OverlappingClasses LintKind = "overlapping classes"
+	ShadowedAlternative LintKind = "shadowed alternative"
+	LazyUngreedy LintKind = "lazy quantifier with U flag"
+	MisplacedAnchor LintKind = "misplaced anchor"
+	EmptyMatch LintKind = "empty match"
+)
+
+// Warning is a likely mistake in one group, or one token of it
+type Warning struct {
+	Kind LintKind
+	Group int
+	Token int
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Token == 0 { return fmt.Sprintf("group %d: %s: %s", w.Group, w.Kind, w.Message) }
+
+	return fmt.Sprintf("group %d, token %d: %s: %s", w.Group, w.Token, w.Kind, w.Message)
+}
+
+// the quantifier as written in the output
+func (q rexQuan) String() string {
+	switch(strings.Count(string(q.regexp), "%d")) {
+	case 1:
+		return fmt.Sprintf(string(q.regexp), q.argv[0])
+	case 2:
+		return fmt.Sprintf(string(q.regexp), q.argv[0], q.argv[1])
+	}
+
+	return string(q.regexp)
+}
+
+// the metacharacters left unescaped in a fixed string that reads as literal
+// text: text around periods, quantified characters, parentheses, single
+// characters in brackets or anchors within it. empty when there are none, or
+// when the string reads as an expression
+func literalMetacharacters(fixed string) string {
+	if strings.Contains(fixed, "(?") { return "" }
+	re, e := syntax.Parse(fixed, syntax.Perl)
+	if e != nil { return "" }
+	parts := []*syntax.Regexp{ re }
+	if re.Op == syntax.OpConcat { parts = re.Sub }
+
+	text := false
+	for i, p := range(parts) {
+		switch(p.Op) {
+		case syntax.OpLiteral:
+			text = true
+		case syntax.OpAnyCharNotNL:
+		case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+			if p.Sub[0].Op != syntax.OpLiteral || len(p.Sub[0].Rune) != 1 { return "" }
+		case syntax.OpCapture:
+			if p.Sub[0].Op != syntax.OpLiteral { return "" }
+			text = true
+		case syntax.OpBeginText, syntax.OpBeginLine:
+			if i == 0 { return "" }
+		case syntax.OpEndText, syntax.OpEndLine:
+			if i == len(parts) - 1 { return "" }
+		default:
+			return ""
+		}
+	}
+	if !text { return "" }
+
+	// those QuoteMeta escapes that are not escaped
+	var metas string
+	for i := 0; i < len(fixed); i++ {
+		c := fixed[i : i+1]
+		if c == "\\" {
+			i++
+			continue
+		}
+		if regexp.QuoteMeta(c) != c && !strings.Contains(metas, c) { metas += c }
+	}
+
+	return metas
+}
+
+// the fixed string with its escapes removed, as written literally
+func literalText(fixed string) string {
+	var b strings.Builder
+	for i := 0; i < len(fixed); i++ {
+		if fixed[i] == '\\' && i + 1 < len(fixed) { i++ }
+		b.WriteByte(fixed[i])
+	}
+
+	return regexp.QuoteMeta(b.String())
+}
+
+// whether the quantifier applies to the whole of a fixed string
+func quantifiesWhole(fixed string, q rexQuan) bool {
+	a, e := syntax.Parse(fixed + q.String(), syntax.Perl)
+	if e != nil { return true }
+	b, e := syntax.Parse("(?:" + fixed + ")" + q.String(), syntax.Perl)
+	if e != nil { return true }
+
+	return a.Equal(b)
+}
+
+// the ranges of class constants
+func namesRanges(names []string) []rune {
+	var r []rune
+	for _, n := range(names) {
+		v, _ := lookupName(classNames, n)
+		re, _ := syntax.Parse("[" + v + "]", syntax.Perl)
+		r = append(r, re.Rune...)
+	}
+
+	return normalizeRanges(r)
+}
+
+func rangesOverlap(a []rune, b []rune) bool {
+	for i := 0; i + 1 < len(a); i += 2 {
+		for j := 0; j + 1 < len(b); j += 2 {
+			if a[i] <= b[j+1] && b[j] <= a[i+1] { return true }
+		}
+	}
+
+	return false
+}
+
+// whether re holds an anchor of one of ops
+func hasAnchor(re *syntax.Regexp, ops ...syntax.Op) bool {
+	for _, op := range(ops) {
+		if re.Op == op { return true }
+	}
+	for _, s := range(re.Sub) {
+		if hasAnchor(s, ops...) { return true }
+	}
+
+	return false
+}
+
+// Lint returns warnings about likely mistakes: unescaped metacharacters in
+// literal text, quantifiers applying to less than was probably meant, overlapping
+// class constants, alternatives an earlier one shadows, lazy quantifiers
+// the U flag makes greedy, anchors that cannot match where they are, and
+// groups matching the empty string without an optional quantifier
+func (g *Gorex) Lint() []Warning {
+	var ws []Warning
+	warn := func(k LintKind, gi int, ti int, format string, args ...interface{}) {
+		ws = append(ws, Warning{ k, gi + 1, ti, fmt.Sprintf(format, args...) })
+	}
+
+	// alternatives as parsed, and what they match; unparsable groups are
+	// left to Output to report
+	parsed, _ := g.parseGroups()
+	groupFacts := make([]facts, len(g.groups))
+	for gi := range(parsed) {
+		var fs []facts
+		for _, re := range(parsed[gi]) { fs = append(fs, syntaxFacts(re)) }
+		groupFacts[gi] = altFacts(fs)
+	}
+
+	for gi, gr := range(g.groups) {
+		// fixed strings end alternatives, and classes after the last start one
+		last := len(gr.tokens) - 1
+		alternatives := 0
+		for ti, tk := range(gr.tokens) {
+			if tk.class == NoClass || ti == last { alternatives++ }
+		}
+		for ti, tk := range(gr.tokens) {
+			if m := literalMetacharacters(tk.fixed); tk.class == NoClass && m != "" {
+				warn(UnescapedMetacharacter, gi, ti + 1, "%q: %s unescaped, the text is written %q", tk.fixed, m, literalText(tk.fixed))
+			}
+			if tk.class != NoClass {
+				names := splitClass(tk.class)
+				for a := 0; a < len(names); a++ {
+					for b := a + 1; b < len(names); b++ {
+						if rangesOverlap(namesRanges(names[a:a+1]), namesRanges(names[b:b+1])) {
+							warn(OverlappingClasses, gi, ti + 1, "%s and %s share characters", names[a], names[b])
+						}
+					}
+				}
+			}
+			if tk.quantifier.regexp == Single { continue }
+			q := tk.quantifier.String()
+			if gr.flags.U && strings.HasSuffix(string(tk.quantifier.regexp), "?") && tk.quantifier.regexp != ZeroOrOne {
+				warn(LazyUngreedy, gi, ti + 1, "%s prefers more, as the U flag swaps greediness", q)
+			}
+			if ti == last && alternatives > 1 {
+				warn(QuantifiedAlternative, gi, ti + 1, "%s applies to the last alternative only", q)
+			} else if tk.class == NoClass && !quantifiesWhole(tk.fixed, tk.quantifier) {
+				warn(QuantifiedCharacter, gi, ti + 1, "%s applies to the last character of %q only", q, tk.fixed)
+			}
+		}
+		if parsed == nil { continue }
+
+		// alternatives matching only text an earlier one is a prefix of
+		alts := parsed[gi]
+		for b := 1; b < len(alts); b++ {
+			later := syntaxFacts(alts[b])
+			for a := 0; a < b; a++ {
+				earlier := syntaxFacts(alts[a])
+				if earlier.exact && strings.HasPrefix(later.prefix, earlier.text) {
+					warn(ShadowedAlternative, gi, 0, "alternative %d is preferred to %d, matching %q first", a + 1, b + 1, earlier.text)
+					break
+				}
+			}
+		}
+
+		// anchors after or before text that must be matched
+		before, after := 0, 0
+		for i := 0; i < gi; i++ { before = addBounds(before, groupFacts[i].min) }
+		for i := gi + 1; i < len(g.groups); i++ { after = addBounds(after, groupFacts[i].min) }
+		var begins, ends bool
+		for _, re := range(alts) {
+			begins = begins || hasAnchor(re, syntax.OpBeginText, syntax.OpBeginLine)
+			ends = ends || hasAnchor(re, syntax.OpEndText, syntax.OpEndLine)
+		}
+		if gr.anchor == atEnd { ends = true }
+		if !gr.flags.m && begins && before != 0 {
+			warn(MisplacedAnchor, gi, 0, "beginning anchor after groups matching at least %d bytes", before)
+		}
+		if !gr.flags.m && ends && after != 0 {
+			warn(MisplacedAnchor, gi, 0, "end anchor before groups matching at least %d bytes", after)
+		}
+
+		// the empty string, unless an optional quantifier asks for it
+		if groupFacts[gi].min == 0 && gr.anchor == "" {
+			optional := false
+			if len(alts) == 1 {
+				re := alts[0]
+				optional = re.Op == syntax.OpQuest || re.Op == syntax.OpStar || (re.Op == syntax.OpRepeat && re.Min == 0)
+			}
+			if !optional { warn(EmptyMatch, gi, 0, "the group can match the empty string") }
+		}
+	}
+
+	return ws
+}
@@ -0,0 +1,116 @@
+package gorex
+
+import(
+	"testing"
+)
+
+func testLint(t *testing.T, name string, g *Gorex, want ...Warning) {
+	ws := g.Lint()
+	if len(ws) != len(want) { t.Fatalf("Lint() %s = %v; expected %d warnings\n", name, ws, len(want)) }
+	for i, w := range(ws) {
+		if w.Kind != want[i].Kind || w.Group != want[i].Group || w.Token != want[i].Token {
+			t.Fatalf("Lint() %s warning %d = %s; expected %s at group %d, token %d\n", name, i, w, want[i].Kind, want[i].Group, want[i].Token)
+		}
+		if w.Message == "" { t.Fatalf("Lint() %s warning %d without message\n", name, i) }
+	}
+}
+
+func TestLint(t *testing.T) {
+	g := testEmail()
+	testLint(t, "email", g)
+
+	g, _ = GolangExpression()
+	g.AddFixed("gmail.com")
+	g.AddFixed("[a-z]+\\.(?:com|net)")
+	g.AddFixed(".*")
+	g.AddFixed("what?")
+	g.AddFixed("(555) 0100")
+	g.AddFixed("see [1]")
+	g.AddFixed("1+1")
+	g.AddFixed("price $5")
+	g.AddFixed("x*")
+	g.AddFixed("(?:ab)")
+	g.AddFixed("\\(555\\) 0100")
+	testLint(t, "metacharacter", g,
+		Warning{ Kind: UnescapedMetacharacter, Group: 1, Token: 1 },
+		Warning{ Kind: UnescapedMetacharacter, Group: 4, Token: 1 },
+		Warning{ Kind: UnescapedMetacharacter, Group: 5, Token: 1 },
+		Warning{ Kind: UnescapedMetacharacter, Group: 6, Token: 1 },
+		Warning{ Kind: UnescapedMetacharacter, Group: 7, Token: 1 },
+		Warning{ Kind: UnescapedMetacharacter, Group: 8, Token: 1 },
+		Warning{ Kind: MisplacedAnchor, Group: 8 })
+	if m := g.Lint()[1].Message; m != `"what?": ? unescaped, the text is written "what\\?"` { t.Fatalf("Lint() metacharacter message %s\n", m) }
+
+	g, _ = GolangExpression()
+	g.AddFixed("com")
+	g.AddFixedToLast("net")
+	g.ApplyQuantifier(ZeroOrOne)
+	g.AddFixed("ab")
+	g.ApplyQuantifier(OneOrMore)
+	g.AddFixed("(?:ab)")
+	g.ApplyQuantifier(Exactly, 2)
+	g.AddClass(Uppers)
+	g.AddFixedToLast("com")
+	g.ApplyQuantifier(ZeroOrOne)
+	g.AddNumericRange(10, 99)
+	g.ApplyQuantifier(OneOrMore)
+	testLint(t, "quantifiers", g,
+		Warning{ Kind: QuantifiedAlternative, Group: 1, Token: 2 },
+		Warning{ Kind: QuantifiedCharacter, Group: 2, Token: 1 },
+		Warning{ Kind: QuantifiedCharacter, Group: 4, Token: 2 })
+
+	g, _ = GolangExpression()
+	g.AddClass(Uppers)
+	g.AddClassToLast(Alphabetics)
+	g.AddClass(Lowers)
+	g.AddClassToLast(Digits)
+	testLint(t, "classes", g,
+		Warning{ Kind: OverlappingClasses, Group: 1, Token: 1 })
+
+	g, _ = GolangExpression()
+	g.AddFixed("in")
+	g.AddFixedToLast("int[a-z]*")
+	g.AddFixedToLast("for")
+	g.AddFixed("x")
+	g.AddFixedToLast("y")
+	testLint(t, "shadowed", g,
+		Warning{ Kind: ShadowedAlternative, Group: 1 })
+
+	g, _ = GolangExpression()
+	g.AddClass(Digits)
+	g.ApplyQuantifier(OneOrMorePrefFewer)
+	g.SetFlags(UngreedySwap)
+	g.AddClass(Digits)
+	g.ApplyQuantifier(MinToMaxPrefFewer, 1, 3)
+	testLint(t, "lazy", g,
+		Warning{ Kind: LazyUngreedy, Group: 1, Token: 1 })
+
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.AddFixed("b")
+	g.ApplyAnchor(atBeginning)
+	g.AddFixed("$")
+	g.AddFixed("c")
+	g.AddFixed("^d")
+	g.SetFlags(MultiLineMode)
+	testLint(t, "anchors", g,
+		Warning{ Kind: MisplacedAnchor, Group: 2 },
+		Warning{ Kind: MisplacedAnchor, Group: 3 },
+		Warning{ Kind: EmptyMatch, Group: 3 })
+
+	g, _ = GolangExpression()
+	g.AddFixed("x*")
+	g.AddFixed("a")
+	g.AddFixedToLast("")
+	g.AddFixed("b")
+	g.ApplyQuantifier(ZeroOrOne)
+	g.AddClass(Digits)
+	g.ApplyQuantifier(MinToMax, 0, 2)
+	testLint(t, "empty", g,
+		Warning{ Kind: EmptyMatch, Group: 2 })
+
+	w := Warning{ QuantifiedAlternative, 1, 2, "? applies to the last alternative only" }
+	if w.String() != "group 1, token 2: quantifier on the last alternative: ? applies to the last alternative only" {
+		t.Fatalf("Warning.String() = %q\n", w.String())
+	}
+}
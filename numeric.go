@@ -0,0 +1,189 @@
+package gorex
+
+// numeric ranges
+//
+// AddNumericRange adds a group matching the decimal numbers from min to max,
+// as alternatives of digit class tokens, each ended by an empty fixed string:
+//
+//  rex.AddNumericRange(0, 255)                  // ([2][5][0-5]|[2][0-4][0-9]|[1][0-9]{2}|[1-9][0-9]|[0-9])
+//  rex.AddNumericRange(1900, 2099)              // ([2][0][0-9]{2}|[1][9][0-9]{2})
+//  rex.AddNumericRange(1, 12, FixedWidth)       // ([1][0-2]|[0][1-9])
+//
+// numbers are written as strconv.Itoa writes them unless options say
+// otherwise; longer numbers come first so that the longest is matched.
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// numeric range options
+const (
+	LeadingZeros string = "LeadingZeros" // any number of leading zeros
+	FixedWidth string = "FixedWidth" // zero padded to the width of the widest bound
+	PlusSign string = "PlusSign" // numbers not negative may have a + sign
+)
+
+// digits from a to b at one position
+func digitClass(a byte, b byte) rexToken {
+	c := Digits
+	if a == b {
+		c = string(a)
+	} else if a != '0' || b != '9' {
+		c = string(a) + "-" + string(b)
+	}
+
+	return rexToken{ "", c, rexQuan{ } }
+}
+
+// n positions of any digit
+func anyDigits(n int) []rexToken {
+	if n == 0 { return nil }
+	tk := digitClass('0', '9')
+	if n > 1 { tk.quantifier = rexQuan{ Exactly, [2]int{ n, 0 } } }
+
+	return []rexToken{ tk }
+}
+
+// tk followed by each of alts
+func leading(tk rexToken, alts [][]rexToken) [][]rexToken {
+	var out [][]rexToken
+	for _, a := range(alts) { out = append(out, append([]rexToken{ tk }, a...)) }
+
+	return out
+}
+
+// alternatives matching the digit strings from lo to hi, of equal length
+func digitRanges(lo string, hi string) [][]rexToken {
+	if strings.Trim(lo, "0") == "" && strings.Trim(hi, "9") == "" { return [][]rexToken{ anyDigits(len(lo)) } }
+	if len(lo) == 1 { return [][]rexToken{ { digitClass(lo[0], hi[0]) } } }
+	if lo[0] == hi[0] { return leading(digitClass(lo[0], lo[0]), digitRanges(lo[1:], hi[1:])) }
+
+	n := len(lo) - 1
+	first, last := lo[0], hi[0]
+	var low, high [][]rexToken
+	if strings.Trim(lo[1:], "0") != "" {
+		low = leading(digitClass(lo[0], lo[0]), digitRanges(lo[1:], strings.Repeat("9", n)))
+		first++
+	}
+	if strings.Trim(hi[1:], "9") != "" {
+		high = leading(digitClass(hi[0], hi[0]), digitRanges(strings.Repeat("0", n), hi[1:]))
+		last--
+	}
+
+	// highest first
+	out := high
+	if first <= last { out = append(out, append([]rexToken{ digitClass(first, last) }, anyDigits(n)...)) }
+
+	return append(out, low...)
+}
+
+// alternatives matching the numbers from lo to hi, not negative, longest
+// first; numbers are zero padded to width when it is not zero
+func magnitudeRanges(lo int64, hi int64, width int) [][]rexToken {
+	if width != 0 {
+		pad := func(n int64) string {
+			s := strconv.FormatInt(n, 10)
+			return strings.Repeat("0", width - len(s)) + s
+		}
+		return digitRanges(pad(lo), pad(hi))
+	}
+
+	var alts [][]rexToken
+	for digits := len(strconv.FormatInt(hi, 10)); digits >= 1; digits-- {
+		low, high := int64(1), int64(9)
+		for i := 1; i < digits; i++ {
+			low *= 10
+			high = high * 10 + 9
+		}
+		if digits == 1 { low = 0 }
+		if low < lo { low = lo }
+		if high > hi { high = hi }
+		if low > high { continue }
+		alts = append(alts, digitRanges(strconv.FormatInt(low, 10), strconv.FormatInt(high, 10))...)
+	}
+
+	return alts
+}
+
+// AddNumericRange adds a group matching the decimal numbers from min to max;
+// LeadingZeros, FixedWidth and PlusSign change how they may be written
+func (g *Gorex) AddNumericRange(min int, max int, opts ...string) error {
+	zeros, fixed, plus := false, false, false
+	for _, op := range(opts) {
+		switch(op) {
+		case LeadingZeros:
+			zeros = true
+		case FixedWidth:
+			fixed = true
+		case PlusSign:
+			plus = true
+		default:
+			return errors.New("Gorex @120: invalid numeric range option")
+		}
+	}
+	if min > max { return errors.New("Gorex @123: invalid numeric range") }
+	lo, hi := int64(min), int64(max)
+	if lo < -(1 << 62) || hi > 1 << 62 { return errors.New("Gorex @125: numeric range out of bounds") }
+
+	width := 0
+	if fixed {
+		for _, n := range([]int64{ lo, hi }) {
+			if n < 0 { n = -n }
+			if w := len(strconv.FormatInt(n, 10)); w > width { width = w }
+		}
+	}
+	var prefix []rexToken
+	if zeros { prefix = []rexToken{ { "", "0", rexQuan{ ZeroOrMore, [2]int{ } } } } }
+
+	var alts [][]rexToken
+	if hi >= 0 {
+		from := lo
+		if from < 0 { from = 0 }
+		var sign []rexToken
+		if plus { sign = []rexToken{ { "", "+", rexQuan{ ZeroOrOne, [2]int{ } } } } }
+		for _, a := range(magnitudeRanges(from, hi, width)) {
+			alts = append(alts, append(append(append([]rexToken{ }, sign...), prefix...), a...))
+		}
+	}
+	if lo < 0 {
+		from := -hi
+		if from < 1 { from = 1 }
+		minus := rexToken{ "", "-", rexQuan{ } }
+		for _, a := range(magnitudeRanges(from, -lo, width)) {
+			alts = append(alts, append(append([]rexToken{ minus }, prefix...), a...))
+		}
+	}
+
+	// the digits and signs are class tokens of their own, which the class
+	// builders do not add, and each alternative is ended by an empty fixed
+	// string
+	gr := rexGroup{ numeric: append([]string{ strconv.Itoa(min), strconv.Itoa(max) }, opts...) }
+	for _, alt := range(alts) {
+		gr.tokens = append(append(gr.tokens, alt...), rexToken{ "", NoClass, rexQuan{ } })
+	}
+	g.groups = append(g.groups, gr)
+
+	return nil
+}
+
+// the number of leading tokens of the group AddNumericRange made, or 0 when
+// they have changed since
+func (gr rexGroup) numericTokens() int {
+	if len(gr.numeric) < 2 { return 0 }
+	min, e := strconv.Atoi(gr.numeric[0])
+	if e != nil { return 0 }
+	max, e := strconv.Atoi(gr.numeric[1])
+	if e != nil { return 0 }
+	n, _ := GolangExpression()
+	if n.AddNumericRange(min, max, gr.numeric[2:]...) != nil { return 0 }
+
+	made := n.groups[0].tokens
+	if len(made) > len(gr.tokens) { return 0 }
+	for i, tk := range(made) {
+		if tk != gr.tokens[i] { return 0 }
+	}
+
+	return len(made)
+}
@@ -0,0 +1,127 @@
+package gorex
+
+import(
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// whether s is a number from min to max written as the options allow
+func numericValid(s string, min int, max int, zeros bool, fixed bool, plus bool) bool {
+	n, e := strconv.Atoi(s)
+	if e != nil || n < min || n > max { return false }
+
+	digits := strings.TrimLeft(s, "+-")
+	sign := s[:len(s) - len(digits)]
+	if sign == "+" && (!plus || n < 0) { return false }
+	if sign == "-" && n == 0 { return false }
+	if fixed {
+		width := len(strconv.Itoa(abs(max)))
+		if w := len(strconv.Itoa(abs(min))); w > width { width = w }
+		if len(digits) < width { return false }
+		if !zeros && len(digits) != width { return false }
+		return true
+	}
+	if zeros { return true }
+
+	return digits == strconv.Itoa(abs(n))
+}
+
+func abs(n int) int {
+	if n < 0 { return -n }
+
+	return n
+}
+
+// every sign and digit string up to width characters
+func numericCandidates(width int) []string {
+	all := allStrings("0123456789", width)
+	var out []string
+	for _, s := range(all[1:]) { out = append(out, s, "-" + s, "+" + s) }
+
+	return append(out, "", "-", "+", "00", "-0", "+-1", "1 ", "0x1")
+}
+
+func testNumericRange(t *testing.T, min int, max int, width int, opts ...string) {
+	g, _ := GolangExpression()
+	if e := g.AddNumericRange(min, max, opts...); e != nil { t.Fatalf("AddNumericRange(%d, %d, %v) unexpected error: %s\n", min, max, opts, e) }
+	rex, e := g.exampleRegexp()
+	if e != nil { t.Fatalf("AddNumericRange(%d, %d, %v) invalid output: %s\n", min, max, opts, e) }
+
+	var zeros, fixed, plus bool
+	for _, op := range(opts) {
+		zeros = zeros || op == LeadingZeros
+		fixed = fixed || op == FixedWidth
+		plus = plus || op == PlusSign
+	}
+	for _, s := range(numericCandidates(width)) {
+		if w := numericValid(s, min, max, zeros, fixed, plus); rex.MatchString(s) != w {
+			o, _ := g.Output()
+			t.Fatalf("AddNumericRange(%d, %d, %v) %s matching %q is %v\n", min, max, opts, o, s, !w)
+		}
+	}
+}
+
+func TestAddNumericRange(t *testing.T) {
+	g, _ := GolangExpression()
+	g.AddNumericRange(0, 255)
+	o, _ := g.Output()
+	if o != "([2][5][0-5]|[2][0-4][0-9]|[1][0-9]{2}|[1-9][0-9]|[0-9])" { t.Fatalf("AddNumericRange(0, 255) output %s\n", o) }
+
+	// digits are class tokens, explained as such, and the range is defined
+	// by its arguments
+	x, _ := g.Explain()
+	if !strings.Contains(x, "followed by one character of \"[0-5]\", or") { t.Fatalf("AddNumericRange(0, 255) explained %s\n", x) }
+	d, e := g.Definition()
+	if e != nil || d != "GolangExpression\nAddNumericRange 0 255\n" { t.Fatalf("AddNumericRange(0, 255) definition %s, %v\n", d, e) }
+	p, e := ParseDefinition(strings.NewReader(d))
+	if e != nil { t.Fatalf("AddNumericRange(0, 255) definition unexpected error: %s\n", e) }
+	if po, _ := p.Output(); po != o { t.Fatalf("AddNumericRange(0, 255) defined output %s != %s\n", po, o) }
+	j, _ := g.MarshalJSON()
+	p, _ = GolangExpression()
+	if e = p.UnmarshalJSON(j); e != nil { t.Fatalf("AddNumericRange(0, 255) JSON unexpected error: %s\n", e) }
+	if po, _ := p.Output(); po != o { t.Fatalf("AddNumericRange(0, 255) JSON output %s != %s\n", po, o) }
+
+	// a fixed string added after is an alternative of its own, and is
+	// defined after the range
+	g.AddFixedToLast("x")
+	g.NameGroup("n")
+	if o, _ = g.Output(); !strings.HasSuffix(o, "|[0-9]|x)") { t.Fatalf("AddNumericRange(0, 255) then AddFixedToLast output %s\n", o) }
+	if d, _ = g.Definition(); d != "GolangExpression\nAddNumericRange 0 255\nAddFixedToLast \"x\"\nNameGroup \"n\"\n" {
+		t.Fatalf("AddNumericRange(0, 255) then AddFixedToLast definition %s\n", d)
+	}
+	j, _ = g.MarshalJSON()
+	p, _ = GolangExpression()
+	if e = p.UnmarshalJSON(j); e != nil { t.Fatalf("AddNumericRange(0, 255) then AddFixedToLast JSON unexpected error: %s\n", e) }
+	if po, _ := p.Output(); po != o { t.Fatalf("AddNumericRange(0, 255) then AddFixedToLast JSON output %s != %s\n", po, o) }
+
+	// the digit and sign classes are not open to the class builders
+	for _, c := range([]string{ "+", "-", "7", "0-3" }) {
+		if g.AddClass(c) == nil || g.AddClassToLast(c) == nil { t.Fatalf("AddClass(%q) expected error\n", c) }
+	}
+
+	// every value of the range, and the numbers either side
+	g, _ = GolangExpression()
+	g.AddNumericRange(0, 65535)
+	rex, _ := g.exampleRegexp()
+	for n := -1; n <= 70000; n++ {
+		if rex.MatchString(strconv.Itoa(n)) != (n >= 0 && n <= 65535) { t.Fatalf("AddNumericRange(0, 65535) matching %d\n", n) }
+	}
+
+	// every string of signs and digits
+	ranges := [][2]int{ { 0, 255 }, { 1900, 2099 }, { 1, 12 }, { 0, 0 }, { 7, 7 }, { 10, 99 }, { 99, 1000 }, { 123, 4567 },
+		{ -40, 125 }, { -999, -100 }, { -5, -1 }, { -1, 0 }, { -1234, 1234 } }
+	for _, r := range(ranges) {
+		width := len(fmt.Sprint(abs(r[0]))) + 1
+		if w := len(fmt.Sprint(abs(r[1]))) + 1; w > width { width = w }
+		testNumericRange(t, r[0], r[1], width)
+		testNumericRange(t, r[0], r[1], width, LeadingZeros)
+		testNumericRange(t, r[0], r[1], width, FixedWidth)
+		testNumericRange(t, r[0], r[1], width, PlusSign)
+		testNumericRange(t, r[0], r[1], width, FixedWidth, LeadingZeros, PlusSign)
+	}
+
+	if e := g.AddNumericRange(2, 1); e == nil { t.Fatalf("AddNumericRange(2, 1) expected error\n") }
+	if e := g.AddNumericRange(1, 2, "nope"); e == nil { t.Fatalf("AddNumericRange(\"nope\") expected error\n") }
+}
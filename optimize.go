@@ -0,0 +1,248 @@
+package gorex
+
+// optimization
+//
+// Optimize rewrites the expression into a shorter one matching the same
+// strings:
+//
+//  rex.AddClass(Uppers)
+//  rex.AddClassToLast(Alphabetics)      // ([A-ZA-Za-z])
+//  rex.AddFixed("gmail\\.com")
+//  rex.AddFixedToLast("gmail\\.co\\.uk") // (gmail\.com|gmail\.co\.uk)
+//  rex.Optimize()                       // ([A-Za-z])(gmail\.co(?:m|\.uk))
+//
+// groups keep their numbers, and so what they capture where the input is
+// matched one way only, unless RenumberGroups allows removing groups.
+
+import (
+	"errors"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// Optimize options
+const (
+	RenumberGroups string = "RenumberGroups" // empty groups may be removed
+)
+
+// a class written with fewer constants, or fewer ranges
+func optimizeClass(c string) string {
+	names := splitClass(c)
+	if names == nil {
+		// custom classes of unsafe expressions, unless negated
+		if strings.HasPrefix(c, "^") { return c }
+		re, e := syntax.Parse("[" + c + "]", syntax.Perl)
+		if e != nil || re.Op != syntax.OpCharClass { return c }
+		if r := rangesString(normalizeRanges(re.Rune)); len(r) < len(c) { return r }
+		return c
+	}
+
+	ranges := func(names []string) []rune {
+		var r []rune
+		for _, n := range(names) {
+			v, _ := lookupName(classNames, n)
+			re, _ := syntax.Parse("[" + v + "]", syntax.Perl)
+			r = append(r, re.Rune...)
+		}
+		return normalizeRanges(r)
+	}
+	same := func(a []rune, b []rune) bool {
+		return rangesString(a) == rangesString(b)
+	}
+	all := ranges(names)
+
+	// one constant for the lot
+	for _, n := range(classNames) {
+		if same(ranges([]string{ n.name }), all) { return n.value }
+	}
+
+	// drop repeated constants and those the others cover
+	var keep []string
+	seen := map[string]bool{ }
+	for _, n := range(names) {
+		if !seen[n] { keep = append(keep, n) }
+		seen[n] = true
+	}
+	for i := 0; i < len(keep); {
+		rest := append(append([]string{ }, keep[:i]...), keep[i+1:]...)
+		if len(rest) != 0 && same(ranges(rest), all) {
+			keep = rest
+			continue
+		}
+		i++
+	}
+
+	var o string
+	for _, n := range(keep) {
+		v, _ := lookupName(classNames, n)
+		o += v
+	}
+
+	return o
+}
+
+// the expression of parsed syntax, false where it has parts expressions
+// cannot hold: captures, anchors, counted or lazy repetition
+func syntaxNode(re *syntax.Regexp) (*node, bool) {
+	var subs []*node
+	for _, s := range(re.Sub) {
+		n, ok := syntaxNode(s)
+		if !ok { return nil, false }
+		subs = append(subs, n)
+	}
+	if re.Flags & syntax.NonGreedy != 0 {
+		switch(re.Op) {
+		case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+			return nil, false
+		}
+	}
+
+	switch(re.Op) {
+	case syntax.OpEmptyMatch:
+		return emptyNode, true
+	case syntax.OpLiteral:
+		var runes []*node
+		for _, r := range(re.Rune) {
+			class := []rune{ r, r }
+			if re.Flags & syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) { class = append(class, f, f) }
+			}
+			runes = append(runes, classNode(class))
+		}
+		return concatNode(runes...), true
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 { return nil, true }
+		return classNode(re.Rune), true
+	case syntax.OpAnyChar:
+		return classNode([]rune{ 0, unicode.MaxRune }), true
+	case syntax.OpAnyCharNotNL:
+		return classNode([]rune{ 0, '\n' - 1, '\n' + 1, unicode.MaxRune }), true
+	case syntax.OpConcat:
+		return concatNode(subs...), true
+	case syntax.OpAlternate:
+		return altNode(subs...), true
+	case syntax.OpStar:
+		return starNode(subs[0]), true
+	case syntax.OpPlus:
+		return concatNode(subs[0], starNode(subs[0])), true
+	case syntax.OpQuest:
+		return altNode(subs[0], emptyNode), true
+	}
+
+	return nil, false
+}
+
+// the alternatives of a group rewritten as one, if that is shorter
+func (gr rexGroup) factored() (string, bool) {
+	if gr.anchor != "" { return "", false }
+	alts, e := gr.alternatives()
+	if e != nil || len(alts) < 2 { return "", false }
+
+	// case folding and newlines are decided here, the U flag is left to
+	// the group
+	var flags string
+	if gr.flags.i { flags += CaseInsensitive }
+	if gr.flags.s { flags += PeriodMatchesNewline }
+	if flags != "" { flags = "(?" + flags + ")" }
+
+	var nodes []*node
+	for _, a := range(alts) {
+		re, e := syntax.Parse(flags + a, syntax.Perl)
+		if e != nil { return "", false }
+		n, ok := syntaxNode(re)
+		if !ok { return "", false }
+		nodes = append(nodes, n)
+	}
+	n := altNode(nodes...)
+	if n == nil { return "", false }
+
+	o := strings.Join(alts, "|")
+	if len(n.key) >= len(o) { return "", false }
+
+	return n.key, true
+}
+
+// nested repetition of x, as for (x+)* with inner quantifier in and outer out
+var nestedQuantifiers = map[syntax.Op]map[Quantifier]Quantifier {
+	syntax.OpStar: { ZeroOrMore: ZeroOrMore, OneOrMore: ZeroOrMore, ZeroOrOne: ZeroOrMore },
+	syntax.OpPlus: { ZeroOrMore: ZeroOrMore, OneOrMore: OneOrMore, ZeroOrOne: ZeroOrMore },
+	syntax.OpQuest: { ZeroOrMore: ZeroOrMore, OneOrMore: ZeroOrMore, ZeroOrOne: ZeroOrOne },
+}
+
+// collapses a quantified fixed token that repeats a quantified expression
+func (tk *rexToken) collapse() {
+	if tk.class != NoClass { return }
+	re, e := syntax.Parse(tk.fixed, syntax.Perl)
+	if e != nil || re.Flags & syntax.NonGreedy != 0 { return }
+	q, ok := nestedQuantifiers[re.Op][tk.quantifier.regexp]
+	if !ok { return }
+
+	sub := re.Sub[0]
+	s := sub.String()
+	for _, r := range(s) {
+		if r > unicode.MaxASCII { return }
+	}
+	if (sub.Op != syntax.OpLiteral || len(sub.Rune) != 1) && sub.Op != syntax.OpCharClass &&
+			sub.Op != syntax.OpAnyChar && sub.Op != syntax.OpAnyCharNotNL && sub.Op != syntax.OpCapture {
+		s = "(?:" + s + ")"
+	}
+	tk.fixed = s
+	tk.quantifier = rexQuan{ q, [2]int{ } }
+}
+
+// whether a group only matches the empty string, capturing nothing
+func (gr rexGroup) empty() bool {
+	if gr.anchor != "" || gr.name != "" { return false }
+	for _, tk := range(gr.tokens) {
+		if tk.class != NoClass || tk.fixed != "" { return false }
+	}
+
+	return true
+}
+
+// Optimize rewrites the expression to match the same strings more
+// briefly: classes are merged and minimized, common prefixes and suffixes
+// of alternatives factored out and nested quantifiers collapsed. with
+// RenumberGroups, unnamed groups matching only the empty string are removed,
+// and so from the examples
+func (g *Gorex) Optimize(opts ...string) error {
+	renumber := false
+	for _, op := range(opts) {
+		if op != RenumberGroups { return errors.New("Gorex @208: invalid Optimize option") }
+		renumber = true
+	}
+
+	var groups []rexGroup
+	var removed []int
+	for i, gr := range(g.groups) {
+		if renumber && gr.empty() && len(g.groups) > 1 {
+			removed = append(removed, i)
+			continue
+		}
+
+		tokens := append([]rexToken{ }, gr.tokens...)
+		for t := range(tokens) {
+			if tokens[t].class != NoClass { tokens[t].class = optimizeClass(tokens[t].class) }
+			// quantifiers of fixed tokens only follow the last
+			if t == len(tokens) - 1 { tokens[t].collapse() }
+		}
+		gr.tokens = tokens
+		if f, ok := gr.factored(); ok { gr.tokens = []rexToken{ { f, NoClass, rexQuan{ } } } }
+		groups = append(groups, gr)
+	}
+	g.groups = groups
+
+	for i, x := range(g.examples) {
+		if len(x.groups) == 0 { continue }
+		var kept []string
+		for n, w := range(x.groups) {
+			drop := false
+			for _, r := range(removed) { drop = drop || r == n }
+			if !drop { kept = append(kept, w) }
+		}
+		g.examples[i].groups = kept
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package gorex
+
+import(
+	"regexp"
+	"testing"
+)
+
+// optimizes g, checking it still matches the same strings with as many groups
+func testOptimize(t *testing.T, g *Gorex, w string, opts ...string) *Gorex {
+	before, _ := GolangExpression()
+	before.groups = append(before.groups, g.groups...)
+	o, _ := g.Output()
+	if e := g.Optimize(opts...); e != nil { t.Fatalf("Optimize(%s) unexpected error: %s\n", o, e) }
+
+	p, e := g.Output()
+	if e != nil { t.Fatalf("Optimize(%s) invalid result: %s\n", o, e) }
+	if p != w { t.Fatalf("Optimize(%s) %s != %s\n", o, p, w) }
+	if ok, x, e := Equivalent(before, g); !ok || e != nil { t.Fatalf("Optimize(%s) %s differs on %q: %v\n", o, p, x, e) }
+	if len(opts) == 0 && regexp.MustCompile(o).NumSubexp() != regexp.MustCompile(p).NumSubexp() {
+		t.Fatalf("Optimize(%s) %s renumbered groups\n", o, p)
+	}
+
+	return g
+}
+
+func TestOptimize(t *testing.T) {
+	var g *Gorex
+
+	// classes
+	g, _ = GolangExpression()
+	g.AddClass(Uppers)
+	g.AddClassToLast(Alphabetics)
+	g.AddClass(Lowers)
+	g.AddClassToLast(Digits)
+	g.AddClassToLast(Uppers)
+	g.ApplyQuantifier(OneOrMore)
+	g.AddClass(Digits)
+	g.AddClassToLast(Punctuation)
+	g.AddClassToLast(Digits)
+	g = testOptimize(t, g, "([A-Za-z])([0-9A-Za-z]+)([0-9!-/:-@[-`{-~])")
+	if d, e := g.Definition(); e != nil || d == "" { t.Fatalf("Optimize() classes not written as constants: %v\n", e) }
+
+	// unsafe custom classes
+	g, _ = GolangExpression(Unsafe)
+	g.AddClass("a-fb-kx")
+	g = testOptimize(t, g, "([a-kx])")
+
+	// alternatives
+	g, _ = GolangExpression()
+	g.AddClass(Alphabetics)
+	g.AddFixed("gmail\\.com")
+	g.AddFixedToLast("gmail\\.co\\.uk")
+	g.AddFixed("a")
+	g.AddFixedToLast("b")
+	g.AddFixedToLast("c")
+	g.AddFixedToLast("d")
+	g.AddFixed("ing")
+	g.AddFixedToLast("ed")
+	g.AddFixedToLast("ring")
+	g = testOptimize(t, g, "([A-Za-z])(gmail\\.co(?:m|\\.uk))([a-d])(r?ing|ed)")
+
+	// shorter only
+	g, _ = GolangExpression()
+	g.AddFixed("ab")
+	g.AddFixedToLast("cd")
+	g.SetFlags(CaseInsensitive)
+	g = testOptimize(t, g, "(?i)(ab|cd)")
+
+	// nested quantifiers
+	g, _ = GolangExpression()
+	g.AddFixed("(?:a+)")
+	g.ApplyQuantifier(ZeroOrMore)
+	g.AddFixed("(?:bc?)?")
+	g.ApplyQuantifier(ZeroOrOne)
+	g.AddFixed("(?:d+?)")
+	g.ApplyQuantifier(ZeroOrMore)
+	g = testOptimize(t, g, "(a*)((?:bc?)?)((?:d+?)*)")
+
+	// empty groups stay unless renumbering
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.AddFixed("")
+	g.AddFixed("b")
+	g.ShouldMatchGroups("ab", "a", "", "b")
+	g = testOptimize(t, g, "(a)()(b)")
+	g = testOptimize(t, g, "(a)(b)", RenumberGroups)
+	if e := g.Verify(); e != nil { t.Fatalf("Optimize() examples not renumbered: %s\n", e) }
+
+	// named empty groups are kept
+	g, _ = GolangExpression()
+	g.AddFixed("a")
+	g.AddFixed("")
+	g.NameGroup("none")
+	g = testOptimize(t, g, "(a)(?P<none>)", RenumberGroups)
+
+	if e := g.Optimize("nope"); e == nil { t.Fatalf("Optimize(\"nope\") expected error\n") }
+}
@@ -0,0 +1,400 @@
+// Package grok expands Logstash grok patterns into gorex expressions
+//
+//  gk := grok.New()
+//  gk.LoadDir("/etc/logstash/patterns")
+//  m, _ := gk.Matcher("%{IP:client} %{WORD:method} %{URIPATHPARAM:request}")
+//  fields := m.Parse("55.3.244.1 GET /index.html")
+//  fmt.Println(fields["client"], fields["request"])   // 55.3.244.1 /index.html
+//
+// a grok pattern is regular expression text in which %{NAME} stands for
+// the pattern registered as NAME and %{NAME:field} also captures it as
+// field; a type after the field, as in %{NUMBER:bytes:int}, is accepted and
+// ignored. definitions may refer to each other in any order, and are
+// expanded when a pattern is compiled, which reports undefined names and
+// cycles.
+//
+// the Oniguruma syntax of the standard pattern files that golang regexps
+// lack is rewritten: atomic groups and possessive quantifiers become plain
+// ones, and lookaround assertions are dropped, so that such patterns may
+// match more than they do in Logstash.
+package grok
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/dev-west/gorex"
+)
+
+// a registered pattern: an expression, or a definition in grok syntax
+type pattern struct {
+	g *gorex.Gorex
+	def string
+}
+
+// Grok is a registry of named patterns
+type Grok struct {
+	patterns map[string]pattern
+}
+
+var (
+	nameRe = regexp.MustCompile(`^\w+$`)
+	referenceRe = regexp.MustCompile(`^%\{(\w+)(?::([^:}]*))?(?::[^}]*)?\}`)
+	captureRe = regexp.MustCompile(`^\(\?P?<([A-Za-z_]\w*)>`)
+	countRe = regexp.MustCompile(`^\{[0-9]+(?:,[0-9]*)?\}`)
+)
+
+// New returns an empty registry
+func New() *Grok {
+	return &Grok{ patterns: map[string]pattern{ } }
+}
+
+// Add registers an expression as name, replacing any pattern of that name;
+// its named groups are fields, repeated where it is used more than once
+func (gk *Grok) Add(name string, g *gorex.Gorex) error {
+	if !nameRe.MatchString(name) { return errors.New("Gorex @61: invalid grok pattern name") }
+	if _, e := g.Output(); e != nil { return e }
+	gk.patterns[name] = pattern{ g: g }
+
+	return nil
+}
+
+// AddPattern registers a definition in grok syntax as name, replacing any
+// pattern of that name; its references are expanded when it is used
+func (gk *Grok) AddPattern(name string, def string) error {
+	if !nameRe.MatchString(name) { return errors.New("Gorex @71: invalid grok pattern name") }
+	gk.patterns[name] = pattern{ def: def }
+
+	return nil
+}
+
+// Load registers the definitions of a grok pattern file: one NAME and
+// definition per line, separated by spaces, with blank lines and lines
+// starting with # ignored
+func (gk *Grok) Load(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1 << 20)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' { continue }
+		end := strings.IndexAny(line, " \t")
+		if end < 0 { return fmt.Errorf("line %d: %w", n, errors.New("Gorex @87: missing grok definition")) }
+		if e := gk.AddPattern(line[:end], strings.TrimLeft(line[end:], " \t")); e != nil { return fmt.Errorf("line %d: %w", n, e) }
+	}
+
+	return s.Err()
+}
+
+// LoadFile registers the definitions of the grok pattern file at path
+func (gk *Grok) LoadFile(path string) error {
+	f, e := os.Open(path)
+	if e != nil { return e }
+	defer f.Close()
+	if e = gk.Load(f); e != nil { return fmt.Errorf("%s: %w", path, e) }
+
+	return nil
+}
+
+// LoadDir registers the definitions of every file in dir, in the order of
+// their names, as Logstash loads its patterns directory
+func (gk *Grok) LoadDir(dir string) error {
+	entries, e := os.ReadDir(dir)
+	if e != nil { return e }
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, en := range(entries) {
+		if en.IsDir() { continue }
+		if e = gk.LoadFile(filepath.Join(dir, en.Name())); e != nil { return e }
+	}
+
+	return nil
+}
+
+// the expansion of one grok pattern
+type expansion struct {
+	gk *Grok
+	fields map[string]string // group names to fields
+	stack []string // the patterns being expanded
+}
+
+// a part of the expanded pattern; references outside parentheses become
+// groups of their own
+type piece struct {
+	text string
+	reference bool
+	group string
+}
+
+// a group name for field, unique in the expansion
+func (x *expansion) groupName(field string) string {
+	b := []byte(field)
+	for i, c := range(b) {
+		if !(c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) { b[i] = '_' }
+	}
+	name := string(b)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') { name = "_" + name }
+	for n, base := 2, name; ; n++ {
+		if _, used := x.fields[name]; !used { break }
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+	x.fields[name] = field
+
+	return name
+}
+
+// the expansion of the pattern registered as name
+func (x *expansion) reference(name string) (string, error) {
+	for i, n := range(x.stack) {
+		if n == name { return "", errors.New("Gorex @153: grok pattern cycle " + strings.Join(append(x.stack[i:], name), " -> ")) }
+	}
+	p, ok := x.gk.patterns[name]
+	if !ok { return "", errors.New("Gorex @156: undefined grok pattern " + name) }
+	if p.g != nil { return x.expression(p.g) }
+
+	x.stack = append(x.stack, name)
+	pieces, _, e := x.rewrite(p.def, false)
+	x.stack = x.stack[:len(x.stack)-1]
+	if e != nil { return "", e }
+
+	return pieces[0].text, nil
+}
+
+// the output of an expression, its named groups renamed as fields
+func (x *expansion) expression(g *gorex.Gorex) (string, error) {
+	o, e := g.Output()
+	if e != nil { return "", e }
+	re, e := syntax.Parse(o, syntax.Perl)
+	if e != nil { return "", e }
+
+	var rename func(re *syntax.Regexp)
+	rename = func(re *syntax.Regexp) {
+		if re.Op == syntax.OpCapture && re.Name != "" { re.Name = x.groupName(re.Name) }
+		for _, s := range(re.Sub) { rename(s) }
+	}
+	rename(re)
+
+	return re.String(), nil
+}
+
+// the end of the character class starting at i
+func classEnd(s string, i int) int {
+	j := i + 1
+	if j < len(s) && s[j] == '^' { j++ }
+	if j < len(s) && s[j] == ']' { j++ }
+	for j < len(s) {
+		switch {
+		case s[j] == '\\':
+			j += 2
+		case s[j] == '[' && j + 1 < len(s) && s[j+1] == ':':
+			if k := strings.Index(s[j:], ":]"); k >= 0 { j += k + 2 } else { j++ }
+		case s[j] == ']':
+			return j + 1
+		default:
+			j++
+		}
+	}
+
+	return len(s)
+}
+
+// the end of the group starting at i
+func groupEnd(s string, i int) (int, error) {
+	depth := 0
+	for j := i; j < len(s); {
+		switch(s[j]) {
+		case '\\':
+			j += 2
+			continue
+		case '[':
+			j = classEnd(s, j)
+			continue
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 { return j + 1, nil }
+		}
+		j++
+	}
+
+	return 0, errors.New("Gorex @207: missing closing parenthesis")
+}
+
+// escapes the characters outside ascii, which gorex does not take in fixed
+// strings
+func escapeRunes(s string) string {
+	var b strings.Builder
+	for _, r := range(s) {
+		if r < 128 { b.WriteRune(r) } else { fmt.Fprintf(&b, "\\x{%X}", r) }
+	}
+
+	return b.String()
+}
+
+// rewrites a definition into golang syntax, with its references expanded.
+// with top set, references outside parentheses and quantifiers are pieces
+// of their own, and alt reports an alternation outside parentheses;
+// otherwise the result is one piece
+func (x *expansion) rewrite(def string, top bool) (pieces []piece, alt bool, err error) {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(def); {
+		c := def[i]
+		switch {
+		case c == '\\':
+			n := i + 2
+			if n > len(def) { n = len(def) }
+			b.WriteString(def[i:n])
+			i = n
+		case c == '[':
+			n := classEnd(def, i)
+			b.WriteString(def[i:n])
+			i = n
+		case c == '%' && referenceRe.MatchString(def[i:]):
+			m := referenceRe.FindStringSubmatch(def[i:])
+			i += len(m[0])
+			text, e := x.reference(m[1])
+			if e != nil { return nil, false, e }
+			if top && depth == 0 && (i == len(def) || !strings.ContainsRune("*+?{", rune(def[i]))) {
+				pieces = append(pieces, piece{ escapeRunes(b.String()), false, "" })
+				b.Reset()
+				p := piece{ text, true, "" }
+				if m[2] != "" { p.group = x.groupName(m[2]) }
+				pieces = append(pieces, p)
+				continue
+			}
+			if m[2] != "" {
+				b.WriteString("(?P<" + x.groupName(m[2]) + ">" + text + ")")
+			} else {
+				b.WriteString("(?:" + text + ")")
+			}
+		case strings.HasPrefix(def[i:], "(?>"):
+			b.WriteString("(?:")
+			depth++
+			i += 3
+		case strings.HasPrefix(def[i:], "(?=") || strings.HasPrefix(def[i:], "(?!") ||
+				strings.HasPrefix(def[i:], "(?<=") || strings.HasPrefix(def[i:], "(?<!"):
+			n, e := groupEnd(def, i)
+			if e != nil { return nil, false, e }
+			i = n
+		case captureRe.MatchString(def[i:]):
+			m := captureRe.FindStringSubmatch(def[i:])
+			b.WriteString("(?P<" + x.groupName(m[1]) + ">")
+			depth++
+			i += len(m[0])
+		case c == '(' || c == ')':
+			if c == '(' { depth++ } else { depth-- }
+			b.WriteByte(c)
+			i++
+		case c == '|':
+			if depth == 0 { alt = true }
+			b.WriteByte(c)
+			i++
+		case c == '*' || c == '+' || c == '?' || (c == '{' && countRe.MatchString(def[i:])):
+			n := i + 1
+			if c == '{' { n = i + len(countRe.FindString(def[i:])) }
+			b.WriteString(def[i:n])
+			// possessive quantifiers, which golang rejects
+			if n < len(def) && def[n] == '+' { n++ }
+			i = n
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	pieces = append(pieces, piece{ escapeRunes(b.String()), false, "" })
+
+	return pieces, alt, nil
+}
+
+// the expression of a pattern, and the fields of its named groups
+func (gk *Grok) compile(p string) (*gorex.Gorex, map[string]string, error) {
+	if p == "" { return nil, nil, errors.New("Gorex @299: empty grok pattern") }
+	x := &expansion{ gk: gk, fields: map[string]string{ } }
+	pieces, alt, e := x.rewrite(p, true)
+	if e != nil { return nil, nil, e }
+
+	g, _ := gorex.GolangExpression()
+	if alt {
+		// an alternation outside parentheses cannot be split into groups
+		var b strings.Builder
+		for _, pc := range(pieces) {
+			switch {
+			case pc.group != "":
+				b.WriteString("(?P<" + pc.group + ">" + pc.text + ")")
+			case pc.reference:
+				b.WriteString("(?:" + pc.text + ")")
+			default:
+				b.WriteString(pc.text)
+			}
+		}
+		pieces = []piece{ { b.String(), false, "" } }
+	}
+	for _, pc := range(pieces) {
+		if pc.text == "" && !pc.reference { continue }
+		if e = g.AddFixed(pc.text); e != nil { return nil, nil, e }
+		if pc.group == "" { continue }
+		if e = g.NameGroup(pc.group); e != nil { return nil, nil, e }
+	}
+	o, e := g.Output()
+	if e != nil { return nil, nil, e }
+	if _, e = regexp.Compile(o); e != nil { return nil, nil, e }
+
+	return g, x.fields, nil
+}
+
+// Compile expands a grok pattern into an expression: the text between
+// references is a group, and so is each reference outside parentheses,
+// named for its field. the names of other fields are named groups inside
+// them. field names that are not group names are written with underscores,
+// and repeated ones numbered, so that %{INT:a.b} %{INT:a.b} has the groups
+// a_b and a_b_2
+func (gk *Grok) Compile(p string) (*gorex.Gorex, error) {
+	g, _, e := gk.compile(p)
+
+	return g, e
+}
+
+// Matcher matches a compiled grok pattern
+type Matcher struct {
+	rex *regexp.Regexp
+	fields []string // field of each subexpression
+}
+
+// Matcher compiles a grok pattern for matching
+func (gk *Grok) Matcher(p string) (*Matcher, error) {
+	g, fields, e := gk.compile(p)
+	if e != nil { return nil, e }
+	o, _ := g.Output()
+	rex := regexp.MustCompile(o)
+	m := &Matcher{ rex, make([]string, rex.NumSubexp() + 1) }
+	for i, n := range(rex.SubexpNames()) { m.fields[i] = fields[n] }
+
+	return m, nil
+}
+
+// Regexp returns the compiled expression
+func (m *Matcher) Regexp() *regexp.Regexp {
+	return m.rex
+}
+
+// Parse returns the fields of the first match in line, or nil if it does
+// not match. fields whose group takes no part in the match are left out,
+// and a field captured more than once takes its first value
+func (m *Matcher) Parse(line string) map[string]string {
+	at := m.rex.FindStringSubmatchIndex(line)
+	if at == nil { return nil }
+	fields := map[string]string{ }
+	for i, f := range(m.fields) {
+		if f == "" || at[2*i] < 0 { continue }
+		if _, ok := fields[f]; !ok { fields[f] = line[at[2*i]:at[2*i+1]] }
+	}
+
+	return fields
+}
@@ -0,0 +1,111 @@
+package grok
+
+import(
+	"strings"
+	"testing"
+
+	"github.com/dev-west/gorex/patterns/net"
+)
+
+// a registry with the excerpt of the standard patterns
+func standard(t *testing.T) *Grok {
+	gk := New()
+	if e := gk.LoadDir("testdata"); e != nil { t.Fatalf("LoadDir() unexpected error: %s\n", e) }
+
+	return gk
+}
+
+// checks the fields parsed from line
+func testParse(t *testing.T, gk *Grok, p string, line string, want map[string]string) {
+	m, e := gk.Matcher(p)
+	if e != nil { t.Fatalf("Matcher(%q) unexpected error: %s\n", p, e) }
+	got := m.Parse(line)
+	if got == nil { t.Fatalf("Matcher(%q) does not match %q\n", p, line) }
+	for f, w := range(want) {
+		if got[f] != w { t.Fatalf("Matcher(%q) field %s of %q = %q; expected %q\n", p, f, line, got[f], w) }
+	}
+	if len(got) != len(want) { t.Fatalf("Matcher(%q) fields of %q = %q; expected %q\n", p, line, got, want) }
+}
+
+func TestParse(t *testing.T) {
+	gk := standard(t)
+	testParse(t, gk, "%{IP:client} %{WORD:method} %{URIPATHPARAM:request}", "55.3.244.1 GET /index.html?a=1",
+		map[string]string{ "client": "55.3.244.1", "method": "GET", "request": "/index.html?a=1" })
+	testParse(t, gk, "%{COMBINEDAPACHELOG}",
+		`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+		map[string]string{ "clientip": "127.0.0.1", "ident": "-", "auth": "frank", "timestamp": "10/Oct/2000:13:55:36 -0700",
+			"verb": "GET", "request": "/apache_pb.gif", "httpversion": "1.0", "response": "200", "bytes": "2326",
+			"referrer": `"http://www.example.com/start.html"`, "agent": `"Mozilla/4.08"` })
+	testParse(t, gk, "%{SYSLOGBASE} %{GREEDYDATA:message}", "Mar  7 04:02:16 myhost sshd[1234]: Accepted publickey",
+		map[string]string{ "timestamp": "Mar  7 04:02:16", "logsource": "myhost", "program": "sshd", "pid": "1234",
+			"message": "Accepted publickey" })
+	// fields that take no part in the match are left out
+	testParse(t, gk, "%{URIHOST} %{NUMBER:n:int}", "example.com 12", map[string]string{ "n": "12" })
+	testParse(t, gk, "%{TIMESTAMP_ISO8601:ts} %{LOGLEVEL:level}", "2024-02-29T23:59:01Z WARN",
+		map[string]string{ "ts": "2024-02-29T23:59:01Z", "level": "WARN" })
+	testParse(t, gk, "%{INT:n}|%{WORD:w}", "abc", map[string]string{ "w": "abc" })
+	testParse(t, gk, "(?<key>%{WORD})=%{WORD:[value][raw]}", "a=b", map[string]string{ "key": "a", "[value][raw]": "b" })
+
+	m, _ := gk.Matcher("%{INT:n} %{INT:n}")
+	if got := m.Parse("1 2"); got["n"] != "1" { t.Fatalf("Parse() repeated field = %q; expected the first\n", got["n"]) }
+	if got := m.Parse("a b"); got != nil { t.Fatalf("Parse() without match = %q\n", got) }
+	for _, n := range([]string{ "n", "n_2" }) {
+		if m.Regexp().SubexpIndex(n) < 0 { t.Fatalf("Matcher() has no group %s: %s\n", n, m.Regexp()) }
+	}
+	// non-ascii characters of the standard patterns are escaped
+	testParse(t, gk, "%{MONTH:month}", "Mär", map[string]string{ "month": "Mär" })
+}
+
+func TestCompile(t *testing.T) {
+	gk := New()
+	gk.AddPattern("INT", "[0-9]+")
+	gk.AddPattern("PAIR", "%{INT:a},%{INT:b}")
+	g, e := gk.Compile("x %{PAIR:pair}(?:%{INT})?")
+	if e != nil { t.Fatalf("Compile() unexpected error: %s\n", e) }
+	o, _ := g.Output()
+	if want := "(x )(?P<pair>(?P<a>[0-9]+),(?P<b>[0-9]+))((?:(?:[0-9]+))?)"; o != want { t.Fatalf("Compile() = %s; expected %s\n", o, want) }
+
+	// atomic groups, possessive quantifiers and lookarounds
+	gk.AddPattern("ONIG", `(?<![0-9])(?>a++|b*+)[)(]{2}+(?=x)`)
+	g, e = gk.Compile("%{ONIG}")
+	if e != nil { t.Fatalf("Compile() unexpected error: %s\n", e) }
+	if o, _ = g.Output(); o != "((?:a+|b*)[)(]{2})" { t.Fatalf("Compile() of Oniguruma syntax = %s\n", o) }
+
+	// expressions registered with Add
+	ip, _ := net.IPv4()
+	if e = gk.Add("IPV4", ip); e != nil { t.Fatalf("Add() unexpected error: %s\n", e) }
+	testParse(t, gk, "%{IPV4:ip}:%{INT:port}", "10.0.0.1:80",
+		map[string]string{ "ip": "10.0.0.1", "port": "80", "octet1": "10", "octet2": "0", "octet3": "0", "octet4": "1" })
+	// used twice, its named groups are repeated fields
+	testParse(t, gk, "%{IPV4:src} > %{IPV4:dst}", "10.0.0.1 > 192.168.0.2",
+		map[string]string{ "src": "10.0.0.1", "dst": "192.168.0.2", "octet1": "10", "octet2": "0", "octet3": "0", "octet4": "1" })
+	if e = gk.Add("a-b", ip); e == nil { t.Fatalf("Add() of an invalid name expects error\n") }
+}
+
+func TestErrors(t *testing.T) {
+	gk := New()
+	gk.AddPattern("A", "a%{B}")
+	gk.AddPattern("B", "(?:%{C}|b)")
+	gk.AddPattern("C", "%{A}")
+	if _, e := gk.Compile("%{A}"); e == nil || !strings.Contains(e.Error(), "A -> B -> C -> A") { t.Fatalf("Compile() of a cycle error = %v\n", e) }
+	gk.AddPattern("SELF", "%{SELF}")
+	if _, e := gk.Compile("x%{SELF}"); e == nil { t.Fatalf("Compile() of a self reference expects error\n") }
+	if _, e := gk.Compile("%{MISSING:x}"); e == nil || !strings.Contains(e.Error(), "MISSING") { t.Fatalf("Compile() of an undefined pattern error = %v\n", e) }
+	if _, e := gk.Compile(""); e == nil { t.Fatalf("Compile(\"\") expects error\n") }
+	gk.AddPattern("BAD", "(a")
+	if _, e := gk.Compile("%{BAD}"); e == nil { t.Fatalf("Compile() of an invalid expression expects error\n") }
+	if e := gk.AddPattern("", "a"); e == nil { t.Fatalf("AddPattern() of an empty name expects error\n") }
+
+	if e := gk.Load(strings.NewReader("# comment\n\nGOOD a\nNODEF\n")); e == nil || !strings.Contains(e.Error(), "line 4") {
+		t.Fatalf("Load() of a line without definition error = %v\n", e)
+	}
+	if e := gk.LoadFile("testdata/missing"); e == nil { t.Fatalf("LoadFile() of a missing file expects error\n") }
+}
+
+// every pattern of the standard excerpt compiles
+func TestStandard(t *testing.T) {
+	gk := standard(t)
+	for name := range(gk.patterns) {
+		if _, e := gk.Compile("%{" + name + "}"); e != nil { t.Fatalf("Compile(%s) unexpected error: %s\n", name, e) }
+	}
+}
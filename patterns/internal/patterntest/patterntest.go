@@ -0,0 +1,74 @@
+// Package patterntest checks the expressions of the patterns packages on
+// generated strings and on strings written by hand
+package patterntest
+
+import (
+	"errors"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/dev-west/gorex"
+)
+
+// how many strings are generated for each expression
+const Samples = 500
+
+// Case is one expression to check
+type Case struct {
+	Name string
+	Build func() (*gorex.Gorex, error)
+	// Check returns nil when the expression rex is right to match s
+	Check func(rex *regexp.Regexp, s string) error
+	// generated near misses must fail Check
+	NearMisses bool
+	Match []string
+	NoMatch []string
+	// the named groups of the match of Submatch
+	Submatch string
+	Groups map[string]string
+}
+
+// Valid is a Check of the strings valid reports true for
+func Valid(valid func(s string) bool) func(rex *regexp.Regexp, s string) error {
+	return func(rex *regexp.Regexp, s string) error {
+		if !valid(s) { return errors.New("not valid") }
+		return nil
+	}
+}
+
+// Run builds the expression of c, compiled to match whole strings, and
+// checks it
+func Run(t *testing.T, c Case) {
+	g, e := c.Build()
+	if e != nil { t.Fatalf("%s() unexpected error: %s\n", c.Name, e) }
+	o, e := g.Output()
+	if e != nil { t.Fatalf("%s() output failed: %s\n", c.Name, e) }
+	rex := regexp.MustCompile("^(?:" + o + ")$")
+
+	for _, s := range(c.Match) {
+		if !rex.MatchString(s) { t.Fatalf("%s() does not match %q\n", c.Name, s) }
+	}
+	for _, s := range(c.NoMatch) {
+		if rex.MatchString(s) { t.Fatalf("%s() matches %q\n", c.Name, s) }
+	}
+	if c.Submatch != "" {
+		m := rex.FindStringSubmatch(c.Submatch)
+		if m == nil { t.Fatalf("%s() does not match %q\n", c.Name, c.Submatch) }
+		for name, want := range(c.Groups) {
+			if got := m[rex.SubexpIndex(name)]; got != want { t.Fatalf("%s() %s of %q = %q; expected %q\n", c.Name, name, c.Submatch, got, want) }
+		}
+	}
+
+	if c.Check == nil { return }
+	gen, e := g.Generator(rand.NewSource(1))
+	if e != nil { t.Fatalf("%s() generator failed: %s\n", c.Name, e) }
+	for i := 0; i < Samples; i++ {
+		s, e := gen.Generate()
+		if e != nil { t.Fatalf("%s() generate failed: %s\n", c.Name, e) }
+		if e = c.Check(rex, s); e != nil { t.Fatalf("%s() matches %q: %s\n", c.Name, s, e) }
+		if !c.NearMisses { continue }
+		if s, e = gen.GenerateNegative(); e != nil { t.Fatalf("%s() near miss failed: %s\n", c.Name, e) }
+		if c.Check(rex, s) == nil { t.Fatalf("%s() does not match %q, which is valid\n", c.Name, s) }
+	}
+}
@@ -0,0 +1,320 @@
+// Package net provides ready expressions for network addresses and names
+//
+// every function returns a new expression, built with the gorex builder
+// methods, that matches the text the standard library parses:
+//
+//  rex, _ := net.IPv4()        // (?P<octet1>...)(\.)(?P<octet2>...)...
+//  o, _ := rex.Output()
+//  ip := regexp.MustCompile("^" + o + "$")
+//  m := ip.FindStringSubmatch("192.168.0.1")
+//  fmt.Println(m[ip.SubexpIndex("octet1")])     // 192
+//
+// IPv4 and IPv6 match what net.ParseIP accepts, IPv4CIDR and IPv6CIDR what
+// net.ParseCIDR accepts, and MAC what net.ParseMAC accepts; addresses with
+// zones are not matched. the expressions are not anchored, and can be added
+// to with the builder methods.
+package net
+
+import (
+	"fmt"
+	"regexp/syntax"
+
+	"github.com/dev-west/gorex"
+)
+
+// the syntax of an expression without its captures, to be written within
+// a fixed string
+func syntaxOf(g *gorex.Gorex) (string, error) {
+	o, e := g.Output()
+	if e != nil { return "", e }
+	re, e := syntax.Parse(o, syntax.Perl)
+	if e != nil { return "", e }
+
+	var strip func(re *syntax.Regexp) *syntax.Regexp
+	strip = func(re *syntax.Regexp) *syntax.Regexp {
+		for re.Op == syntax.OpCapture { re = re.Sub[0] }
+		for i, s := range(re.Sub) { re.Sub[i] = strip(s) }
+		return re
+	}
+	re = strip(re)
+	if re.Op == syntax.OpAlternate { return "(?:" + re.String() + ")", nil }
+
+	return re.String(), nil
+}
+
+// the syntax of a piece, repeated as the quantifier says
+func repeated(piece string, q gorex.Quantifier, args ...int) (string, error) {
+	g, _ := gorex.GolangExpression()
+	if e := g.AddFixed("(?:" + piece + ")"); e != nil { return "", e }
+	if e := g.ApplyQuantifier(q, args...); e != nil { return "", e }
+
+	return syntaxOf(g)
+}
+
+// the syntax of n to m characters of a class
+func classSyntax(class string, n int, m int) (string, error) {
+	g, _ := gorex.GolangExpression()
+	if e := g.AddClass(class); e != nil { return "", e }
+	if e := g.ApplyQuantifier(gorex.MinToMax, n, m); e != nil { return "", e }
+
+	return syntaxOf(g)
+}
+
+// hexadecimal field of an IPv6 address
+func hextet() (string, error) { return classSyntax(gorex.HexDigits, 1, 4) }
+
+// one label of a host name: letters, digits and hyphens, neither first nor
+// last, up to 63 characters
+func label() (string, error) {
+	// the hyphen is not a class constant
+	inner, _ := gorex.GolangExpression(gorex.Unsafe)
+	if e := inner.AddClass(gorex.AlphaNumerics); e != nil { return "", e }
+	if e := inner.AddClassToLast("-"); e != nil { return "", e }
+	if e := inner.ApplyQuantifier(gorex.MinToMax, 0, 61); e != nil { return "", e }
+	if e := inner.AddClass(gorex.AlphaNumerics); e != nil { return "", e }
+	s, e := syntaxOf(inner)
+	if e != nil { return "", e }
+	if s, e = repeated(s, gorex.ZeroOrOne); e != nil { return "", e }
+
+	g, _ := gorex.GolangExpression()
+	if e = g.AddClass(gorex.AlphaNumerics); e != nil { return "", e }
+	if e = g.AddFixed(s); e != nil { return "", e }
+
+	return syntaxOf(g)
+}
+
+// the syntax of a numeric range, without capture
+func numericSyntax(min int, max int) (string, error) {
+	g, _ := gorex.GolangExpression()
+	if e := g.AddNumericRange(min, max); e != nil { return "", e }
+
+	return syntaxOf(g)
+}
+
+// adds fixed alternatives as a new group
+func addAlternatives(g *gorex.Gorex, alts []string) error {
+	if e := g.AddFixed(alts[0]); e != nil { return e }
+	for _, a := range(alts[1:]) {
+		if e := g.AddFixedToLast(a); e != nil { return e }
+	}
+
+	return nil
+}
+
+// adds a named numeric range as a new group
+func addNumber(g *gorex.Gorex, name string, min int, max int, opts ...string) error {
+	if e := g.AddNumericRange(min, max, opts...); e != nil { return e }
+
+	return g.NameGroup(name)
+}
+
+// adds the groups of a dotted IPv4 address
+func addIPv4(g *gorex.Gorex) error {
+	for i := 1; i <= 4; i++ {
+		if i > 1 {
+			if e := g.AddFixed("\\."); e != nil { return e }
+		}
+		if e := addNumber(g, fmt.Sprintf("octet%d", i), 0, 255); e != nil { return e }
+	}
+
+	return nil
+}
+
+// the alternatives of an IPv6 address: eight fields, or six and an IPv4
+// address, with at most one run of fields compressed to ::
+func ipv6Alternatives() ([]string, error) {
+	h, e := hextet()
+	if e != nil { return nil, e }
+	v4, e := IPv4Syntax()
+	if e != nil { return nil, e }
+
+	// fields followed by colons, or colons followed by fields
+	var fails error
+	before := func(q gorex.Quantifier, args ...int) string {
+		s, e := repeated(h + ":", q, args...)
+		if e != nil { fails = e }
+		return s
+	}
+	after := func(n int, m int) string {
+		s, e := repeated(":" + h, gorex.MinToMax, n, m)
+		if e != nil { fails = e }
+		return s
+	}
+
+	// the embedded forms first, so that the IPv4 address is matched whole
+	alts := []string{
+		before(gorex.Exactly, 6) + v4,
+		"::" + before(gorex.MinToMax, 0, 5) + v4,
+	}
+	for l := 1; l <= 5; l++ {
+		alts = append(alts, before(gorex.Exactly, l) + ":" + before(gorex.MinToMax, 0, 5 - l) + v4)
+	}
+	alts = append(alts, before(gorex.Exactly, 7) + h)
+	for l := 1; l <= 6; l++ {
+		alts = append(alts, before(gorex.Exactly, l) + after(1, 7 - l))
+	}
+	alts = append(alts, before(gorex.MinToMax, 1, 7) + ":", ":" + after(1, 7), "::")
+
+	return alts, fails
+}
+
+// adds an IPv6 address as a new group named address
+func addIPv6(g *gorex.Gorex) error {
+	alts, e := ipv6Alternatives()
+	if e != nil { return e }
+	if e = addAlternatives(g, alts); e != nil { return e }
+
+	return g.NameGroup("address")
+}
+
+// IPv4 matches dotted decimal IPv4 addresses, octets from 0 to 255 without
+// leading zeros, in groups named octet1 to octet4
+func IPv4() (*gorex.Gorex, error) {
+	g, _ := gorex.GolangExpression()
+	if e := addIPv4(g); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// IPv6 matches IPv6 addresses, compressed or not and with or without an
+// embedded IPv4 address, in one group named address
+func IPv6() (*gorex.Gorex, error) {
+	g, _ := gorex.GolangExpression()
+	if e := addIPv6(g); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// IPv4Syntax is the syntax of IPv4 without its groups, to be written
+// within the fixed strings of other expressions
+func IPv4Syntax() (string, error) {
+	octet, e := numericSyntax(0, 255)
+	if e != nil { return "", e }
+	more, e := repeated("\\." + octet, gorex.Exactly, 3)
+	if e != nil { return "", e }
+
+	return octet + more, nil
+}
+
+// IPv6Syntax is the syntax of IPv6 without its group, to be written within
+// the fixed strings of other expressions
+func IPv6Syntax() (string, error) {
+	alts, e := ipv6Alternatives()
+	if e != nil { return "", e }
+	g, _ := gorex.GolangExpression()
+	if e = addAlternatives(g, alts); e != nil { return "", e }
+
+	return syntaxOf(g)
+}
+
+// LabelSyntax is the syntax of one label of Hostname and FQDN, to be
+// written within the fixed strings of other expressions
+func LabelSyntax() (string, error) { return label() }
+
+// IPv4CIDR matches an IPv4 address and prefix length, the length from 0 to
+// 32, leading zeros allowed as by net.ParseCIDR, in a group named prefix
+func IPv4CIDR() (*gorex.Gorex, error) {
+	g, _ := gorex.GolangExpression()
+	if e := addIPv4(g); e != nil { return nil, e }
+	if e := g.AddFixed("/"); e != nil { return nil, e }
+	if e := addNumber(g, "prefix", 0, 32, gorex.LeadingZeros); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// IPv6CIDR matches an IPv6 address and prefix length, the length from 0 to
+// 128, leading zeros allowed, in a group named prefix
+func IPv6CIDR() (*gorex.Gorex, error) {
+	g, _ := gorex.GolangExpression()
+	if e := addIPv6(g); e != nil { return nil, e }
+	if e := g.AddFixed("/"); e != nil { return nil, e }
+	if e := addNumber(g, "prefix", 0, 128, gorex.LeadingZeros); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// MAC matches the hardware addresses net.ParseMAC accepts: 6, 8 or 20 pairs
+// of hexadecimal digits separated by colons or hyphens, or groups of four
+// separated by periods, in one group named mac
+func MAC() (*gorex.Gorex, error) {
+	pair, e := classSyntax(gorex.HexDigits, 2, 2)
+	if e != nil { return nil, e }
+	quad, e := classSyntax(gorex.HexDigits, 4, 4)
+	if e != nil { return nil, e }
+
+	var alts []string
+	for _, n := range([]int{ 20, 8, 6 }) {
+		for _, sep := range([]string{ ":", "-" }) {
+			s, e := repeated(pair + sep, gorex.Exactly, n - 1)
+			if e != nil { return nil, e }
+			alts = append(alts, s + pair)
+		}
+		s, e := repeated(quad + "\\.", gorex.Exactly, n / 2 - 1)
+		if e != nil { return nil, e }
+		alts = append(alts, s + quad)
+	}
+
+	g, _ := gorex.GolangExpression()
+	if e := addAlternatives(g, alts); e != nil { return nil, e }
+	if e := g.NameGroup("mac"); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// Port matches port numbers from 0 to 65535 without leading zeros, in a
+// group named port
+func Port() (*gorex.Gorex, error) {
+	g, _ := gorex.GolangExpression()
+	if e := addNumber(g, "port", 0, 65535); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// Hostname matches RFC 1123 host names: labels of letters, digits and inner
+// hyphens up to 63 characters, separated by periods, in one group named
+// host; the limit of 253 characters on the whole name is not checked
+func Hostname() (*gorex.Gorex, error) {
+	l, e := label()
+	if e != nil { return nil, e }
+	more, e := repeated("\\." + l, gorex.ZeroOrMore)
+	if e != nil { return nil, e }
+
+	g, _ := gorex.GolangExpression()
+	if e := g.AddFixed(l + more); e != nil { return nil, e }
+	if e := g.NameGroup("host"); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// FQDN matches fully qualified domain names: one or more labels and a top
+// level domain of letters, or an internationalized xn-- one, with an
+// optional final period for the root. the labels before the top level
+// domain are in a group named domain, with their periods, and the top level
+// domain in a group named tld
+func FQDN() (*gorex.Gorex, error) {
+	l, e := label()
+	if e != nil { return nil, e }
+	domain, e := repeated(l + "\\.", gorex.OneOrMore)
+	if e != nil { return nil, e }
+	letters, e := classSyntax(gorex.Alphabetics, 2, 63)
+	if e != nil { return nil, e }
+	// xn-- and the punycode of the name, ending in a letter or digit
+	idn, _ := gorex.GolangExpression(gorex.Unsafe)
+	if e = idn.AddFixed("xn--"); e != nil { return nil, e }
+	if e = idn.AddClass(gorex.AlphaNumerics); e != nil { return nil, e }
+	if e = idn.AddClassToLast("-"); e != nil { return nil, e }
+	if e = idn.ApplyQuantifier(gorex.MinToMax, 0, 58); e != nil { return nil, e }
+	if e = idn.AddClass(gorex.AlphaNumerics); e != nil { return nil, e }
+	punycode, e := syntaxOf(idn)
+	if e != nil { return nil, e }
+
+	g, _ := gorex.GolangExpression()
+	if e := g.AddFixed(domain); e != nil { return nil, e }
+	if e := g.NameGroup("domain"); e != nil { return nil, e }
+	if e := addAlternatives(g, []string{ letters, punycode }); e != nil { return nil, e }
+	if e := g.NameGroup("tld"); e != nil { return nil, e }
+	if e := g.AddFixed("\\."); e != nil { return nil, e }
+	if e := g.ApplyQuantifier(gorex.ZeroOrOne); e != nil { return nil, e }
+
+	return g, nil
+}
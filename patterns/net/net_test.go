@@ -0,0 +1,134 @@
+package net
+
+import(
+	gonet "net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dev-west/gorex"
+	"github.com/dev-west/gorex/patterns/internal/patterntest"
+)
+
+// an expression of a syntax helper, in one group
+func syntaxExpression(f func() (string, error)) func() (*gorex.Gorex, error) {
+	return func() (*gorex.Gorex, error) {
+		s, e := f()
+		if e != nil { return nil, e }
+		g, _ := gorex.GolangExpression()
+		return g, g.AddFixed(s)
+	}
+}
+
+// a name of RFC 1123 labels, written out
+func validHostname(s string, fqdn bool) bool {
+	if fqdn { s = strings.TrimSuffix(s, ".") }
+	labels := strings.Split(s, ".")
+	if fqdn && len(labels) < 2 { return false }
+	for i, l := range(labels) {
+		if len(l) == 0 || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' { return false }
+		for _, c := range(l) {
+			if !(c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) { return false }
+		}
+		if fqdn && i == len(labels) - 1 && !strings.HasPrefix(l, "xn--") && strings.Trim(l, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return false
+		}
+		if fqdn && i == len(labels) - 1 && len(l) < 2 { return false }
+	}
+
+	return true
+}
+
+// whether s parses as a CIDR of the IPv6 family or not
+func validCIDR(v6 bool) func(s string) bool {
+	return func(s string) bool {
+		_, _, e := gonet.ParseCIDR(s)
+		return e == nil && strings.Contains(s, ":") == v6
+	}
+}
+
+var cases = []patterntest.Case{
+	{
+		Name: "IPv4", Build: IPv4, NearMisses: true,
+		Check: patterntest.Valid(func(s string) bool {
+			ip := gonet.ParseIP(s)
+			return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+		}),
+		Match: []string{ "0.0.0.0", "255.255.255.255", "192.168.1.10" },
+		NoMatch: []string{ "256.1.1.1", "01.2.3.4", "1.2.3", "1.2.3.4.5", "1..2.3" },
+		Submatch: "192.168.1.10", Groups: map[string]string{ "octet1": "192", "octet2": "168", "octet3": "1", "octet4": "10" },
+	},
+	{
+		Name: "IPv6", Build: IPv6, NearMisses: true,
+		Check: patterntest.Valid(func(s string) bool { return gonet.ParseIP(s) != nil && strings.Contains(s, ":") }),
+		Match: []string{ "::", "::1", "1::", "2001:db8::8a2e:370:7334", "1:2:3:4:5:6:7:8",
+			"1:2:3:4:5:6:7::", "::ffff:192.0.2.1", "64:ff9b::192.0.2.33", "1:2:3:4:5:6:1.2.3.4", "FE80::A" },
+		NoMatch: []string{ ":::", "1:2:3:4:5:6:7:8:9", "1::2::3", "12345::", "::1.2.3",
+			"1:2:3:4:5:6:7:1.2.3.4", "::256.1.1.1", "fe80::1%eth0", "1.2.3.4" },
+		Submatch: "2001:db8::1", Groups: map[string]string{ "address": "2001:db8::1" },
+	},
+	{
+		Name: "IPv4CIDR", Build: IPv4CIDR, NearMisses: true, Check: patterntest.Valid(validCIDR(false)),
+		NoMatch: []string{ "10.0.0.0/33", "10.0.0.0/033", "10.0.0.0" },
+		Submatch: "10.0.0.0/8", Groups: map[string]string{ "prefix": "8" },
+	},
+	{
+		Name: "IPv6CIDR", Build: IPv6CIDR, NearMisses: true, Check: patterntest.Valid(validCIDR(true)),
+		NoMatch: []string{ "::/129", "2001:db8::/0129", "::1.2.3.4/12x" },
+		Submatch: "2001:db8::/32", Groups: map[string]string{ "address": "2001:db8::" },
+	},
+	{
+		Name: "MAC", Build: MAC, NearMisses: true,
+		Check: patterntest.Valid(func(s string) bool {
+			_, e := gonet.ParseMAC(s)
+			return e == nil
+		}),
+		Match: []string{ "00:00:5e:00:53:01", "02-00-5E-10-00-00-00-01", "0000.5e00.5301" },
+		NoMatch: []string{ "00:00:5e-00:53:01", "00:00:5e:00:53", "0:0:5e:0:53:1" },
+	},
+	{
+		Name: "Port", Build: Port, NearMisses: true,
+		Check: patterntest.Valid(func(s string) bool {
+			n, e := strconv.ParseUint(s, 10, 16)
+			return e == nil && strconv.FormatUint(n, 10) == s
+		}),
+		Match: []string{ "0", "80", "65535" },
+		NoMatch: []string{ "65536", "080", "-1" },
+	},
+	{
+		Name: "Hostname", Build: Hostname, NearMisses: true,
+		Check: patterntest.Valid(func(s string) bool { return validHostname(s, false) }),
+		Match: []string{ "localhost", "db-1", "3com.example", strings.Repeat("a", 63) },
+		NoMatch: []string{ "-db", "db-", "a..b", "a_b", strings.Repeat("a", 64), "example.com." },
+	},
+	{
+		Name: "FQDN", Build: FQDN, NearMisses: true,
+		Check: patterntest.Valid(func(s string) bool { return validHostname(s, true) }),
+		Match: []string{ "example.com", "www.example.co.uk.", "example.xn--p1ai" },
+		NoMatch: []string{ "localhost", "example.c", "example.123", "example..com" },
+		Submatch: "www.example.com.", Groups: map[string]string{ "domain": "www.example.", "tld": "com" },
+	},
+	{
+		Name: "IPv6Syntax", Build: syntaxExpression(IPv6Syntax),
+		Match: []string{ "::", "2001:db8::1", "::ffff:192.0.2.1" },
+		NoMatch: []string{ "1::2::3", "1.2.3.4" },
+	},
+	{
+		Name: "LabelSyntax", Build: syntaxExpression(LabelSyntax),
+		Match: []string{ "a", "x-y", "a1" },
+		NoMatch: []string{ "-a", "a-", "a.b", strings.Repeat("a", 64) },
+	},
+}
+
+func TestExpressions(t *testing.T) {
+	for _, c := range(cases) { patterntest.Run(t, c) }
+}
+
+func TestSyntax(t *testing.T) {
+	for name, f := range(map[string]func() (string, error){ "IPv4Syntax": IPv4Syntax, "IPv6Syntax": IPv6Syntax, "LabelSyntax": LabelSyntax }) {
+		s, e := f()
+		if e != nil { t.Fatalf("%s() unexpected error: %s\n", name, e) }
+		if n := regexp.MustCompile(s).NumSubexp(); n != 0 { t.Fatalf("%s() = %q has %d groups; expected none\n", name, s, n) }
+	}
+}
@@ -0,0 +1,378 @@
+// Package timefmt provides expressions for dates and times
+//
+// Layout builds an expression from a golang time layout, each field of the
+// layout a group named after it, with the range of numbers the field can
+// take:
+//
+//  rex, _ := timefmt.Layout("2006-01-02 15:04")
+//  // (?P<year>[0-9]{4})(-)(?P<month>[1][0-2]|[0][1-9])(-)(?P<day>[3][0-1]|...)...
+//  o, _ := rex.Output()
+//  re := regexp.MustCompile(o)
+//  t, e := timefmt.Time(re, re.FindStringSubmatch(line), time.UTC)
+//
+// the groups are named year, month, day, yearday, weekday, hour, minute,
+// second, fraction, ampm, zone and offset; when a field appears twice in a
+// layout, only its first group is named. ISO8601, RFC3339, RFC1123 and
+// Syslog return the usual variants.
+package timefmt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dev-west/gorex"
+)
+
+var monthNames = []string{ "January", "February", "March", "April", "May", "June", "July",
+	"August", "September", "October", "November", "December" }
+var dayNames = []string{ "Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday" }
+
+// offset parts as time.Format writes them
+const (
+	offsetHours = "[-+](?:[01][0-9]|2[0-3])"
+	offsetMinutes = "[0-5][0-9]"
+)
+
+// a field of a layout: the layout text, the group name and how the group is
+// added
+type field struct {
+	std string
+	name string
+	add func(g *gorex.Gorex) error
+}
+
+func numeric(min int, max int, opts ...string) func(g *gorex.Gorex) error {
+	return func(g *gorex.Gorex) error { return g.AddNumericRange(min, max, opts...) }
+}
+
+func digits(n int) func(g *gorex.Gorex) error {
+	return func(g *gorex.Gorex) error {
+		if e := g.AddClass(gorex.Digits); e != nil { return e }
+		return g.ApplyQuantifier(gorex.Exactly, n)
+	}
+}
+
+// a numeric range, then more alternatives, in one group
+func padded(min int, max int, alts ...string) func(g *gorex.Gorex) error {
+	return func(g *gorex.Gorex) error {
+		if e := g.AddNumericRange(min, max); e != nil { return e }
+		for _, a := range(alts) {
+			if e := g.AddFixedToLast(a); e != nil { return e }
+		}
+		return nil
+	}
+}
+
+func alternatives(alts ...string) func(g *gorex.Gorex) error {
+	return func(g *gorex.Gorex) error {
+		if e := g.AddFixed(alts[0]); e != nil { return e }
+		for _, a := range(alts[1:]) {
+			if e := g.AddFixedToLast(a); e != nil { return e }
+		}
+		return nil
+	}
+}
+
+func names(all []string, n int) []string {
+	var s []string
+	for _, a := range(all) {
+		if n != 0 { a = a[:n] }
+		s = append(s, a)
+	}
+
+	return s
+}
+
+// the fields of layouts, longest first where one is the prefix of another
+var fields = []field{
+	{ "January", "month", alternatives(names(monthNames, 0)...) },
+	{ "Jan", "month", alternatives(names(monthNames, 3)...) },
+	{ "Monday", "weekday", alternatives(names(dayNames, 0)...) },
+	{ "Mon", "weekday", alternatives(names(dayNames, 3)...) },
+	{ "MST", "zone", alternatives("[A-Z]{2,4}T", "[A-Z]{3}", offsetHours) },
+	{ "2006", "year", digits(4) },
+	{ "002", "yearday", numeric(1, 366, gorex.FixedWidth) },
+	{ "01", "month", numeric(1, 12, gorex.FixedWidth) },
+	{ "02", "day", numeric(1, 31, gorex.FixedWidth) },
+	{ "03", "hour", numeric(1, 12, gorex.FixedWidth) },
+	{ "04", "minute", numeric(0, 59, gorex.FixedWidth) },
+	{ "05", "second", numeric(0, 59, gorex.FixedWidth) },
+	{ "06", "year", digits(2) },
+	{ "15", "hour", numeric(0, 23, gorex.FixedWidth) },
+	{ "1", "month", numeric(1, 12) },
+	{ "2", "day", numeric(1, 31) },
+	{ "__2", "yearday", padded(100, 366, " [1-9][0-9]", "  [1-9]") },
+	{ "_2", "day", padded(10, 31, " [1-9]") },
+	{ "3", "hour", numeric(1, 12) },
+	{ "4", "minute", numeric(0, 59) },
+	{ "5", "second", numeric(0, 59) },
+	{ "PM", "ampm", alternatives("AM", "PM") },
+	{ "pm", "ampm", alternatives("am", "pm") },
+	{ "-070000", "offset", alternatives(offsetHours + offsetMinutes + offsetMinutes) },
+	{ "-07:00:00", "offset", alternatives(offsetHours + ":" + offsetMinutes + ":" + offsetMinutes) },
+	{ "-0700", "offset", alternatives(offsetHours + offsetMinutes) },
+	{ "-07:00", "offset", alternatives(offsetHours + ":" + offsetMinutes) },
+	{ "-07", "offset", alternatives(offsetHours) },
+	{ "Z070000", "offset", alternatives("Z", offsetHours + offsetMinutes + offsetMinutes) },
+	{ "Z07:00:00", "offset", alternatives("Z", offsetHours + ":" + offsetMinutes + ":" + offsetMinutes) },
+	{ "Z0700", "offset", alternatives("Z", offsetHours + offsetMinutes) },
+	{ "Z07:00", "offset", alternatives("Z", offsetHours + ":" + offsetMinutes) },
+	{ "Z07", "offset", alternatives("Z", offsetHours) },
+}
+
+// a fractional second at the start of layout, as time reads them: a period
+// or comma, then a run of zeros or nines not followed by other digits
+func fraction(layout string) (field, bool) {
+	if len(layout) < 2 || (layout[0] != '.' && layout[0] != ',') || (layout[1] != '0' && layout[1] != '9') { return field{ }, false }
+	n := 1
+	for n < len(layout) && layout[n] == layout[1] { n++ }
+	if n < len(layout) && layout[n] >= '0' && layout[n] <= '9' { return field{ }, false }
+
+	sep := regexp.QuoteMeta(layout[:1])
+	if layout[1] == '0' { return field{ layout[:n], "fraction", alternatives(fmt.Sprintf("%s[0-9]{%d}", sep, n - 1)) }, true }
+	// nines drop trailing zeros, and the whole fraction when it is zero
+	return field{ layout[:n], "fraction", alternatives(fmt.Sprintf("(?:%s[0-9]{0,%d}[1-9])?", sep, n - 2)) }, true
+}
+
+// the field at the start of layout, as time.Format reads layouts
+func nextField(layout string) (field, bool) {
+	if f, ok := fraction(layout); ok { return f, true }
+	// Jan and Mon only when not the start of a longer word
+	for _, f := range(fields) {
+		if !strings.HasPrefix(layout, f.std) { continue }
+		if (f.std == "Jan" || f.std == "Mon") && len(layout) > 3 && unicode.IsLower(rune(layout[3])) { continue }
+		return f, true
+	}
+
+	return field{ }, false
+}
+
+// literal text as AddFixed wants it
+func literal(s string) string {
+	o := strings.Builder{ }
+	for _, r := range(regexp.QuoteMeta(s)) {
+		if r > unicode.MaxASCII {
+			fmt.Fprintf(&o, "\\x{%x}", r)
+			continue
+		}
+		o.WriteRune(r)
+	}
+
+	return o.String()
+}
+
+// Layout builds an expression matching times written in a golang time
+// layout; as time.Parse does, it accepts a fractional second after the
+// seconds even when the layout has none, in a group named fraction
+func Layout(layout string) (*gorex.Gorex, error) {
+	if layout == "" { return nil, errors.New("Gorex @174: empty layout") }
+	g, _ := gorex.GolangExpression()
+	named := map[string]bool{ }
+	text := ""
+	flush := func() error {
+		if text == "" { return nil }
+		e := g.AddFixed(literal(text))
+		text = ""
+		return e
+	}
+	add := func(f field) error {
+		if e := flush(); e != nil { return e }
+		if e := f.add(g); e != nil { return e }
+		if named[f.name] { return nil }
+		named[f.name] = true
+		return g.NameGroup(f.name)
+	}
+
+	for len(layout) > 0 {
+		// _2006 is a literal _ and the year
+		if strings.HasPrefix(layout, "_2006") {
+			text += "_"
+			layout = layout[1:]
+		}
+		f, ok := nextField(layout)
+		if !ok {
+			_, n := utf8.DecodeRuneInString(layout)
+			text += layout[:n]
+			layout = layout[n:]
+			continue
+		}
+		if e := add(f); e != nil { return nil, e }
+		layout = layout[len(f.std):]
+
+		if f.name == "second" {
+			if _, ok := fraction(layout); !ok {
+				if e := add(field{ "", "fraction", alternatives("(?:[.,][0-9]{1,9})?") }); e != nil { return nil, e }
+			}
+		}
+	}
+	if e := flush(); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// ISO8601 matches ISO 8601 extended date and times, 2006-01-02T15:04:05,
+// with an optional fractional second and an optional offset, Z or +07,
+// +0700 or +07:00
+func ISO8601() (*gorex.Gorex, error) {
+	g, e := Layout("2006-01-02T15:04:05")
+	if e != nil { return nil, e }
+	if e = g.AddFixed("(?:Z|" + offsetHours + "(?::?" + offsetMinutes + ")?)?"); e != nil { return nil, e }
+	if e = g.NameGroup("offset"); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// ISO8601Basic matches ISO 8601 basic date and times, 20060102T150405,
+// with an optional fractional second and an optional offset, Z or +07 or
+// +0700
+func ISO8601Basic() (*gorex.Gorex, error) {
+	g, e := Layout("20060102T150405")
+	if e != nil { return nil, e }
+	if e = g.AddFixed("(?:Z|" + offsetHours + "(?:" + offsetMinutes + ")?)?"); e != nil { return nil, e }
+	if e = g.NameGroup("offset"); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// RFC3339 matches time.RFC3339 times, with or without a fractional second
+// as in time.RFC3339Nano
+func RFC3339() (*gorex.Gorex, error) { return Layout(time.RFC3339) }
+
+// RFC1123 matches time.RFC1123 times, with a zone abbreviation
+func RFC1123() (*gorex.Gorex, error) { return Layout(time.RFC1123) }
+
+// RFC1123Z matches time.RFC1123Z times, with a numeric offset
+func RFC1123Z() (*gorex.Gorex, error) { return Layout(time.RFC1123Z) }
+
+// Syslog matches the timestamps of BSD syslog messages (RFC 3164), as
+// time.Stamp writes them, which have no year
+func Syslog() (*gorex.Gorex, error) { return Layout(time.Stamp) }
+
+// Syslog5424 matches the timestamps of RFC 5424 syslog messages: RFC 3339
+// times with at most six digits of fractional second
+func Syslog5424() (*gorex.Gorex, error) { return Layout("2006-01-02T15:04:05.999999Z07:00") }
+
+// the value of a named group in match, empty when absent or unmatched
+func group(rex *regexp.Regexp, match []string, name string) string {
+	i := rex.SubexpIndex(name)
+	if i < 0 || i >= len(match) { return "" }
+
+	return match[i]
+}
+
+// the index of s in names, compared by their first three letters
+func lookup(names []string, s string) int {
+	for i, n := range(names) {
+		if len(s) >= 3 && strings.EqualFold(n[:3], s[:3]) { return i }
+	}
+
+	return -1
+}
+
+// the seconds east of UTC of an offset, as Z, +07, +0700, +07:00 or
+// +07:00:00
+func offsetSeconds(s string) (int, error) {
+	if s == "Z" { return 0, nil }
+	sign := 1
+	if strings.HasPrefix(s, "-") { sign = -1 }
+	d := strings.ReplaceAll(s[1:], ":", "")
+	if len(d) != 2 && len(d) != 4 && len(d) != 6 { return 0, errors.New("Gorex @285: invalid offset") }
+	secs := 0
+	for i, unit := range([]int{ 3600, 60, 1 }) {
+		if 2 * i >= len(d) { break }
+		n, e := strconv.Atoi(d[2*i:2*i+2])
+		if e != nil { return 0, errors.New("Gorex @290: invalid offset") }
+		secs += n * unit
+	}
+
+	return sign * secs, nil
+}
+
+// Time makes the time matched by an expression of this package from the
+// submatches of rex, as FindStringSubmatch returns them. fields that were
+// not matched take the values time.Parse gives them; times without an
+// offset are in loc, and a zone abbreviation loc does not use gives a zone
+// of that name at UTC, as time.Parse does; numeric zones such as -07, which
+// time.Parse also puts at UTC, keep their offset. the weekday is not checked
+func Time(rex *regexp.Regexp, match []string, loc *time.Location) (time.Time, error) {
+	if match == nil { return time.Time{ }, errors.New("Gorex @304: no match") }
+	num := func(name string, none int) (int, error) {
+		s := strings.TrimSpace(group(rex, match, name))
+		if s == "" { return none, nil }
+		return strconv.Atoi(s)
+	}
+
+	year, e := num("year", 0)
+	if e != nil { return time.Time{ }, e }
+	if s := group(rex, match, "year"); len(s) == 2 {
+		// as time.Parse reads two digit years
+		year += 1900
+		if year < 1969 { year += 100 }
+	}
+	month, e := num("month", 1)
+	if e != nil {
+		if month = lookup(monthNames, group(rex, match, "month")) + 1; month == 0 { return time.Time{ }, errors.New("Gorex @320: invalid month") }
+	}
+	day, e := num("day", 1)
+	if e != nil { return time.Time{ }, e }
+	yday, e := num("yearday", 0)
+	if e != nil { return time.Time{ }, e }
+	hour, e := num("hour", 0)
+	if e != nil { return time.Time{ }, e }
+	minute, e := num("minute", 0)
+	if e != nil { return time.Time{ }, e }
+	second, e := num("second", 0)
+	if e != nil { return time.Time{ }, e }
+
+	switch(strings.ToUpper(group(rex, match, "ampm"))) {
+	case "AM":
+		if hour == 12 { hour = 0 }
+	case "PM":
+		if hour < 12 { hour += 12 }
+	}
+	nsec := 0
+	if f := group(rex, match, "fraction"); len(f) > 1 {
+		digits := (f[1:] + "000000000")[:9]
+		if nsec, e = strconv.Atoi(digits); e != nil { return time.Time{ }, e }
+	}
+
+	if off := group(rex, match, "offset"); off != "" {
+		secs, e := offsetSeconds(off)
+		if e != nil { return time.Time{ }, e }
+		loc = time.FixedZone("", secs)
+		if secs == 0 && off == "Z" { loc = time.UTC }
+	}
+
+	if yday != 0 {
+		// the date of the day of the year, which month and day must agree with
+		t := time.Date(year, time.January, yday, hour, minute, second, nsec, loc)
+		if t.Year() != year { return time.Time{ }, errors.New("Gorex @355: day of year out of range") }
+		if group(rex, match, "month") != "" && int(t.Month()) != month { return time.Time{ }, errors.New("Gorex @356: day of year does not match month") }
+		if group(rex, match, "day") != "" && t.Day() != day { return time.Time{ }, errors.New("Gorex @357: day of year does not match day") }
+		return withZone(t, group(rex, match, "zone"), loc), nil
+	}
+	t := time.Date(year, time.Month(month), day, hour, minute, second, nsec, loc)
+	if t.Day() != day { return time.Time{ }, errors.New("Gorex @361: day out of range") }
+
+	return withZone(t, group(rex, match, "zone"), loc), nil
+}
+
+// t in the zone of an abbreviation, or a numeric zone
+func withZone(t time.Time, zone string, loc *time.Location) time.Time {
+	if zone == "" { return t }
+	if zone == "UTC" || zone == "GMT" { return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC) }
+	if zone[0] == '+' || zone[0] == '-' {
+		secs, e := offsetSeconds(zone)
+		if e != nil { return t }
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone("", secs))
+	}
+	if name, _ := t.In(loc).Zone(); name == zone { return t }
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(zone, 0))
+}
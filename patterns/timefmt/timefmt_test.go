@@ -0,0 +1,118 @@
+package timefmt
+
+import(
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-west/gorex"
+)
+
+const samples = 300
+
+// the expression compiled to match whole strings
+func compile(t *testing.T, name string, g *gorex.Gorex, e error) *regexp.Regexp {
+	if e != nil { t.Fatalf("%s unexpected error: %s\n", name, e) }
+	o, e := g.Output()
+	if e != nil { t.Fatalf("%s output failed: %s\n", name, e) }
+
+	return regexp.MustCompile("^(?:" + o + ")$")
+}
+
+// checks Time agrees with time.Parse on generated samples, and that the
+// near misses are not times time.Format writes
+func testLayout(t *testing.T, layout string, g *gorex.Gorex, e error) {
+	rex := compile(t, "Layout(" + layout + ")", g, e)
+	gen, e := g.Generator(rand.NewSource(1))
+	if e != nil { t.Fatalf("Layout(%q) generator failed: %s\n", layout, e) }
+	for i := 0; i < samples; i++ {
+		s, e := gen.Generate()
+		if e != nil { t.Fatalf("Layout(%q) generate failed: %s\n", layout, e) }
+		want, ep := time.ParseInLocation(layout, s, time.UTC)
+		got, eg := Time(rex, rex.FindStringSubmatch(s), time.UTC)
+		if (ep == nil) != (eg == nil) { t.Fatalf("Time(%q) error %v; time.Parse %q error %v\n", s, eg, layout, ep) }
+		_, wo := want.Zone()
+		_, go_ := got.Zone()
+		if z := group(rex, rex.FindStringSubmatch(s), "zone"); z != "" && (z[0] == '+' || z[0] == '-') {
+			// time.Parse reads numeric zones as names, at UTC
+			want = time.Date(want.Year(), want.Month(), want.Day(), want.Hour(), want.Minute(), want.Second(), want.Nanosecond(), got.Location())
+			wo = go_
+		}
+		if ep == nil && (!got.Equal(want) || wo != go_) { t.Fatalf("Time(%q) = %s; time.Parse %q = %s\n", s, got, layout, want) }
+
+		if s, e = gen.GenerateNegative(); e != nil { t.Fatalf("Layout(%q) near miss failed: %s\n", layout, e) }
+		// time.Format writes back numeric zones as they were read, such as +000
+		p, e := time.ParseInLocation(layout, s, time.UTC)
+		if z, _ := p.Zone(); e == nil && p.Format(layout) == s && (z == "" || (z[0] != '+' && z[0] != '-')) {
+			t.Fatalf("Layout(%q) does not match %q, which time.Format writes\n", layout, s)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	for _, layout := range([]string{ time.ANSIC, time.UnixDate, time.RubyDate, time.RFC822, time.RFC822Z,
+			time.RFC850, time.RFC1123, time.RFC1123Z, time.RFC3339, time.RFC3339Nano, time.Kitchen, time.Stamp,
+			time.StampMilli, time.StampMicro, time.DateTime, "2006-002 3:4:5.000 pm", "06 Jan __2 15 Z0700",
+			"January 2, 2006 -07:00:00 Monday", "_2006 Z07:00:00", "2006 -070000",
+			"Jan 2006 -07", "2006-01-02 Z07", "15:04:05,99 2006" }) {
+		g, e := Layout(layout)
+		testLayout(t, layout, g, e)
+	}
+
+	g, e := Layout("2006-01-02 15:04")
+	rex := compile(t, "Layout", g, e)
+	// as the package documentation shows
+	if o, _ := g.Output(); !strings.HasPrefix(o, "(?P<year>[0-9]{4})(-)(?P<month>[1][0-2]|[0][1-9])(-)(?P<day>[3][0-1]|") {
+		t.Fatalf("Layout() output %s\n", o)
+	}
+	for _, s := range([]string{ "2024-13-01 10:00", "2024-00-01 10:00", "2024-01-32 10:00", "2024-1-01 10:00", "2024-01-01 24:00" }) {
+		if rex.MatchString(s) { t.Fatalf("Layout() matches %q\n", s) }
+	}
+	m := rex.FindStringSubmatch("2024-02-29 23:59")
+	for name, want := range(map[string]string{ "year": "2024", "month": "02", "day": "29", "hour": "23", "minute": "59" }) {
+		if got := m[rex.SubexpIndex(name)]; got != want { t.Fatalf("Layout() %s = %q; expected %q\n", name, got, want) }
+	}
+
+	if _, e = Layout(""); e == nil { t.Fatalf("Layout(\"\") expects error\n") }
+}
+
+func TestVariants(t *testing.T) {
+	g, e := ISO8601()
+	rex := compile(t, "ISO8601()", g, e)
+	for s, want := range(map[string]string{
+		"2024-02-29T23:59:01": "2024-02-29T23:59:01Z",
+		"2024-02-29T23:59:01.5Z": "2024-02-29T23:59:01.5Z",
+		"2024-02-29T23:59:01,25+01": "2024-02-29T23:59:01.25+01:00",
+		"2024-02-29T23:59:01-0530": "2024-02-29T23:59:01-05:30",
+		"2024-02-29T23:59:01+05:30": "2024-02-29T23:59:01+05:30",
+	}) {
+		got, e := Time(rex, rex.FindStringSubmatch(s), time.UTC)
+		if e != nil || got.Format(time.RFC3339Nano) != want { t.Fatalf("ISO8601() time of %q = %s (%v); expected %s\n", s, got.Format(time.RFC3339Nano), e, want) }
+	}
+	if rex.MatchString("2024-02-29 23:59:01") { t.Fatalf("ISO8601() matches a space for T\n") }
+
+	g, e = ISO8601Basic()
+	rex = compile(t, "ISO8601Basic()", g, e)
+	got, e := Time(rex, rex.FindStringSubmatch("20240229T235901Z"), time.Local)
+	if e != nil || !got.Equal(time.Date(2024, 2, 29, 23, 59, 1, 0, time.UTC)) { t.Fatalf("ISO8601Basic() time = %s (%v)\n", got, e) }
+
+	g, e = Syslog5424()
+	testLayout(t, "2006-01-02T15:04:05.999999Z07:00", g, e)
+	g, e = RFC3339()
+	rex = compile(t, "RFC3339()", g, e)
+	if !rex.MatchString("2024-02-29T23:59:01.123456789Z") { t.Fatalf("RFC3339() does not match a fractional second\n") }
+
+	g, e = Syslog()
+	rex = compile(t, "Syslog()", g, e)
+	got, e = Time(rex, rex.FindStringSubmatch("Feb  9 07:05:01"), time.UTC)
+	if e != nil || got != time.Date(0, 2, 9, 7, 5, 1, 0, time.UTC) { t.Fatalf("Syslog() time = %s (%v)\n", got, e) }
+
+	g, e = RFC1123()
+	rex = compile(t, "RFC1123()", g, e)
+	if _, e = Time(rex, rex.FindStringSubmatch("Fri, 31 Feb 2024 10:00:00 GMT"), time.UTC); e == nil { t.Fatalf("Time() of 31 February expects error\n") }
+	if _, e = Time(rex, nil, time.UTC); e == nil { t.Fatalf("Time() without match expects error\n") }
+	g, e = RFC1123Z()
+	compile(t, "RFC1123Z()", g, e)
+}
@@ -0,0 +1,153 @@
+// Package web provides ready expressions for e-mail addresses and URLs
+//
+//  rex, _ := web.URL()
+//  o, _ := rex.Output()
+//  u := regexp.MustCompile("^" + o + "$")
+//  m := u.FindStringSubmatch("https://joe@example.com:8443/a/b?x=1#top")
+//  fmt.Println(m[u.SubexpIndex("host")], m[u.SubexpIndex("port")])   // example.com 8443
+//
+// Email is the pragmatic address of HTML5 forms, StrictEmail an RFC 5322
+// address as net/mail.ParseAddress reads it without a display name, and
+// URL and URI follow RFC 3986 as net/url.Parse reads it. the expressions
+// are not anchored. parts that may be missing are groups of their own, with
+// the named group of the part inside them.
+package web
+
+import (
+	"github.com/dev-west/gorex"
+	"github.com/dev-west/gorex/patterns/net"
+)
+
+// RFC 3986 character sets
+const (
+	unreserved = "-._~0-9A-Za-z"
+	subDelims = "!$&'()*+,;="
+	pctEncoded = "%[0-9A-Fa-f]{2}"
+	pchar = "(?:[" + unreserved + subDelims + ":@]|" + pctEncoded + ")"
+	userinfo = "(?:[" + unreserved + subDelims + ":]|" + pctEncoded + ")*"
+	// net/url reads percent encoding in host names for bytes outside ascii
+	// only, and none is kept here
+	regName = "[" + unreserved + subDelims + "]*"
+	segment = pchar + "*"
+	segmentNZ = pchar + "+"
+	queryChars = "(?:" + pchar + "|[/?])*"
+)
+
+// RFC 5322 character sets, ascii only
+const (
+	atext = "[-0-9A-Za-z!#$%&'*+/=?^_`{|}~]"
+	dotAtom = atext + "+(?:\\." + atext + "+)*"
+	quotedString = "\"(?:[ !#-\\[\\]-~]|\\\\[ -~])+\""
+)
+
+// adds a named group of one fixed string
+func addNamed(g *gorex.Gorex, name string, fixed string) error {
+	if e := g.AddFixed(fixed); e != nil { return e }
+
+	return g.NameGroup(name)
+}
+
+// adds a part that may be missing: a group of the text before, a named
+// group of one fixed string and the text after, or nothing
+func addOptional(g *gorex.Gorex, before string, name string, fixed string, after string) error {
+	part, _ := gorex.GolangExpression()
+	if e := addNamed(part, name, fixed); e != nil { return e }
+	o, e := part.Output()
+	if e != nil { return e }
+
+	return g.AddFixed("(?:" + before + o + after + ")?")
+}
+
+// Email matches the addresses of HTML5 e-mail inputs: a local part of
+// letters, digits and the punctuation RFC 5322 allows unquoted, periods
+// anywhere, and a domain of host name labels, in groups named local and
+// domain. it matches addresses net/mail rejects, such as a..b@example.com
+func Email() (*gorex.Gorex, error) {
+	label, e := net.LabelSyntax()
+	if e != nil { return nil, e }
+
+	g, _ := gorex.GolangExpression()
+	if e = addNamed(g, "local", "[-0-9A-Za-z.!#$%&'*+/=?^_`{|}~]+"); e != nil { return nil, e }
+	if e = g.AddFixed("@"); e != nil { return nil, e }
+	if e = addNamed(g, "domain", label + "(?:\\." + label + ")*"); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// StrictEmail matches RFC 5322 addresses: a local part that is a dot-atom
+// or a quoted string, and a domain with a top level domain of letters, or
+// an IPv4 or IPv6 address in brackets, in groups named local and domain.
+// net/mail.ParseAddress accepts every address it matches
+func StrictEmail() (*gorex.Gorex, error) {
+	label, e := net.LabelSyntax()
+	if e != nil { return nil, e }
+	v4, e := net.IPv4Syntax()
+	if e != nil { return nil, e }
+	v6, e := net.IPv6Syntax()
+	if e != nil { return nil, e }
+
+	g, _ := gorex.GolangExpression()
+	if e = g.AddFixed(dotAtom); e != nil { return nil, e }
+	if e = g.AddFixedToLast(quotedString); e != nil { return nil, e }
+	if e = g.NameGroup("local"); e != nil { return nil, e }
+	if e = g.AddFixed("@"); e != nil { return nil, e }
+	if e = g.AddFixed("(?:" + label + "\\.)+[A-Za-z]{2,63}"); e != nil { return nil, e }
+	if e = g.AddFixedToLast("\\[" + v4 + "\\]"); e != nil { return nil, e }
+	if e = g.AddFixedToLast("\\[IPv6:" + v6 + "\\]"); e != nil { return nil, e }
+	if e = g.NameGroup("domain"); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// adds the query and fragment, each optional
+func addQueryFragment(g *gorex.Gorex) error {
+	if e := addOptional(g, "\\?", "query", queryChars, ""); e != nil { return e }
+
+	return addOptional(g, "#", "fragment", queryChars, "")
+}
+
+// URL matches RFC 3986 URIs with an authority, such as http and ftp URLs,
+// in groups named scheme, userinfo, host, port, path, query and fragment.
+// the host is a name, an IPv4 address or an IPv6 address in brackets, and
+// may be empty, as in file:///etc
+func URL() (*gorex.Gorex, error) {
+	v6, e := net.IPv6Syntax()
+	if e != nil { return nil, e }
+
+	g, _ := gorex.GolangExpression()
+	if e = addNamed(g, "scheme", "[A-Za-z][-+.0-9A-Za-z]*"); e != nil { return nil, e }
+	if e = g.AddFixed("://"); e != nil { return nil, e }
+	if e = addOptional(g, "", "userinfo", userinfo, "@"); e != nil { return nil, e }
+	if e = g.AddFixed(regName); e != nil { return nil, e }
+	if e = g.AddFixedToLast("\\[" + v6 + "\\]"); e != nil { return nil, e }
+	if e = g.NameGroup("host"); e != nil { return nil, e }
+	if e = addOptional(g, ":", "port", "[0-9]*", ""); e != nil { return nil, e }
+	if e = addNamed(g, "path", "(?:/" + segment + ")*"); e != nil { return nil, e }
+	if e = addQueryFragment(g); e != nil { return nil, e }
+
+	return g, nil
+}
+
+// URI matches RFC 3986 URIs, with or without an authority, such as
+// mailto:joe@example.com or urn:isbn:0451450523, in groups named scheme,
+// hier for the hierarchical part, query and fragment; URL splits the
+// authority of the URIs that have one
+func URI() (*gorex.Gorex, error) {
+	v6, e := net.IPv6Syntax()
+	if e != nil { return nil, e }
+	authority := "(?:" + userinfo + "@)?(?:" + regName + "|\\[" + v6 + "\\])(?::[0-9]*)?"
+
+	g, _ := gorex.GolangExpression()
+	if e = addNamed(g, "scheme", "[A-Za-z][-+.0-9A-Za-z]*"); e != nil { return nil, e }
+	if e = g.AddFixed(":"); e != nil { return nil, e }
+	// an authority and an empty or absolute path, an absolute path, a
+	// relative one, or nothing
+	if e = g.AddFixed("//" + authority + "(?:/" + segment + ")*"); e != nil { return nil, e }
+	if e = g.AddFixedToLast("/(?:" + segmentNZ + "(?:/" + segment + ")*)?"); e != nil { return nil, e }
+	if e = g.AddFixedToLast(segmentNZ + "(?:/" + segment + ")*"); e != nil { return nil, e }
+	if e = g.AddFixedToLast(""); e != nil { return nil, e }
+	if e = g.NameGroup("hier"); e != nil { return nil, e }
+	if e = addQueryFragment(g); e != nil { return nil, e }
+
+	return g, nil
+}
@@ -0,0 +1,440 @@
+package gorex
+
+// registry
+//
+// a Registry holds expressions by name, in numbered versions. an expression
+// refers to another as a sub-expression through the fixed string Reference
+// returns, expanded by Resolve:
+//
+//  reg := NewRegistry()
+//  reg.Add("octet", octet)
+//  ip, _ := GolangExpression()
+//  ip.AddFixed(Reference("octet", 0) + `(?:\.` + Reference("octet", 0) + "){3}")
+//  reg.Add("ipv4", ip)
+//  c, _ := reg.Add("octet", narrower)   // version 2
+//  if !c.Compatible() { fmt.Println(c.Rejected, c.Dependents) }
+//  rex, _ := reg.Resolve("ipv4", 0)
+//
+// references without a version follow the latest version of the pattern.
+// adding a version compares it with the previous one, listing the shortest
+// strings the previous version matched that the new one does not.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// how many rejected strings a Compatibility lists
+const compatibilityExamples = 5
+
+var (
+	patternNameRe = regexp.MustCompile(`^[A-Za-z_][-.\w]*$`)
+	referenceRe = regexp.MustCompile(`%\{([A-Za-z_][-.\w]*)(?:@([0-9]+))?\}`)
+)
+
+// a reference to a registered pattern; version 0 is the latest
+type patternRef struct {
+	name string
+	version int
+}
+
+func (p patternRef) String() string {
+	if p.version == 0 { return p.name }
+
+	return p.name + "@" + strconv.Itoa(p.version)
+}
+
+// a registered version of a pattern
+type registered struct {
+	version int
+	g *Gorex
+	refs []patternRef
+}
+
+// Registry holds named, versioned expressions; it is safe for concurrent use
+type Registry struct {
+	mu sync.RWMutex
+	patterns map[string][]*registered // by name, in version order
+	order []patternRef // every version, in the order added
+}
+
+// Compatibility compares a new version of a pattern with the previous one:
+// Rejected lists the shortest strings the previous version matches and the
+// new one does not, and Dependents the patterns that change with it, as
+// they refer to its latest version directly or through other patterns
+type Compatibility struct {
+	Name string
+	Previous int
+	Version int
+	Rejected []string
+	Dependents []string
+}
+
+// Compatible reports whether the new version matches every string the
+// previous one matched
+func (c *Compatibility) Compatible() bool {
+	return len(c.Rejected) == 0
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{ patterns: map[string][]*registered{ } }
+}
+
+// Reference returns the fixed string standing for a registered pattern in
+// an expression added to a registry: %{name}, or %{name@version} for a
+// given version, with version 0 for the latest
+func Reference(name string, version int) string {
+	return "%{" + patternRef{ name, version }.String() + "}"
+}
+
+// a copy of g
+func (g *Gorex) clone() *Gorex {
+	c := &Gorex{ unsafe: g.unsafe, examples: append([]rexExample{ }, g.examples...) }
+	for _, gr := range(g.groups) {
+		gr.tokens = append([]rexToken{ }, gr.tokens...)
+		c.groups = append(c.groups, gr)
+	}
+
+	return c
+}
+
+// the references in the fixed strings of g
+func references(g *Gorex) ([]patternRef, error) {
+	var refs []patternRef
+	for _, gr := range(g.groups) {
+		for _, tk := range(gr.tokens) {
+			for _, m := range(referenceRe.FindAllStringSubmatch(tk.fixed, -1)) {
+				p := patternRef{ m[1], 0 }
+				if m[2] != "" {
+					v, e := strconv.Atoi(m[2])
+					if e != nil || v == 0 { return nil, errors.New("Gorex @119: invalid pattern version " + m[2]) }
+					p.version = v
+				}
+				refs = append(refs, p)
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+// the registered version p refers to; extra is a version being added
+func (r *Registry) lookup(p patternRef, extra *registered, name string) (*registered, error) {
+	if extra != nil && p.name == name && (p.version == 0 || p.version == extra.version) { return extra, nil }
+	vs := r.patterns[p.name]
+	if len(vs) == 0 { return nil, errors.New("Gorex @134: unknown pattern " + p.name) }
+	if p.version == 0 { return vs[len(vs)-1], nil }
+	for _, x := range(vs) {
+		if x.version == p.version { return x, nil }
+	}
+
+	return nil, errors.New("Gorex @140: unknown pattern version " + p.String())
+}
+
+// the expression of x with its references expanded; path lists the
+// versions being expanded, to report cycles
+func (r *Registry) resolve(x *registered, extra *registered, name string, path []string) (*Gorex, error) {
+	c := x.g.clone()
+	for gi := range(c.groups) {
+		for ti := range(c.groups[gi].tokens) {
+			tk := &c.groups[gi].tokens[ti]
+			var err error
+			tk.fixed = referenceRe.ReplaceAllStringFunc(tk.fixed, func(s string) string {
+				if err != nil { return "" }
+				m := referenceRe.FindStringSubmatch(s)
+				p := patternRef{ m[1], 0 }
+				p.version, _ = strconv.Atoi(m[2])
+				y, e := r.lookup(p, extra, name)
+				if e != nil {
+					err = e
+					return ""
+				}
+				at := p.name + "@" + strconv.Itoa(y.version)
+				for i, q := range(path) {
+					if q == at {
+						err = errors.New("Gorex @164: pattern cycle " + strings.Join(append(append([]string{ }, path[i:]...), at), " -> "))
+						return ""
+					}
+				}
+				sub, e := r.resolve(y, extra, name, append(path, at))
+				if e == nil { s, e = sub.Output() }
+				if e != nil {
+					err = e
+					return ""
+				}
+				return "(?:" + s + ")"
+			})
+			if err != nil { return nil, err }
+		}
+	}
+
+	return c, nil
+}
+
+// the names whose latest version changes with the latest version of name
+func (r *Registry) dependents(name string) []string {
+	affected := map[string]bool{ name: true }
+	for changed := true; changed; {
+		changed = false
+		for n, vs := range(r.patterns) {
+			if affected[n] { continue }
+			for _, p := range(vs[len(vs)-1].refs) {
+				if p.version == 0 && affected[p.name] {
+					affected[n] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	delete(affected, name)
+	var ds []string
+	for n := range(affected) { ds = append(ds, n) }
+	sort.Strings(ds)
+
+	return ds
+}
+
+// checks g as the next version of name, returning the version and the
+// comparison with the previous one, if any
+func (r *Registry) check(name string, g *Gorex) (*registered, *Compatibility, error) {
+	if !patternNameRe.MatchString(name) { return nil, nil, errors.New("Gorex @210: invalid pattern name") }
+	refs, e := references(g)
+	if e != nil { return nil, nil, e }
+	vs := r.patterns[name]
+	x := &registered{ len(vs) + 1, g.clone(), refs }
+	if len(vs) != 0 { x.version = vs[len(vs)-1].version + 1 }
+	next, e := r.resolve(x, x, name, []string{ name + "@" + strconv.Itoa(x.version) })
+	if e != nil { return nil, nil, e }
+	if _, e = next.Output(); e != nil { return nil, nil, e }
+
+	c := &Compatibility{ Name: name, Version: x.version }
+	if len(vs) == 0 { return x, c, nil }
+	prev, e := r.resolve(vs[len(vs)-1], nil, name, []string{ name + "@" + strconv.Itoa(vs[len(vs)-1].version) })
+	if e != nil { return nil, nil, e }
+	c.Previous = vs[len(vs)-1].version
+	d, e := Difference(prev, next)
+	if e != nil { return nil, nil, e }
+	it, e := d.Strings(LengthOrder)
+	if e != nil { return nil, nil, e }
+	for len(c.Rejected) < compatibilityExamples && it.Next() { c.Rejected = append(c.Rejected, it.Text()) }
+	c.Dependents = r.dependents(name)
+
+	return x, c, nil
+}
+
+// Check compares g with the latest version of name, as Add would, without
+// adding it
+func (r *Registry) Check(name string, g *Gorex) (*Compatibility, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, c, e := r.check(name, g)
+
+	return c, e
+}
+
+// Add registers a copy of g as the next version of name, numbered from 1,
+// and returns its comparison with the previous version. the references of
+// g must name registered patterns and versions, and must not lead back to
+// g; the version is not added when they do. the versions are compared on
+// whole strings, anchors or not, so ^[0-9]+ followed by ^[0-9]{1,3} lists
+// 0000 as rejected, though the new version still finds 000 in it
+func (r *Registry) Add(name string, g *Gorex) (*Compatibility, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	x, c, e := r.check(name, g)
+	if e != nil { return nil, e }
+	r.patterns[name] = append(r.patterns[name], x)
+	r.order = append(r.order, patternRef{ name, x.version })
+
+	return c, nil
+}
+
+// adds a version as stored in a bundle, checking its references only
+func (r *Registry) restore(name string, version int, g *Gorex) error {
+	if !patternNameRe.MatchString(name) { return errors.New("Gorex @263: invalid pattern name") }
+	vs := r.patterns[name]
+	if version <= 0 || (len(vs) != 0 && version <= vs[len(vs)-1].version) { return errors.New("Gorex @265: invalid pattern version") }
+	refs, e := references(g)
+	if e != nil { return e }
+	x := &registered{ version, g.clone(), refs }
+	if _, e = r.resolve(x, x, name, []string{ name + "@" + strconv.Itoa(version) }); e != nil { return e }
+	r.patterns[name] = append(vs, x)
+	r.order = append(r.order, patternRef{ name, version })
+
+	return nil
+}
+
+// Get returns a copy of a version of name as added, with its references;
+// version 0 is the latest
+func (r *Registry) Get(name string, version int) (*Gorex, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	x, e := r.lookup(patternRef{ name, version }, nil, "")
+	if e != nil { return nil, e }
+
+	return x.g.clone(), nil
+}
+
+// Resolve returns a version of name with every reference replaced by the
+// resolved expression it refers to, in a non-capturing group; version 0 is
+// the latest
+func (r *Registry) Resolve(name string, version int) (*Gorex, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	x, e := r.lookup(patternRef{ name, version }, nil, "")
+	if e != nil { return nil, e }
+
+	return r.resolve(x, nil, name, []string{ name + "@" + strconv.Itoa(x.version) })
+}
+
+// Names returns the registered names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ns []string
+	for n := range(r.patterns) { ns = append(ns, n) }
+	sort.Strings(ns)
+
+	return ns
+}
+
+// Versions returns the versions of name, in order
+func (r *Registry) Versions(name string) []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var vs []int
+	for _, x := range(r.patterns[name]) { vs = append(vs, x.version) }
+
+	return vs
+}
+
+// Dependencies returns the references of a version of name, as name or
+// name@version, in the order they appear; version 0 is the latest
+func (r *Registry) Dependencies(name string, version int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	x, e := r.lookup(patternRef{ name, version }, nil, "")
+	if e != nil { return nil, e }
+	var ds []string
+	for _, p := range(x.refs) { ds = append(ds, p.String()) }
+
+	return ds, nil
+}
+
+// Dependents returns the names whose latest version refers to the latest
+// version of name, directly or through other patterns, sorted
+func (r *Registry) Dependents(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.dependents(name)
+}
+
+// json form of a registry
+type jsonRegistry struct {
+	Patterns []jsonPattern `json:"patterns"`
+}
+
+type jsonPattern struct {
+	Name string `json:"name"`
+	Version int `json:"version"`
+	Expression *Gorex `json:"expression"`
+}
+
+// MarshalJSON stores every version, in the order they were added
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	j := jsonRegistry{ Patterns: []jsonPattern{ } }
+	for _, p := range(r.order) {
+		x, _ := r.lookup(p, nil, "")
+		j.Patterns = append(j.Patterns, jsonPattern{ p.name, p.version, x.g })
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON replaces the registry with the stored versions, checking
+// their references but not their compatibility
+func (r *Registry) UnmarshalJSON(data []byte) error {
+	var j jsonRegistry
+	if e := json.Unmarshal(data, &j); e != nil { return e }
+	n := NewRegistry()
+	for _, p := range(j.Patterns) {
+		if p.Expression == nil { return fmt.Errorf("Gorex @373: pattern %s@%d: missing expression", p.Name, p.Version) }
+		if e := n.restore(p.Name, p.Version, p.Expression); e != nil { return fmt.Errorf("pattern %s@%d: %w", p.Name, p.Version, e) }
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns, r.order = n.patterns, n.order
+
+	return nil
+}
+
+// Bundle writes every version as definitions, in the order they were added,
+// each after a line naming it:
+//
+//  Pattern "octet" 1
+//  GolangExpression
+//  AddNumericRange ...
+func (r *Registry) Bundle() (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var b strings.Builder
+	for _, p := range(r.order) {
+		x, _ := r.lookup(p, nil, "")
+		d, e := x.g.Definition()
+		if e != nil { return "", fmt.Errorf("pattern %s: %w", p, e) }
+		fmt.Fprintf(&b, "Pattern %s %d\n%s", strconv.Quote(p.name), p.version, d)
+	}
+
+	return b.String(), nil
+}
+
+// ParseBundle builds a registry from the definitions Bundle writes,
+// returning the first failing statement with its line number
+func ParseBundle(in io.Reader) (*Registry, error) {
+	r := NewRegistry()
+	s := bufio.NewScanner(in)
+	var name string
+	var version, start int
+	var def strings.Builder
+	flush := func() error {
+		if name == "" { return nil }
+		g, e := ParseDefinition(strings.NewReader(def.String()))
+		if e == nil { e = r.restore(name, version, g) }
+		if e != nil { return fmt.Errorf("pattern %s@%d at line %d: %w", name, version, start, e) }
+		def.Reset()
+		return nil
+	}
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		words, e := splitStatement(line)
+		if e != nil { return nil, fmt.Errorf("line %d: %w", n, e) }
+		if len(words) == 0 || words[0] != "Pattern" {
+			if name == "" && len(words) != 0 { return nil, fmt.Errorf("line %d: %w", n, errors.New("Gorex @425: statement outside a pattern")) }
+			def.WriteString(line + "\n")
+			continue
+		}
+		if e = flush(); e != nil { return nil, e }
+		if len(words) != 3 { return nil, fmt.Errorf("line %d: %w", n, errors.New("Gorex @430: invalid pattern statement")) }
+		v, e := strconv.Atoi(words[2])
+		if e != nil { return nil, fmt.Errorf("line %d: %w", n, errors.New("Gorex @432: invalid pattern version")) }
+		name, version, start = words[1], v, n
+	}
+	if e := s.Err(); e != nil { return nil, e }
+	if e := flush(); e != nil { return nil, e }
+
+	return r, nil
+}
@@ -0,0 +1,148 @@
+package gorex
+
+import(
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testOctet(max int) *Gorex {
+	g, _ := GolangExpression()
+	g.AddNumericRange(0, max)
+
+	return g
+}
+
+func testFixed(s string) *Gorex {
+	g, _ := GolangExpression()
+	g.AddFixed(s)
+
+	return g
+}
+
+// a registry with octet 0-255, ipv4 built on it and pair, pinned to octet 1
+func testRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+	if _, e := r.Add("octet", testOctet(255)); e != nil { t.Fatalf("Add(octet) unexpected error: %s\n", e) }
+	ip := testFixed(Reference("octet", 0) + `(?:\.` + Reference("octet", 0) + "){3}")
+	if _, e := r.Add("ipv4", ip); e != nil { t.Fatalf("Add(ipv4) unexpected error: %s\n", e) }
+	if _, e := r.Add("pair", testFixed(Reference("octet", 1) + ":" + Reference("octet", 1))); e != nil { t.Fatalf("Add(pair) unexpected error: %s\n", e) }
+
+	return r
+}
+
+// the resolved expression of name, matching whole strings
+func testResolve(t *testing.T, r *Registry, name string, version int) *regexp.Regexp {
+	g, e := r.Resolve(name, version)
+	if e != nil { t.Fatalf("Resolve(%s, %d) unexpected error: %s\n", name, version, e) }
+	o, e := g.Output()
+	if e != nil { t.Fatalf("Resolve(%s, %d) output failed: %s\n", name, version, e) }
+
+	return regexp.MustCompile("^(?:" + o + ")$")
+}
+
+func TestRegistry(t *testing.T) {
+	r := testRegistry(t)
+	ip := testResolve(t, r, "ipv4", 0)
+	if !ip.MatchString("192.168.1.255") || ip.MatchString("192.168.1.256") { t.Fatalf("Resolve(ipv4) = %s\n", ip) }
+
+	c, e := r.Add("octet", testOctet(99))
+	if e != nil { t.Fatalf("Add(octet) unexpected error: %s\n", e) }
+	if c.Compatible() || c.Previous != 1 || c.Version != 2 || !reflect.DeepEqual(c.Rejected, []string{ "100", "101", "102", "103", "104" }) {
+		t.Fatalf("Add(octet) compatibility = %+v\n", c)
+	}
+	// pair refers to version 1 and does not change
+	if !reflect.DeepEqual(c.Dependents, []string{ "ipv4" }) { t.Fatalf("Add(octet) dependents = %q\n", c.Dependents) }
+	if ip = testResolve(t, r, "ipv4", 0); ip.MatchString("192.168.1.1") { t.Fatalf("Resolve(ipv4) does not follow octet 2\n") }
+	if !testResolve(t, r, "pair", 0).MatchString("200:255") { t.Fatalf("Resolve(pair) does not keep octet 1\n") }
+	if !testResolve(t, r, "octet", 1).MatchString("255") { t.Fatalf("Resolve(octet, 1) lost version 1\n") }
+
+	c, e = r.Add("octet", testOctet(999))
+	if e != nil || !c.Compatible() || c.Version != 3 { t.Fatalf("Add(octet) widened = %+v (%v)\n", c, e) }
+	if vs := r.Versions("octet"); !reflect.DeepEqual(vs, []int{ 1, 2, 3 }) { t.Fatalf("Versions(octet) = %v\n", vs) }
+	if ns := r.Names(); !reflect.DeepEqual(ns, []string{ "ipv4", "octet", "pair" }) { t.Fatalf("Names() = %q\n", ns) }
+	if ds, _ := r.Dependencies("pair", 0); !reflect.DeepEqual(ds, []string{ "octet@1", "octet@1" }) { t.Fatalf("Dependencies(pair) = %q\n", ds) }
+	if ds := r.Dependents("octet"); !reflect.DeepEqual(ds, []string{ "ipv4" }) { t.Fatalf("Dependents(octet) = %q\n", ds) }
+
+	// Check does not add
+	if c, e = r.Check("octet", testOctet(9)); e != nil || c.Compatible() || c.Version != 4 { t.Fatalf("Check(octet) = %+v (%v)\n", c, e) }
+	if vs := r.Versions("octet"); len(vs) != 3 { t.Fatalf("Check(octet) added a version\n") }
+
+	// compared on whole strings, though neither is anchored at the end
+	r.Add("digits", testFixed("^[0-9]+"))
+	if c, e = r.Add("digits", testFixed("^[0-9]{1,3}")); e != nil || len(c.Rejected) == 0 || c.Rejected[0] != "0000" {
+		t.Fatalf("Add(digits) compatibility = %+v (%v)\n", c, e)
+	}
+
+	// the registry keeps a copy
+	g := testFixed("a")
+	r.Add("copy", g)
+	g.AddFixed("b")
+	if got, _ := r.Get("copy", 0); len(got.groups) != 1 { t.Fatalf("Get() follows changes to the added expression\n") }
+}
+
+func TestRegistryErrors(t *testing.T) {
+	r := NewRegistry()
+	r.Add("a", testFixed("x"))
+	r.Add("b", testFixed("<" + Reference("a", 0) + ">"))
+	if _, e := r.Add("a", testFixed(Reference("b", 0))); e == nil || !strings.Contains(e.Error(), "a@2 -> b@1 -> a@2") {
+		t.Fatalf("Add() of a cycle error = %v\n", e)
+	}
+	if _, e := r.Add("a", testFixed(Reference("a", 0))); e == nil { t.Fatalf("Add() of a self reference expects error\n") }
+	if _, e := r.Add("a", testFixed(Reference("a", 1) + "y")); e != nil { t.Fatalf("Add() of a reference to an earlier version unexpected error: %s\n", e) }
+	if !testResolve(t, r, "b", 0).MatchString("<xy>") { t.Fatalf("Resolve(b) does not follow a@2\n") }
+
+	if _, e := r.Add("c", testFixed(Reference("missing", 0))); e == nil { t.Fatalf("Add() of an unknown reference expects error\n") }
+	if _, e := r.Add("c", testFixed(Reference("a", 7))); e == nil { t.Fatalf("Add() of an unknown version expects error\n") }
+	if _, e := r.Add("c", testFixed("%{a@0}")); e == nil { t.Fatalf("Add() of version 0 expects error\n") }
+	if _, e := r.Add("a b", testFixed("x")); e == nil { t.Fatalf("Add() of an invalid name expects error\n") }
+	if _, e := r.Resolve("missing", 0); e == nil { t.Fatalf("Resolve() of an unknown pattern expects error\n") }
+	if _, e := r.Get("a", 3); e == nil { t.Fatalf("Get() of an unknown version expects error\n") }
+	if vs := r.Versions("c"); vs != nil { t.Fatalf("failed Add() left versions %v\n", vs) }
+}
+
+// the resolved outputs of every version
+func testOutputs(t *testing.T, r *Registry) map[string]string {
+	out := map[string]string{ }
+	for _, n := range(r.Names()) {
+		for _, v := range(r.Versions(n)) {
+			g, e := r.Resolve(n, v)
+			if e != nil { t.Fatalf("Resolve(%s, %d) unexpected error: %s\n", n, v, e) }
+			out[Reference(n, v)], _ = g.Output()
+		}
+	}
+
+	return out
+}
+
+func TestRegistryBundle(t *testing.T) {
+	r := testRegistry(t)
+	r.Add("octet", testOctet(99))
+	want := testOutputs(t, r)
+
+	b, e := r.Bundle()
+	if e != nil { t.Fatalf("Bundle() unexpected error: %s\n", e) }
+	if !strings.HasPrefix(b, "Pattern \"octet\" 1\nGolangExpression\n") { t.Fatalf("Bundle() = %s\n", b) }
+	p, e := ParseBundle(strings.NewReader(b))
+	if e != nil { t.Fatalf("ParseBundle() unexpected error: %s\n", e) }
+	if got := testOutputs(t, p); !reflect.DeepEqual(got, want) { t.Fatalf("ParseBundle() = %q; expected %q\n", got, want) }
+
+	data, e := json.Marshal(r)
+	if e != nil { t.Fatalf("MarshalJSON() unexpected error: %s\n", e) }
+	j := NewRegistry()
+	if e = json.Unmarshal(data, j); e != nil { t.Fatalf("UnmarshalJSON() unexpected error: %s\n", e) }
+	if got := testOutputs(t, j); !reflect.DeepEqual(got, want) { t.Fatalf("UnmarshalJSON() = %q; expected %q\n", got, want) }
+
+	for _, bad := range([]string{
+		"AddFixed \"x\"\n",
+		"Pattern \"a\" 1\nAddFixed \"%{b}\"\n",
+		"Pattern \"a\" 2\nAddFixed \"x\"\nPattern \"a\" 1\nAddFixed \"y\"\n",
+		"Pattern \"a\" one\n",
+		"Pattern \"a\" 1\nAddFixed\n",
+	}) {
+		if _, e = ParseBundle(strings.NewReader(bad)); e == nil { t.Fatalf("ParseBundle(%q) expects error\n", bad) }
+	}
+	if e = json.Unmarshal([]byte(`{"patterns":[{"name":"a","version":1}]}`), j); e == nil { t.Fatalf("UnmarshalJSON() without expression expects error\n") }
+}
@@ -0,0 +1,169 @@
+package gorex
+
+// sets
+//
+// a Set matches many expressions against an input at once, reporting which
+// match and where, as RE2's RE2::Set does:
+//
+//  set, e := gorex.NewSet(sshd, sudo, cron, kernel)
+//  for _, i := range(set.Match(line)) { counts[i]++ }
+//  for _, m := range(set.Find(line)) { fmt.Println(m.Pattern, line[m.Start:m.End]) }
+//
+// expressions that every match starts with literal text, as those starting
+// with AddFixed do, are found by a single Aho-Corasick scan for their
+// prefixes, checking each expression only where its prefix occurs; the
+// others are searched for one by one.
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// SetMatch is the leftmost match of an expression of a set, at byte offsets
+// in the input
+type SetMatch struct {
+	Pattern int // the index of the expression in the set
+	Start int
+	End int
+}
+
+// an expression of a set
+type setPattern struct {
+	rex *regexp.Regexp
+	prefix string
+	first *regexp.Regexp // matching at the start of the input
+	next *regexp.Regexp // matching after a rune of context, in group 1
+}
+
+// Set is a compiled set of expressions; it is safe for concurrent use
+type Set struct {
+	patterns []setPattern
+	scanned []int // the patterns with a prefix
+	others []int // the patterns without
+	goTo [][256]int32 // the Aho-Corasick automaton of the prefixes
+	out [][]int // the patterns whose prefix ends at each state
+}
+
+// NewSet compiles expressions into a set; the index of each in the set is
+// its index among the arguments
+func NewSet(gs ...*Gorex) (*Set, error) {
+	s := &Set{ }
+	for i, g := range(gs) {
+		o, e := g.Output()
+		if e != nil { return nil, e }
+		rex, e := regexp.Compile(o)
+		if e != nil { return nil, e }
+		lit, e := g.Literals()
+		if e != nil { return nil, e }
+
+		p := setPattern{ rex: rex, prefix: lit.Prefix }
+		if p.prefix == "" {
+			s.others = append(s.others, i)
+		} else {
+			p.first = regexp.MustCompile("^(" + o + ")")
+			p.next = regexp.MustCompile("^(?s:.)(" + o + ")")
+			s.scanned = append(s.scanned, i)
+		}
+		s.patterns = append(s.patterns, p)
+	}
+	s.build()
+
+	return s, nil
+}
+
+// builds the automaton of the prefixes, with every transition filled in
+func (s *Set) build() {
+	s.goTo = [][256]int32{ { } }
+	s.out = [][]int{ nil }
+	for _, i := range(s.scanned) {
+		state := int32(0)
+		for _, b := range([]byte(s.patterns[i].prefix)) {
+			if s.goTo[state][b] == 0 {
+				s.goTo = append(s.goTo, [256]int32{ })
+				s.out = append(s.out, nil)
+				s.goTo[state][b] = int32(len(s.goTo) - 1)
+			}
+			state = s.goTo[state][b]
+		}
+		s.out[state] = append(s.out[state], i)
+	}
+
+	// breadth first, the failure of a state is the longest proper suffix of
+	// its text that is a state
+	fail := make([]int32, len(s.goTo))
+	var queue []int32
+	for b := 0; b < 256; b++ {
+		if child := s.goTo[0][b]; child != 0 { queue = append(queue, child) }
+	}
+	for len(queue) != 0 {
+		state := queue[0]
+		queue = queue[1:]
+		s.out[state] = append(s.out[state], s.out[fail[state]]...)
+		for b := 0; b < 256; b++ {
+			child := s.goTo[state][b]
+			if child == 0 {
+				s.goTo[state][b] = s.goTo[fail[state]][b]
+				continue
+			}
+			fail[child] = s.goTo[fail[state]][b]
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Len returns the number of expressions in the set
+func (s *Set) Len() int {
+	return len(s.patterns)
+}
+
+// the end of the match of pattern p starting at start, or -1
+func (p *setPattern) matchAt(input string, start int) int {
+	if start == 0 {
+		if at := p.first.FindStringSubmatchIndex(input); at != nil { return at[3] }
+		return -1
+	}
+	_, w := utf8.DecodeLastRuneInString(input[:start])
+	if at := p.next.FindStringSubmatchIndex(input[start-w:]); at != nil && at[2] == w { return start - w + at[3] }
+
+	return -1
+}
+
+// Find returns the leftmost match of each expression matching input, as
+// FindStringIndex finds it, in the order of the set
+func (s *Set) Find(input string) []SetMatch {
+	found := make([]SetMatch, len(s.patterns))
+	for i := range(found) { found[i].Pattern = -1 }
+
+	// the prefixes of each pattern end in order, so the first match
+	// starting at one is the leftmost
+	left, state := len(s.scanned), int32(0)
+	for i := 0; i < len(input) && left > 0; i++ {
+		state = s.goTo[state][input[i]]
+		for _, p := range(s.out[state]) {
+			if found[p].Pattern >= 0 { continue }
+			start := i + 1 - len(s.patterns[p].prefix)
+			if end := s.patterns[p].matchAt(input, start); end >= 0 {
+				found[p] = SetMatch{ p, start, end }
+				left--
+			}
+		}
+	}
+	for _, p := range(s.others) {
+		if at := s.patterns[p].rex.FindStringIndex(input); at != nil { found[p] = SetMatch{ p, at[0], at[1] } }
+	}
+
+	var ms []SetMatch
+	for _, m := range(found) {
+		if m.Pattern >= 0 { ms = append(ms, m) }
+	}
+
+	return ms
+}
+
+// Match returns the indices of the expressions matching input, in order
+func (s *Set) Match(input string) []int {
+	var is []int
+	for _, m := range(s.Find(input)) { is = append(is, m.Pattern) }
+
+	return is
+}
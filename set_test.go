@@ -0,0 +1,95 @@
+package gorex
+
+import(
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// the leftmost matches of each expression, found one by one
+func naiveFind(rexes []*regexp.Regexp, input string) []SetMatch {
+	var ms []SetMatch
+	for i, rex := range(rexes) {
+		if at := rex.FindStringIndex(input); at != nil { ms = append(ms, SetMatch{ i, at[0], at[1] }) }
+	}
+
+	return ms
+}
+
+func testSet(t *testing.T, exprs []string) (*Set, []*regexp.Regexp) {
+	var gs []*Gorex
+	var rexes []*regexp.Regexp
+	for _, x := range(exprs) {
+		g, e := FromRegexp(x)
+		if e != nil { t.Fatalf("FromRegexp(%s) unexpected error: %s\n", x, e) }
+		gs = append(gs, g)
+		rexes = append(rexes, regexp.MustCompile(x))
+	}
+	s, e := NewSet(gs...)
+	if e != nil { t.Fatalf("NewSet() unexpected error: %s\n", e) }
+
+	return s, rexes
+}
+
+func TestSet(t *testing.T) {
+	s, rexes := testSet(t, []string{
+		`he`, `she`, `hers`, `his[0-9]*`,
+		`ab|abc`, `abc(?:de)?`,
+		`\bcat\b`, `(?m)^dog$`, `^start`, `end$`,
+		`[0-9]{2,}`, `x?`, `é+ñ`,
+	})
+	if len(s.scanned) != 11 || s.Len() != 13 { t.Fatalf("NewSet() scans %d of %d expressions\n", len(s.scanned), s.Len()) }
+	for _, input := range([]string{
+		"", "ushers", "his123 hers", "abcde abc", "concat cat", "hot\ndog\nend",
+		"start dog", "no digits 7", "ééñ", "xabcat end", "ab\xffcat",
+		strings.Repeat("a", 100) + "she said 42",
+	}) {
+		want := naiveFind(rexes, input)
+		if got := s.Find(input); !reflect.DeepEqual(got, want) { t.Fatalf("Find(%q) = %v; expected %v\n", input, got, want) }
+		var is []int
+		for _, m := range(want) { is = append(is, m.Pattern) }
+		if got := s.Match(input); !reflect.DeepEqual(got, is) { t.Fatalf("Match(%q) = %v; expected %v\n", input, got, is) }
+	}
+
+	if s, _ = NewSet(); s.Len() != 0 || s.Match("anything") != nil { t.Fatalf("NewSet() of nothing matches\n") }
+	bad, _ := GolangExpression()
+	bad.AddFixed("(")
+	if _, e := NewSet(testLog(), bad); e == nil { t.Fatalf("NewSet() of an invalid expression expects error\n") }
+}
+
+// n expressions, each starting with a word of its own
+func benchmarkPatterns(n int) []*Gorex {
+	var gs []*Gorex
+	for i := 0; i < n; i++ {
+		g, _ := GolangExpression()
+		g.AddFixed(fmt.Sprintf("event%d=", i))
+		g.AddClass(Digits)
+		g.ApplyQuantifier(MinToMax, 1, 6)
+		g.AddFixed(" ")
+		g.AddClass(Lowers)
+		g.ApplyQuantifier(OneOrMore, 0, 0)
+		gs = append(gs, g)
+	}
+
+	return gs
+}
+
+var benchmarkLine = strings.Repeat("2024-02-29T12:00:00Z host sshd[42]: accepted key for user ", 3) + "event17=250 ok event42=7 done"
+
+func BenchmarkSetMatch(b *testing.B) {
+	s, _ := NewSet(benchmarkPatterns(50)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ { s.Match(benchmarkLine) }
+}
+
+func BenchmarkNaiveSetMatch(b *testing.B) {
+	var rexes []*regexp.Regexp
+	for _, g := range(benchmarkPatterns(50)) {
+		o, _ := g.Output()
+		rexes = append(rexes, regexp.MustCompile(o))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ { naiveFind(rexes, benchmarkLine) }
+}
@@ -0,0 +1,121 @@
+package gorex
+
+// split functions
+//
+// SplitFunc and TokenSplitFunc make bufio.SplitFunc values of an expression,
+// for a bufio.Scanner to read the records it divides, or its matches:
+//
+//  split, e := rex.SplitFunc()   // records between lines of dashes
+//  sc := bufio.NewScanner(f)
+//  sc.Split(split)
+//  for sc.Scan() {
+//  	fmt.Println(sc.Text())
+//  }
+//
+// a match is taken only when more data cannot change it: the longest match
+// and a rune after it have been read, or the input has ended, so the
+// expression must have a greatest length. empty matches neither divide nor
+// are tokens. anchors and word boundaries see the text the scanner has
+// already passed, so a split function serves one Scanner.
+
+import (
+	"bufio"
+	"errors"
+	"unicode/utf8"
+)
+
+// a split function's state
+type splitter struct {
+	rex *matcher
+	max int // longest match
+	prev []byte // the end of the text before data, the context of anchors
+}
+
+func (g *Gorex) splitter() (*splitter, error) {
+	_, max, e := g.LengthBounds()
+	if e != nil { return nil, e }
+	if max == Unbounded { return nil, errors.New("Gorex @37: unbounded match length cannot split") }
+	o, e := g.Output()
+	if e != nil { return nil, e }
+	rex, e := newMatcher(o)
+	if e != nil { return nil, e }
+
+	return &splitter{ rex: rex, max: max }, nil
+}
+
+// the first non-empty match in data more data cannot change, or -1, -1
+func (sp *splitter) find(data []byte, atEOF bool) (int, int) {
+	text, k := data, 0
+	if sp.rex.plain == nil && len(sp.prev) != 0 {
+		text = append(append([]byte{ }, sp.prev...), data...)
+		k = len(sp.prev)
+	}
+	settled := len(text) - sp.max - utf8.UTFMax
+	for p := k; p <= len(text); {
+		at := sp.rex.find(text, p)
+		if at == nil || (!atEOF && at[0] > settled) { break }
+		if at[1] > at[0] { return at[0] - k, at[1] - k }
+		if at[0] == len(text) { break }
+		// steps over the empty match
+		_, w := utf8.DecodeRune(text[at[0]:])
+		p = at[0] + w
+	}
+
+	return -1, -1
+}
+
+// consumes data up to n, keeping its end as the context of what follows
+func (sp *splitter) advance(data []byte, n int) int {
+	if sp.rex.plain == nil && n > 0 {
+		sp.prev = append(sp.prev, data[:n]...)
+		if len(sp.prev) > utf8.UTFMax { sp.prev = append(sp.prev[:0], sp.prev[len(sp.prev)-utf8.UTFMax:]...) }
+	}
+
+	return n
+}
+
+// the last rune start in data before which no match can begin
+func (sp *splitter) unsettled(data []byte) int {
+	n, p := len(data) - sp.max - utf8.UTFMax, 0
+	for p < n {
+		_, w := utf8.DecodeRune(data[p:])
+		if p + w > n { break }
+		p += w
+	}
+
+	return p
+}
+
+// SplitFunc returns a split function whose tokens are the text between
+// the matches of the expression, as regexp's Split gives them, without the
+// empty token after a final match; it returns an error for an expression
+// without a greatest length
+func (g *Gorex) SplitFunc() (bufio.SplitFunc, error) {
+	sp, e := g.splitter()
+	if e != nil { return nil, e }
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 { return 0, nil, nil }
+		if start, end := sp.find(data, atEOF); start >= 0 { return sp.advance(data, end), data[:start], nil }
+		// the last token
+		if atEOF { return sp.advance(data, len(data)), data, nil }
+
+		return 0, nil, nil
+	}, nil
+}
+
+// TokenSplitFunc returns a split function whose tokens are the non-empty
+// matches of the expression, skipping the text between them; it returns an
+// error for an expression without a greatest length
+func (g *Gorex) TokenSplitFunc() (bufio.SplitFunc, error) {
+	sp, e := g.splitter()
+	if e != nil { return nil, e }
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if start, end := sp.find(data, atEOF); start >= 0 { return sp.advance(data, end), data[start:end], nil }
+		if atEOF { return sp.advance(data, len(data)), nil, nil }
+
+		// drops the data no match can start in
+		return sp.advance(data, sp.unsettled(data)), nil, nil
+	}, nil
+}
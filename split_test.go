@@ -0,0 +1,89 @@
+package gorex
+
+import(
+	"bufio"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// the tokens of split over input, read in every way a reader may give it
+func testSplit(t *testing.T, split func() (bufio.SplitFunc, error), input string, want []string) {
+	for _, r := range([]io.Reader{
+		strings.NewReader(input),
+		iotest.OneByteReader(strings.NewReader(input)),
+		iotest.HalfReader(strings.NewReader(input)),
+		iotest.DataErrReader(iotest.OneByteReader(strings.NewReader(input))),
+	}) {
+		f, e := split()
+		if e != nil { t.Fatalf("split function unexpected error: %s\n", e) }
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 1), 1 << 16)
+		sc.Split(f)
+		var got []string
+		for sc.Scan() { got = append(got, sc.Text()) }
+		if sc.Err() != nil { t.Fatalf("Scan() of %q unexpected error: %s\n", input, sc.Err()) }
+		if !reflect.DeepEqual(got, want) { t.Fatalf("Scan() of %q = %q; expected %q\n", input, got, want) }
+	}
+}
+
+func TestSplitFunc(t *testing.T) {
+	for _, c := range([]struct {
+		expr, input string
+		want []string // if not as regexp's Split, without a last empty string
+	}{
+		{ `\r?\n`, "a\r\nb\n\nc\r\n", nil },
+		{ `\r?\n`, "no separator", nil },
+		{ `,[ ]{0,3}`, "a, b,c,   d,,e", nil },
+		{ `(?m)^--$\n`, "a\n--\nb --\nc\n--\n", nil },
+		{ `\b;\b`, "a;b ;c; d;e", nil },
+		{ `→{1,2}`, "α→β→→γ→", nil },
+		// empty matches do not divide
+		{ `x?`, "axbxxc", []string{ "a", "b", "", "c" } },
+	}) {
+		g, e := FromRegexp(c.expr)
+		if e != nil { t.Fatalf("FromRegexp(%s) unexpected error: %s\n", c.expr, e) }
+		want := c.want
+		if want == nil {
+			want = regexp.MustCompile(c.expr).Split(c.input, -1)
+			if want[len(want)-1] == "" { want = want[:len(want)-1] }
+		}
+		testSplit(t, g.SplitFunc, c.input, want)
+	}
+	testSplit(t, testLog().SplitFunc, "", nil)
+}
+
+func TestTokenSplitFunc(t *testing.T) {
+	for _, c := range([]struct {
+		expr, input string
+	}{
+		{ `[0-9]{1,4}`, "ab 12 34567 x9" },
+		{ `\b[a-z]{2,3}\b`, "ab abcd xyz q ab" },
+		{ `(?m)^[a-z]{1,5}$`, "abc\ndef gh\nij\nx" },
+		{ `é{1,3}[a-z]?`, "aéébéééé\xffx" },
+		{ `[0-9]{0,2}`, "a12b345" },
+		{ `[0-9]`, "none" },
+	}) {
+		g, e := FromRegexp(c.expr)
+		if e != nil { t.Fatalf("FromRegexp(%s) unexpected error: %s\n", c.expr, e) }
+		var want []string
+		for _, s := range(regexp.MustCompile(c.expr).FindAllString(c.input, -1)) {
+			if s != "" { want = append(want, s) }
+		}
+		testSplit(t, g.TokenSplitFunc, c.input, want)
+	}
+	// longer than the scanner's buffer
+	input := strings.Repeat("x ERROR: 12ms ERROR: 345ms\n", 5000) + "ERROR: 9"
+	log := testLog()
+	o, _ := log.Output()
+	testSplit(t, log.TokenSplitFunc, input, regexp.MustCompile(o).FindAllString(input, -1))
+}
+
+func TestSplitFuncErrors(t *testing.T) {
+	g, _ := FromRegexp(`a+`)
+	if _, e := g.SplitFunc(); e == nil { t.Fatalf("SplitFunc() of an unbounded expression expects error\n") }
+	if _, e := g.TokenSplitFunc(); e == nil { t.Fatalf("TokenSplitFunc() of an unbounded expression expects error\n") }
+}
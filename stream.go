@@ -0,0 +1,262 @@
+package gorex
+
+// streams
+//
+// Stream finds the matches of an expression in a reader too large to hold,
+// reading it in chunks and keeping no more than the longest match and a
+// chunk:
+//
+//  f, _ := os.Open("huge.log")
+//  s, e := rex.Stream(f, 0)   // the longest match from LengthBounds
+//  for s.Next() {
+//  	m := s.Match()
+//  	code, _ := m.Group("code")
+//  	fmt.Println(m.Start, m.Text, code)
+//  }
+//  if s.Err() != nil { ... }
+//
+// the matches are those FindAllIndex finds in the whole stream, provided no
+// match is longer than the maximum; longer matches may be cut short.
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// the bytes a stream reads at a time, unless set with SetChunkSize
+const streamChunk = 64 << 10
+
+// StreamGroup is a group of a match, at byte offsets from the start of the
+// stream; Start and End are -1 when the group takes no part in the match
+type StreamGroup struct {
+	Name string
+	Start int64
+	End int64
+	Text string
+}
+
+// StreamMatch is a match in a stream, at byte offsets from its start, with
+// the groups of the expression from group 1
+type StreamMatch struct {
+	Start int64
+	End int64
+	Text string
+	Groups []StreamGroup
+}
+
+// Group returns the text of the named group, and whether it took part in
+// the match
+func (m StreamMatch) Group(name string) (string, bool) {
+	for _, g := range(m.Groups) {
+		if g.Name == name && g.Start >= 0 { return g.Text, true }
+	}
+
+	return "", false
+}
+
+// Stream lists the matches of an expression in a reader; see Stream
+type Stream struct {
+	r io.Reader
+	rex *matcher
+	max int // longest match
+	chunk int
+	buf []byte
+	base int64 // offset of buf[0] in the stream
+	pos int64 // where the search resumes
+	prevEnd int64 // end of the last match, -1 before any
+	eof bool
+	err error
+	m StreamMatch
+}
+
+// Stream returns the matches of the expression in r, in order. max is the
+// longest match, in bytes; with 0 it is taken from LengthBounds, which is
+// an error for unbounded expressions, and a greater max than LengthBounds
+// gives is lowered to it
+func (g *Gorex) Stream(r io.Reader, max int) (*Stream, error) {
+	if max < 0 { return nil, errors.New("Gorex @82: invalid maximum match length") }
+	_, bound, e := g.LengthBounds()
+	if max == 0 {
+		if e != nil { return nil, e }
+		if bound == Unbounded { return nil, errors.New("Gorex @86: unbounded match length requires a maximum") }
+	}
+	if e == nil && bound != Unbounded && (max == 0 || bound < max) { max = bound }
+
+	o, e := g.Output()
+	if e != nil { return nil, e }
+	rex, e := newMatcher(o)
+	if e != nil { return nil, e }
+
+	return &Stream{ r: r, rex: rex, max: max, chunk: streamChunk, prevEnd: -1 }, nil
+}
+
+// matcher finds matches at a position in a buffer, seeing the text before it
+type matcher struct {
+	plain *regexp.Regexp // searching without context, if no assertion needs it
+	first *regexp.Regexp // searching from the start of the buffer
+	next *regexp.Regexp // searching after a rune of context
+	names []string
+}
+
+func newMatcher(o string) (*matcher, error) {
+	rex, e := regexp.Compile(o)
+	if e != nil { return nil, e }
+	m := &matcher{ names: rex.SubexpNames() }
+	re, _ := syntax.Parse(o, syntax.Perl)
+	if !assertions(re) {
+		m.plain = rex
+		return m, nil
+	}
+	// as unanchored searches are, with the match in group 1
+	m.first = regexp.MustCompile("^(?s:.*?)(" + o + ")")
+	m.next = regexp.MustCompile("^(?s:.)(?s:.*?)(" + o + ")")
+
+	return m, nil
+}
+
+// the indices in b of the first match at or after p and of its groups, as
+// FindSubmatchIndex gives them, or nil
+func (m *matcher) find(b []byte, p int) []int {
+	rex, ctx := m.plain, p
+	if rex == nil {
+		rex = m.first
+		if p > 0 {
+			_, w := utf8.DecodeLastRune(b[:p])
+			rex, ctx = m.next, p - w
+		}
+	}
+	at := rex.FindSubmatchIndex(b[ctx:])
+	if at == nil { return nil }
+	if m.plain == nil { at = at[2:] }
+	for i := range(at) {
+		if at[i] >= 0 { at[i] += ctx }
+	}
+
+	return at
+}
+
+// whether re has anchors or word boundaries, which look at the text around
+// where they stand
+func assertions(re *syntax.Regexp) bool {
+	switch(re.Op) {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range(re.Sub) {
+		if assertions(sub) { return true }
+	}
+
+	return false
+}
+
+// SetChunkSize sets the bytes read at a time, before the first call to Next
+func (s *Stream) SetChunkSize(n int) {
+	if n < 1 { n = 1 }
+	s.chunk = n
+}
+
+// the first position at or after p where a rune starts, as the matcher
+// steps through the buffer from from
+func (s *Stream) runeStart(from int64, p int64) int64 {
+	for from < p {
+		_, w := utf8.DecodeRune(s.buf[from-s.base:])
+		from += int64(w)
+	}
+
+	return from
+}
+
+// looks for the next match in the buffer: found reports one, and done that
+// there are no more; with neither, the buffer is too short to tell
+func (s *Stream) search() (found bool, done bool) {
+	end := s.base + int64(len(s.buf))
+	for s.pos <= end {
+		// a match is settled when the longest match and a rune after it
+		// are in the buffer
+		settled := func(p int64) bool { return s.eof || p + int64(s.max + utf8.UTFMax) <= end }
+
+		at := s.rex.find(s.buf, int(s.pos - s.base))
+		start, stop := int64(-1), int64(-1)
+		if at != nil { start, stop = s.base + int64(at[0]), s.base + int64(at[1]) }
+		if at == nil || !settled(start) {
+			if s.eof { return false, true }
+			// no match starts where the search is settled
+			if p := end - int64(s.max + utf8.UTFMax); p > s.pos && (at == nil || p <= start) { s.pos = s.runeStart(s.pos, p) }
+			return false, false
+		}
+
+		// as regexp's FindAll steps over empty matches
+		accept := true
+		if stop == s.pos {
+			if start == s.prevEnd { accept = false }
+			if s.pos < end {
+				_, w := utf8.DecodeRune(s.buf[s.pos-s.base:])
+				s.pos += int64(w)
+			} else {
+				s.pos = end + 1
+			}
+		} else {
+			s.pos = stop
+		}
+		s.prevEnd = stop
+		if !accept { continue }
+
+		s.m = StreamMatch{ start, stop, string(s.buf[start-s.base:stop-s.base]), nil }
+		for i := 1; i < len(s.rex.names); i++ {
+			gr := StreamGroup{ s.rex.names[i], -1, -1, "" }
+			if at[2*i] >= 0 {
+				gr.Start, gr.End = s.base + int64(at[2*i]), s.base + int64(at[2*i+1])
+				gr.Text = string(s.buf[gr.Start-s.base:gr.End-s.base])
+			}
+			s.m.Groups = append(s.m.Groups, gr)
+		}
+		return true, false
+	}
+
+	return false, true
+}
+
+// drops what the search no longer needs and reads a chunk
+func (s *Stream) fill() {
+	// a rune before the search position is its context
+	if keep := s.pos - utf8.UTFMax; keep > s.base {
+		if keep > s.base + int64(len(s.buf)) { keep = s.base + int64(len(s.buf)) }
+		s.buf = append(s.buf[:0], s.buf[keep-s.base:]...)
+		s.base = keep
+	}
+	n := len(s.buf)
+	if cap(s.buf) - n < s.chunk { s.buf = append(s.buf, make([]byte, s.chunk)...)[:n] }
+	read, e := io.ReadAtLeast(s.r, s.buf[n:n+s.chunk], s.chunk)
+	s.buf = s.buf[:n+read]
+	if e == io.EOF || e == io.ErrUnexpectedEOF {
+		s.eof = true
+	} else if e != nil {
+		s.err = e
+	}
+}
+
+// Next finds the next match, reporting false at the end of the stream or
+// on a read error
+func (s *Stream) Next() bool {
+	for s.err == nil {
+		found, done := s.search()
+		if found { return true }
+		if done { return false }
+		s.fill()
+	}
+
+	return false
+}
+
+// Match returns the match found by the last call to Next
+func (s *Stream) Match() StreamMatch {
+	return s.m
+}
+
+// Err returns the read error that ended the stream, if any
+func (s *Stream) Err() error {
+	return s.err
+}
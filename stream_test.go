@@ -0,0 +1,131 @@
+package gorex
+
+import(
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// the matches of a stream over r
+func testStream(t *testing.T, g *Gorex, r io.Reader, max int, chunk int) []StreamMatch {
+	s, e := g.Stream(r, max)
+	if e != nil { t.Fatalf("Stream() unexpected error: %s\n", e) }
+	s.SetChunkSize(chunk)
+	var ms []StreamMatch
+	for s.Next() { ms = append(ms, s.Match()) }
+	if s.Err() != nil { t.Fatalf("Stream() unexpected read error: %s\n", s.Err()) }
+
+	return ms
+}
+
+// checks a stream finds what FindAllSubmatchIndex finds in the whole input
+func testStreamInput(t *testing.T, g *Gorex, input string, max int) {
+	o, _ := g.Output()
+	rex := regexp.MustCompile(o)
+	want := rex.FindAllStringSubmatchIndex(input, -1)
+	for _, chunk := range([]int{ 1, 2, 3, 7, 64, 1 << 16 }) {
+		for _, r := range([]io.Reader{ strings.NewReader(input), iotest.OneByteReader(strings.NewReader(input)), iotest.HalfReader(strings.NewReader(input)) }) {
+			got := testStream(t, g, r, max, chunk)
+			if len(got) != len(want) { t.Fatalf("Stream(%s) of %q in chunks of %d found %d matches; expected %d\n", o, input, chunk, len(got), len(want)) }
+			for i, m := range(got) {
+				w := want[i]
+				if m.Start != int64(w[0]) || m.End != int64(w[1]) || m.Text != input[w[0]:w[1]] || len(m.Groups) != len(w) / 2 - 1 {
+					t.Fatalf("Stream(%s) of %q in chunks of %d match %d = %+v; expected %v\n", o, input, chunk, i, m, w)
+				}
+				for j, gr := range(m.Groups) {
+					if gr.Start != int64(w[2*j+2]) || gr.End != int64(w[2*j+3]) || gr.Name != rex.SubexpNames()[j+1] {
+						t.Fatalf("Stream(%s) of %q group %d = %+v; expected %v\n", o, input, j + 1, gr, w[2*j+2:2*j+4])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	log := testLog()
+	log.NameGroup("ms")
+	input := strings.Repeat("ERROR: 12ms ok ERROR: 345ms ERROR: 7ms ERROR: 1234ms ", 5)
+	testStreamInput(t, log, input, 0)
+	testStreamInput(t, log, "", 0)
+
+	// anchors and word boundaries look around the chunks
+	for _, c := range([]struct {
+		expr, input string
+		max int
+	}{
+		{ `\bab\b`, "ab xab ab_ ab ab", 0 },
+		{ `(?m)^a+$`, "aa\naaa\nba\naaaa", 10 },
+		{ `^a|b$`, "aabab", 0 },
+		{ `a*`, "baaacaad", 5 },
+		{ `\Bé+`, "éé xéé éxé", 8 },
+		{ `(?P<w>[^ ]{2})(?P<n>[0-9])?`, "ab1 cd ef2 g", 0 },
+		{ `[\x{80}-\x{10FFFF}]{2}`, "aé\U0001F600b世界", 0 },
+		{ `x?`, "\xff\xfex\xe4\xb8", 1 },
+	}) {
+		g, e := FromRegexp(c.expr)
+		if e != nil { t.Fatalf("FromRegexp(%s) unexpected error: %s\n", c.expr, e) }
+		testStreamInput(t, g, c.input, c.max)
+	}
+
+	m := testStream(t, log, strings.NewReader("x ERROR: 42ms"), 0, 3)
+	if ms, ok := m[0].Group("ms"); len(m) != 1 || !ok || ms != "ms" { t.Fatalf("Stream() group = %q, %t\n", ms, ok) }
+	if _, ok := m[0].Group("missing"); ok { t.Fatalf("Stream() has a missing group\n") }
+}
+
+func TestStreamErrors(t *testing.T) {
+	g, _ := FromRegexp(`a+`)
+	if _, e := g.Stream(strings.NewReader("a"), 0); e == nil || e.Error() != "Gorex @86: unbounded match length requires a maximum" {
+		t.Fatalf("Stream() of an unbounded expression expected error: %v\n", e)
+	}
+	if _, e := g.Stream(strings.NewReader("a"), -1); e == nil || e.Error() != "Gorex @82: invalid maximum match length" {
+		t.Fatalf("Stream() of a negative maximum expected error: %v\n", e)
+	}
+
+	// the maximum cuts longer matches short
+	if ms := testStream(t, g, strings.NewReader("aaaaaaaa"), 3, 1); len(ms) == 0 || len(ms[0].Text) > 8 { t.Fatalf("Stream() with a short maximum = %+v\n", ms) }
+
+	// matches the error cuts short of their lookahead are not reported
+	fail := errors.New("disk on fire")
+	s, _ := g.Stream(io.MultiReader(strings.NewReader("a b aaa " + strings.Repeat(" ", 8) + "aa"), iotest.ErrReader(fail)), 4)
+	s.SetChunkSize(2)
+	n := 0
+	for s.Next() { n++ }
+	if s.Err() != fail || n != 2 { t.Fatalf("Stream() read error = %v after %d matches\n", s.Err(), n) }
+}
+
+// a reader of n bytes repeating a line, without holding them
+type testRepeater struct {
+	line string
+	n, at int
+}
+
+func (r *testRepeater) Read(p []byte) (int, error) {
+	if r.at >= r.n { return 0, io.EOF }
+	k := 0
+	for k < len(p) && r.at < r.n {
+		p[k] = r.line[r.at % len(r.line)]
+		k++
+		r.at++
+	}
+
+	return k, nil
+}
+
+func TestStreamLarge(t *testing.T) {
+	line := "2024-02-29 ERROR: 250ms\n"
+	s, e := testLog().Stream(&testRepeater{ line, len(line) * 100000, 0 }, 0)
+	if e != nil { t.Fatalf("Stream() unexpected error: %s\n", e) }
+	n := 0
+	var last StreamMatch
+	for s.Next() {
+		n++
+		last = s.Match()
+	}
+	if n != 100000 || last.Start != int64(len(line) * 99999 + 11) || !reflect.DeepEqual(last.Text, "ERROR: 250ms") { t.Fatalf("Stream() found %d matches, the last %+v\n", n, last) }
+	if cap(s.buf) > 2 * streamChunk { t.Fatalf("Stream() buffer grew to %d\n", cap(s.buf)) }
+}
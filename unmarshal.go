@@ -0,0 +1,228 @@
+package gorex
+
+// unmarshal
+//
+// Unmarshal copies the named groups of a match into the fields of a struct
+// that name them in a gorex tag:
+//
+//  type Entry struct {
+//  	When time.Time     `gorex:"when,layout=2006-01-02 15:04"`
+//  	Level string       `gorex:"level"`
+//  	Took time.Duration `gorex:"took"`
+//  	Code int           `gorex:"code"`
+//  }
+//  var x Entry
+//  e := gorex.Unmarshal(rex, line, &x)
+//  var xs []Entry
+//  e = gorex.UnmarshalAll(rex, log, &xs)
+//
+// fields convert to strings, integers, floats, bools, time.Duration, any
+// encoding.TextUnmarshaler, and time.Time, which is RFC 3339 without a
+// layout, and to pointers to these. groups that take no part in the match
+// leave their fields unchanged. the fields of embedded structs are filled
+// as if they were fields of the outer struct.
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoMatch is returned by Unmarshal when the expression does not match
+var ErrNoMatch = errors.New("Gorex @37: no match")
+
+// UnmarshalError reports a group whose text does not convert to the type of
+// its field
+type UnmarshalError struct {
+	Group string
+	Value string
+	Type reflect.Type
+	Err error
+}
+
+func (e *UnmarshalError) Error() string {
+	return fmt.Sprintf("Gorex @49: group %s: cannot convert %s to %s: %s", e.Group, strconv.Quote(e.Value), e.Type, e.Err)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType = reflect.TypeOf(time.Time{ })
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// a tagged field and the group it takes
+type fieldGroup struct {
+	index []int
+	name string
+	group int
+	layout string
+}
+
+// whether a field of type t can take the text of a group
+func convertible(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr { t = t.Elem() }
+	if t == timeType || t == durationType || reflect.PtrTo(t).Implements(textUnmarshalerType) { return true }
+	switch(t.Kind()) {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// the tagged fields of struct type t, and embedded structs, with their groups
+func fieldGroups(rex *regexp.Regexp, t reflect.Type, outer []int) ([]fieldGroup, error) {
+	var fs []fieldGroup
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int{ }, outer...), i)
+		tag, ok := f.Tag.Lookup("gorex")
+		if !ok && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			inner, e := fieldGroups(rex, f.Type, index)
+			if e != nil { return nil, e }
+			fs = append(fs, inner...)
+			continue
+		}
+		if !ok || tag == "-" || !f.IsExported() { continue }
+
+		name, layout := tag, ""
+		if n := strings.Index(tag, ","); n >= 0 {
+			name = tag[:n]
+			if !strings.HasPrefix(tag[n+1:], "layout=") { return nil, errors.New("Gorex @102: invalid gorex tag option of field " + f.Name) }
+			layout = strings.TrimPrefix(tag[n+1:], "layout=")
+		}
+		group := rex.SubexpIndex(name)
+		if group < 0 { return nil, errors.New("Gorex @106: no group named " + name + " for field " + f.Name) }
+		if !convertible(f.Type) { return nil, errors.New("Gorex @107: unsupported type " + f.Type.String() + " of field " + f.Name) }
+		fs = append(fs, fieldGroup{ index, name, group, layout })
+	}
+
+	return fs, nil
+}
+
+// sets v from the text of a group
+func setField(v reflect.Value, s string, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() { v.Set(reflect.New(v.Type().Elem())) }
+		v = v.Elem()
+	}
+	if v.Type() == timeType && layout != "" {
+		t, e := time.Parse(layout, s)
+		if e != nil { return e }
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok { return u.UnmarshalText([]byte(s)) }
+	if v.Type() == durationType {
+		d, e := time.ParseDuration(s)
+		if e != nil { return e }
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch(v.Kind()) {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, e := strconv.ParseBool(s)
+		if e != nil { return e }
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, e := strconv.ParseFloat(s, v.Type().Bits())
+		if e != nil { return e }
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, e := strconv.ParseInt(s, 10, v.Type().Bits())
+		if e != nil { return e }
+		v.SetInt(n)
+	default:
+		n, e := strconv.ParseUint(s, 10, v.Type().Bits())
+		if e != nil { return e }
+		v.SetUint(n)
+	}
+
+	return nil
+}
+
+// fills the struct v from the submatch indices of a match in input
+func fill(v reflect.Value, fs []fieldGroup, input string, at []int) error {
+	for _, f := range(fs) {
+		if at[2*f.group] < 0 { continue }
+		s := input[at[2*f.group]:at[2*f.group+1]]
+		field := v.FieldByIndex(f.index)
+		if e := setField(field, s, f.layout); e != nil { return &UnmarshalError{ f.name, s, field.Type(), e } }
+	}
+
+	return nil
+}
+
+// the compiled expression and the tagged fields of struct type t
+func prepare(g *Gorex, t reflect.Type) (*regexp.Regexp, []fieldGroup, error) {
+	o, e := g.Output()
+	if e != nil { return nil, nil, e }
+	rex, e := regexp.Compile(o)
+	if e != nil { return nil, nil, e }
+	fs, e := fieldGroups(rex, t, nil)
+	if e != nil { return nil, nil, e }
+
+	return rex, fs, nil
+}
+
+// Unmarshal fills the struct v points to from the named groups of the first
+// match of the expression in input, returning ErrNoMatch if there is none,
+// or an *UnmarshalError for a group that does not convert to its field
+func Unmarshal(g *Gorex, input string, v interface{}) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.IsNil() || p.Elem().Kind() != reflect.Struct {
+		return errors.New("Gorex @188: Unmarshal needs a pointer to a struct")
+	}
+	rex, fs, e := prepare(g, p.Elem().Type())
+	if e != nil { return e }
+	at := rex.FindStringSubmatchIndex(input)
+	if at == nil { return ErrNoMatch }
+
+	return fill(p.Elem(), fs, input, at)
+}
+
+// UnmarshalAll sets the slice v points to, of structs or of pointers to
+// structs, to one element for each match of the expression in input, as
+// FindAllString finds them, filled as by Unmarshal. without matches the
+// slice is empty
+func UnmarshalAll(g *Gorex, input string, v interface{}) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.IsNil() || p.Elem().Kind() != reflect.Slice {
+		return errors.New("Gorex @205: UnmarshalAll needs a pointer to a slice")
+	}
+	slice := p.Elem()
+	elem := slice.Type().Elem()
+	st := elem
+	if st.Kind() == reflect.Ptr { st = st.Elem() }
+	if st.Kind() != reflect.Struct { return errors.New("Gorex @211: UnmarshalAll needs a slice of structs") }
+	rex, fs, e := prepare(g, st)
+	if e != nil { return e }
+
+	all := rex.FindAllStringSubmatchIndex(input, -1)
+	out := reflect.MakeSlice(slice.Type(), len(all), len(all))
+	for i, at := range(all) {
+		x := out.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			x.Set(reflect.New(st))
+			x = x.Elem()
+		}
+		if e = fill(x, fs, input, at); e != nil { return e }
+	}
+	slice.Set(out)
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package gorex
+
+import(
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// when, level, took, code and ok, as in "2024-02-29 10:00 WARN took=1.5s code=404 ok=false"
+func testEntry() *Gorex {
+	g, _ := GolangExpression()
+	g.AddFixed("[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}")
+	g.NameGroup("when")
+	g.AddFixed(" ")
+	g.AddClass(Uppers)
+	g.ApplyQuantifier(OneOrMore)
+	g.NameGroup("level")
+	g.AddFixed(" took=")
+	g.AddFixed("[0-9.]+[a-z]+")
+	g.NameGroup("took")
+	g.AddFixed(" code=")
+	g.AddClass(Digits)
+	g.ApplyQuantifier(OneOrMore)
+	g.NameGroup("code")
+	g.AddFixed("(?: ok=(?P<ok>[a-z]+))?")
+
+	return g
+}
+
+type testSource struct {
+	Code uint16 `gorex:"code"`
+}
+
+type testRecord struct {
+	testSource
+	When time.Time `gorex:"when,layout=2006-01-02 15:04"`
+	Level string `gorex:"level"`
+	Took time.Duration `gorex:"took"`
+	OK *bool `gorex:"ok"`
+	Rate float64
+	skipped int `gorex:"level"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	var r testRecord
+	r.Rate = 0.5
+	if e := Unmarshal(testEntry(), "x 2024-02-29 10:00 WARN took=1.5s code=404 ok=false", &r); e != nil { t.Fatalf("Unmarshal() unexpected error: %s\n", e) }
+	if !r.When.Equal(time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC)) || r.Level != "WARN" || r.Took != 1500 * time.Millisecond ||
+			r.Code != 404 || r.OK == nil || *r.OK || r.Rate != 0.5 || r.skipped != 0 {
+		t.Fatalf("Unmarshal() = %+v\n", r)
+	}
+
+	// groups without a part in the match leave their fields unchanged
+	r = testRecord{ }
+	Unmarshal(testEntry(), "2024-02-29 10:00 INFO took=2m code=200", &r)
+	if r.OK != nil { t.Fatalf("Unmarshal() set ok without a match\n") }
+
+	var ue *UnmarshalError
+	e := Unmarshal(testEntry(), "2024-02-29 10:00 INFO took=2m code=70000", &r)
+	if !errors.As(e, &ue) || ue.Group != "code" || ue.Value != "70000" { t.Fatalf("Unmarshal() of an overflow error = %v\n", e) }
+	e = Unmarshal(testEntry(), "2024-02-29 10:00 INFO took=2x code=1", &r)
+	if !errors.As(e, &ue) || ue.Group != "took" || !strings.Contains(e.Error(), "took") { t.Fatalf("Unmarshal() of a bad duration error = %v\n", e) }
+	e = Unmarshal(testEntry(), "2024-02-30 10:00 INFO took=2s code=1", &r)
+	if !errors.As(e, &ue) || ue.Group != "when" { t.Fatalf("Unmarshal() of a bad time error = %v\n", e) }
+	if e = Unmarshal(testEntry(), "nothing", &r); e != ErrNoMatch { t.Fatalf("Unmarshal() without match error = %v\n", e) }
+
+	if e = Unmarshal(testEntry(), "", r); e == nil { t.Fatalf("Unmarshal() of a struct expects error\n") }
+	var missing struct { X string `gorex:"x"` }
+	if e = Unmarshal(testEntry(), "", &missing); e == nil { t.Fatalf("Unmarshal() of a missing group expects error\n") }
+	var unsupported struct { X []int `gorex:"code"` }
+	if e = Unmarshal(testEntry(), "", &unsupported); e == nil { t.Fatalf("Unmarshal() of an unsupported type expects error\n") }
+	var option struct { X string `gorex:"code,omitempty"` }
+	if e = Unmarshal(testEntry(), "", &option); e == nil { t.Fatalf("Unmarshal() of an unknown option expects error\n") }
+}
+
+func TestUnmarshalText(t *testing.T) {
+	g, _ := GolangExpression()
+	g.AddFixed("[0-9.]+")
+	g.NameGroup("ip")
+	g.AddFixed(" ")
+	g.AddFixed("[0-9TZ:-]+")
+	g.NameGroup("at")
+	g.AddFixed(" ")
+	g.AddFixed("-?[0-9.e]+")
+	g.NameGroup("f")
+	var x struct {
+		IP net.IP `gorex:"ip"`
+		At time.Time `gorex:"at"`
+		F float32 `gorex:"f"`
+		I int8 `gorex:"f"`
+	}
+	e := Unmarshal(g, "10.0.0.1 2024-02-29T10:00:00Z 1.5", &x)
+	var ue *UnmarshalError
+	if !errors.As(e, &ue) || ue.Group != "f" || ue.Type.String() != "int8" { t.Fatalf("Unmarshal() of a float into int8 error = %v\n", e) }
+	if x.IP.String() != "10.0.0.1" || x.At.Year() != 2024 || x.F != 1.5 { t.Fatalf("Unmarshal() = %+v\n", x) }
+	if e = Unmarshal(g, "10.0.0.300 2024-02-29T10:00:00Z 1", &x); !errors.As(e, &ue) || ue.Group != "ip" { t.Fatalf("Unmarshal() of a bad address error = %v\n", e) }
+}
+
+func TestUnmarshalAll(t *testing.T) {
+	log := "2024-02-29 10:00 WARN took=1s code=1\n2024-02-29 10:01 INFO took=2s code=2 ok=true\n"
+	var rs []testRecord
+	if e := UnmarshalAll(testEntry(), log, &rs); e != nil { t.Fatalf("UnmarshalAll() unexpected error: %s\n", e) }
+	if len(rs) != 2 || rs[0].Level != "WARN" || rs[1].Code != 2 || rs[0].OK != nil || !*rs[1].OK { t.Fatalf("UnmarshalAll() = %+v\n", rs) }
+
+	var ps []*testRecord
+	if e := UnmarshalAll(testEntry(), log, &ps); e != nil || len(ps) != 2 || ps[1].Took != 2 * time.Second { t.Fatalf("UnmarshalAll() of pointers = %+v (%v)\n", ps, e) }
+	if e := UnmarshalAll(testEntry(), "none", &ps); e != nil || len(ps) != 0 { t.Fatalf("UnmarshalAll() without match = %+v (%v)\n", ps, e) }
+	if e := UnmarshalAll(testEntry(), log, &[]int{ }); e == nil { t.Fatalf("UnmarshalAll() of ints expects error\n") }
+	if e := UnmarshalAll(testEntry(), log, rs); e == nil { t.Fatalf("UnmarshalAll() of a slice expects error\n") }
+}
@@ -0,0 +1,281 @@
+// Package validator checks struct fields against named gorex expressions
+//
+// fields name the pattern they must match in a validate tag, and patterns
+// are registered once, compiled to match whole values:
+//
+//  type Signup struct {
+//  	Email string      `validate:"gorex=email"`
+//  	Site *string      `validate:"omitempty,gorex=url"`
+//  	Hosts []string    `validate:"gorex=hostname"`
+//  	Contacts []Contact // validated field by field
+//  }
+//  v, _ := validator.Standard()
+//  v.Register("sku", sku)
+//  if e := v.Validate(&signup); e != nil { ... }   // Contacts[1].Phone: "12" does not match phone ...
+//
+// tagged fields are strings, pointers to strings, or slices and arrays of
+// them; structs, pointers to structs and slices, arrays and maps of structs
+// are validated field by field, whether tagged or not. with omitempty, empty
+// strings and nil pointers are not checked.
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dev-west/gorex"
+	"github.com/dev-west/gorex/patterns/net"
+	"github.com/dev-west/gorex/patterns/timefmt"
+	"github.com/dev-west/gorex/patterns/web"
+)
+
+// a registered pattern
+type pattern struct {
+	rex *regexp.Regexp
+	explain string
+}
+
+// Validator holds the patterns fields are checked against; it is safe for
+// concurrent use
+type Validator struct {
+	mu sync.RWMutex
+	patterns map[string]pattern
+}
+
+// FieldError is a field whose value does not match its pattern; Path names
+// the field from the validated struct, as in Contacts[1].Phone, and Explain
+// is the Explain text of the pattern
+type FieldError struct {
+	Path string
+	Value string
+	Pattern string
+	Explain string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s does not match %s\n%s", e.Path, strconv.Quote(e.Value), e.Pattern, strings.TrimSuffix(e.Explain, "\n"))
+}
+
+// Errors lists every field that failed, in the order of the struct
+type Errors []*FieldError
+
+func (es Errors) Error() string {
+	var lines []string
+	for _, e := range(es) { lines = append(lines, e.Error()) }
+
+	return fmt.Sprintf("Gorex @72: %d invalid fields\n", len(es)) + strings.Join(lines, "\n")
+}
+
+// New returns a validator without patterns
+func New() *Validator {
+	return &Validator{ patterns: map[string]pattern{ } }
+}
+
+// Standard returns a validator with the expressions of patterns/web,
+// patterns/net and patterns/timefmt, named email, strictemail, url, uri,
+// ipv4, ipv6, ipv4cidr, ipv6cidr, mac, port, hostname, fqdn, rfc3339 and
+// iso8601
+func Standard() (*Validator, error) {
+	v := New()
+	for _, p := range([]struct {
+		name string
+		build func() (*gorex.Gorex, error)
+	}{
+		{ "email", web.Email }, { "strictemail", web.StrictEmail }, { "url", web.URL }, { "uri", web.URI },
+		{ "ipv4", net.IPv4 }, { "ipv6", net.IPv6 }, { "ipv4cidr", net.IPv4CIDR }, { "ipv6cidr", net.IPv6CIDR },
+		{ "mac", net.MAC }, { "port", net.Port }, { "hostname", net.Hostname }, { "fqdn", net.FQDN },
+		{ "rfc3339", timefmt.RFC3339 }, { "iso8601", timefmt.ISO8601 },
+	}) {
+		g, e := p.build()
+		if e != nil { return nil, e }
+		if e = v.Register(p.name, g); e != nil { return nil, e }
+	}
+
+	return v, nil
+}
+
+// Register compiles an expression to match whole values, under name;
+// registering a name again replaces its pattern
+func (v *Validator) Register(name string, g *gorex.Gorex) error {
+	if name == "" || strings.ContainsAny(name, ",= ") { return errors.New("Gorex @106: invalid pattern name") }
+	o, e := g.Output()
+	if e != nil { return e }
+	rex, e := regexp.Compile("^(?:" + o + ")$")
+	if e != nil { return e }
+	explain, e := g.Explain()
+	if e != nil { return e }
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.patterns[name] = pattern{ rex, explain }
+
+	return nil
+}
+
+// a parsed validate tag
+type rule struct {
+	name string
+	omitEmpty bool
+}
+
+func parseTag(tag string) (rule, error) {
+	var r rule
+	for _, part := range(strings.Split(tag, ",")) {
+		switch {
+		case part == "omitempty":
+			r.omitEmpty = true
+		case strings.HasPrefix(part, "gorex="):
+			r.name = strings.TrimPrefix(part, "gorex=")
+		default:
+			return r, errors.New("Gorex @136: invalid validate rule " + strconv.Quote(part))
+		}
+	}
+	if r.name == "" { return r, errors.New("Gorex @139: validate tag without gorex pattern") }
+
+	return r, nil
+}
+
+// a pointer, map or slice the walk has been through
+type visit struct {
+	p uintptr
+	t reflect.Type
+	n int
+}
+
+// the walk of one validated value
+type walk struct {
+	v *Validator
+	errs Errors
+	seen map[visit]bool
+}
+
+// reports whether x was walked before, and records it
+func (w *walk) visited(x reflect.Value) bool {
+	k := visit{ x.Pointer(), x.Type(), 0 }
+	if x.Kind() == reflect.Slice { k.n = x.Len() }
+	if w.seen[k] { return true }
+	if w.seen == nil { w.seen = map[visit]bool{ } }
+	w.seen[k] = true
+
+	return false
+}
+
+// checks a tagged value: a string, a pointer to one, or a slice or array
+func (w *walk) check(path string, x reflect.Value, r rule) error {
+	switch(x.Kind()) {
+	case reflect.Ptr, reflect.Interface:
+		if x.IsNil() {
+			if r.omitEmpty { return nil }
+			return w.match(path, "", r.name)
+		}
+		return w.check(path, x.Elem(), r)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < x.Len(); i++ {
+			if e := w.check(fmt.Sprintf("%s[%d]", path, i), x.Index(i), r); e != nil { return e }
+		}
+		return nil
+	case reflect.String:
+		if x.Len() == 0 && r.omitEmpty { return nil }
+		return w.match(path, x.String(), r.name)
+	}
+
+	return errors.New("Gorex @169: unsupported type " + x.Type().String() + " of field " + path)
+}
+
+// records the value as failed if it does not match the pattern
+func (w *walk) match(path string, s string, name string) error {
+	p, ok := w.v.patterns[name]
+	if !ok { return errors.New("Gorex @175: unknown pattern " + name + " of field " + path) }
+	if !p.rex.MatchString(s) { w.errs = append(w.errs, &FieldError{ path, s, name, p.explain }) }
+
+	return nil
+}
+
+// walks the structs within x, once for each pointer, map or slice
+func (w *walk) value(path string, x reflect.Value) error {
+	switch(x.Kind()) {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if x.IsNil() || w.visited(x) { return nil }
+	}
+	switch(x.Kind()) {
+	case reflect.Ptr, reflect.Interface:
+		if x.IsNil() { return nil }
+		return w.value(path, x.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < x.Len(); i++ {
+			if e := w.value(fmt.Sprintf("%s[%d]", path, i), x.Index(i)); e != nil { return e }
+		}
+	case reflect.Map:
+		// in the order of the keys, for repeatable errors
+		keys := x.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range(keys) {
+			if e := w.value(fmt.Sprintf("%s[%v]", path, k), x.MapIndex(k)); e != nil { return e }
+		}
+	case reflect.Struct:
+		return w.fields(path, x)
+	}
+
+	return nil
+}
+
+// walks the fields of a struct
+func (w *walk) fields(path string, x reflect.Value) error {
+	t := x.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous { continue }
+		fpath := f.Name
+		if path != "" { fpath = path + "." + f.Name }
+		if f.Anonymous { fpath = path }
+
+		tag, ok := f.Tag.Lookup("validate")
+		if tag == "-" { continue }
+		if !ok {
+			if e := w.value(fpath, x.Field(i)); e != nil { return e }
+			continue
+		}
+		r, e := parseTag(tag)
+		if e != nil { return fmt.Errorf("%s: %w", fpath, e) }
+		if e = w.check(fpath, x.Field(i), r); e != nil { return e }
+	}
+
+	return nil
+}
+
+// Validate checks the fields of the struct s is or points to, and of the
+// structs within it, returning Errors listing every field that does not
+// match its pattern, or the error of a tag naming an unknown pattern or a
+// field of the wrong type. a struct reached twice through the same pointer,
+// as in a cycle, is checked the first time only
+func (v *Validator) Validate(s interface{}) error {
+	w := &walk{ v: v }
+	x := reflect.ValueOf(s)
+	for x.Kind() == reflect.Ptr && !x.IsNil() && !w.visited(x) { x = x.Elem() }
+	if x.Kind() != reflect.Struct { return errors.New("Gorex @236: Validate needs a struct") }
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if e := w.fields("", x); e != nil { return e }
+	if len(w.errs) != 0 { return w.errs }
+
+	return nil
+}
+
+// Var checks one value against the pattern registered as name, returning
+// a *FieldError naming the pattern if it does not match
+func (v *Validator) Var(name string, s string) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	w := &walk{ v: v }
+	if e := w.match("value", s, name); e != nil { return e }
+	if len(w.errs) != 0 { return w.errs[0] }
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package validator
+
+import(
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dev-west/gorex"
+)
+
+type testContact struct {
+	Phone string `validate:"gorex=phone"`
+	Email *string `validate:"omitempty,gorex=email"`
+}
+
+type testAudit struct {
+	Host string `validate:"gorex=hostname"`
+}
+
+type testSignup struct {
+	testAudit
+	Email string `validate:"gorex=email"`
+	Site string `validate:"omitempty,gorex=url"`
+	Hosts []string `validate:"gorex=ipv4"`
+	Contacts []testContact
+	Primary *testContact
+	ByName map[string]testContact
+	Notes string
+	Raw string `validate:"-"`
+}
+
+// a validator with the standard patterns and phone
+func testValidator(t *testing.T) *Validator {
+	v, e := Standard()
+	if e != nil { t.Fatalf("Standard() unexpected error: %s\n", e) }
+	g, _ := gorex.GolangExpression()
+	g.AddFixed("\\+")
+	g.ApplyQuantifier(gorex.ZeroOrOne)
+	g.AddClass(gorex.Digits)
+	g.ApplyQuantifier(gorex.MinToMax, 7, 15)
+	if e = v.Register("phone", g); e != nil { t.Fatalf("Register() unexpected error: %s\n", e) }
+
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	v := testValidator(t)
+	mail := "a@example.com"
+	ok := testSignup{ testAudit{ "db-1" }, "joe@example.com", "", []string{ "10.0.0.1" },
+		[]testContact{ { "+15551234567", nil }, { "5551234", &mail } }, nil, nil, "anything", "!" }
+	if e := v.Validate(ok); e != nil { t.Fatalf("Validate() unexpected error: %s\n", e) }
+	if e := v.Validate(&ok); e != nil { t.Fatalf("Validate() of a pointer unexpected error: %s\n", e) }
+
+	bad := "not mail"
+	s := testSignup{ testAudit{ "-db" }, "joe", "example.com", []string{ "10.0.0.1", "10.0.0.256" },
+		[]testContact{ { "+15551234567", nil }, { "12", &bad } }, &testContact{ "x", nil },
+		map[string]testContact{ "b": { "1", nil }, "a": { "2", nil } }, "", "" }
+	e := v.Validate(&s)
+	var es Errors
+	if !errors.As(e, &es) { t.Fatalf("Validate() error = %v; expected Errors\n", e) }
+	want := []string{ "Host", "Email", "Site", "Hosts[1]", "Contacts[1].Phone", "Contacts[1].Email", "Primary.Phone", "ByName[a].Phone", "ByName[b].Phone" }
+	if len(es) != len(want) { t.Fatalf("Validate() errors = %s\n", e) }
+	for i, w := range(want) {
+		if es[i].Path != w { t.Fatalf("Validate() error %d path = %s; expected %s\n", i, es[i].Path, w) }
+	}
+	if es[4].Value != "12" || es[4].Pattern != "phone" || !strings.Contains(es[4].Explain, "group 2: one character of digits") {
+		t.Fatalf("Validate() error = %#v\n", es[4])
+	}
+	if !strings.Contains(e.Error(), "Contacts[1].Phone: \"12\" does not match phone\ngroup 1") { t.Fatalf("Validate() message = %s\n", e) }
+}
+
+type testNode struct {
+	Name string `validate:"gorex=hostname"`
+	Next *testNode
+	Children []*testNode
+}
+
+func TestValidateCycle(t *testing.T) {
+	v := testValidator(t)
+	a := &testNode{ Name: "a" }
+	b := &testNode{ Name: "-b", Next: a }
+	a.Next = a
+	a.Children = []*testNode{ b, a }
+	e := v.Validate(a)
+	var es Errors
+	if !errors.As(e, &es) || len(es) != 1 || es[0].Path != "Children[0].Name" { t.Fatalf("Validate() of a cycle error = %v\n", e) }
+	if e = v.Validate(b); !errors.As(e, &es) || len(es) != 1 || es[0].Path != "Name" { t.Fatalf("Validate() of a cycle error = %v\n", e) }
+}
+
+func TestValidateErrors(t *testing.T) {
+	v := testValidator(t)
+	var unknown struct { X string `validate:"gorex=zip"` }
+	if e := v.Validate(unknown); e == nil || !strings.Contains(e.Error(), "zip") { t.Fatalf("Validate() of an unknown pattern error = %v\n", e) }
+	var rule struct { X string `validate:"required,gorex=email"` }
+	if e := v.Validate(rule); e == nil || !strings.Contains(e.Error(), "X") { t.Fatalf("Validate() of an unknown rule error = %v\n", e) }
+	var kind struct { X int `validate:"gorex=port"` }
+	if e := v.Validate(kind); e == nil { t.Fatalf("Validate() of an int expects error\n") }
+	var required struct { X *string `validate:"gorex=email"` }
+	if e := v.Validate(required); e == nil { t.Fatalf("Validate() of a nil pointer without omitempty expects error\n") }
+	if e := v.Validate("x"); e == nil { t.Fatalf("Validate() of a string expects error\n") }
+	if e := v.Register("a,b", nil); e == nil { t.Fatalf("Register() of an invalid name expects error\n") }
+
+	if e := v.Var("port", "8080"); e != nil { t.Fatalf("Var() unexpected error: %s\n", e) }
+	var fe *FieldError
+	if e := v.Var("port", "80800"); !errors.As(e, &fe) || fe.Pattern != "port" { t.Fatalf("Var() error = %v\n", e) }
+}
+
+func TestConcurrent(t *testing.T) {
+	v := testValidator(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g, _ := gorex.GolangExpression()
+			g.AddClass(gorex.Digits)
+			v.Register("digit", g)
+			v.Validate(testContact{ Phone: "5551234" })
+		}()
+	}
+	wg.Wait()
+}
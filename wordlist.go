@@ -0,0 +1,94 @@
+package gorex
+
+// word lists
+//
+// AddWordList adds a group matching any of a list of words, assembled into
+// one alternation with common prefixes and suffixes factored out, as Perl's
+// Regexp::Assemble does:
+//
+//  rex.AddWordList([]string{ "sing", "ring", "rings", "bring" })   // ((?:br|s)ing|rings?)
+//  f, _ := os.Open("blocklist.txt")
+//  rex.ReadWordList(f, FoldCase)                                  // one word per line
+//
+// where words share a prefix, the longer words are preferred.
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// word list options
+const (
+	FoldCase string = "FoldCase" // words match in any case
+)
+
+type trie struct {
+	children map[rune]*trie
+	word bool
+}
+
+func (t *trie) insert(word string) {
+	for _, r := range(word) {
+		child, ok := t.children[r]
+		if !ok {
+			child = &trie{ children: map[rune]*trie{ } }
+			t.children[r] = child
+		}
+		t = child
+	}
+	t.word = true
+}
+
+// the expression of the words below t; suffixes shared by children are
+// factored out as their alternatives are joined
+func (t *trie) expression() *node {
+	var runes []rune
+	for r := range(t.children) { runes = append(runes, r) }
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	var alts []*node
+	for _, r := range(runes) { alts = append(alts, concatNode(classNode([]rune{ r, r }), t.children[r].expression())) }
+	if t.word { alts = append(alts, emptyNode) }
+
+	return altNode(alts...)
+}
+
+// AddWordList adds a group matching any one of words; with FoldCase the
+// group matches them in any case
+func (g *Gorex) AddWordList(words []string, opts ...string) error {
+	fold := false
+	for _, op := range(opts) {
+		if op != FoldCase { return errors.New("Gorex @67: invalid word list option") }
+		fold = true
+	}
+	if len(words) == 0 { return errors.New("Gorex @70: empty word list") }
+
+	root := &trie{ children: map[rune]*trie{ } }
+	for _, w := range(words) {
+		if fold { w = strings.ToLower(w) }
+		root.insert(w)
+	}
+
+	id := len(g.groups)
+	g.groups = append(g.groups, rexGroup{ })
+	g.groups[id].tokens = append(g.groups[id].tokens, rexToken{ root.expression().key, NoClass, rexQuan{ } })
+	if fold { g.groups[id].flags.i = true }
+
+	return nil
+}
+
+// ReadWordList adds a group matching any one of the words read from r, one
+// per line; blank lines are skipped and surrounding spaces ignored
+func (g *Gorex) ReadWordList(r io.Reader, opts ...string) error {
+	var words []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if w := strings.TrimSpace(s.Text()); w != "" { words = append(words, w) }
+	}
+	if e := s.Err(); e != nil { return e }
+
+	return g.AddWordList(words, opts...)
+}
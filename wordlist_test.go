@@ -0,0 +1,107 @@
+package gorex
+
+import(
+	"fmt"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+// product codes such as SKU-AB-0123
+func testWords(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	var words []string
+	for i := 0; i < n; i++ {
+		words = append(words, fmt.Sprintf("SKU-%c%c-%04d", 'A' + r.Intn(6), 'A' + r.Intn(26), r.Intn(10000)))
+	}
+
+	return words
+}
+
+// the flat alternation of words
+func naiveWords(words []string) *Gorex {
+	g, _ := GolangExpression()
+	g.AddFixed(regexp.QuoteMeta(words[0]))
+	for _, w := range(words[1:]) { g.AddFixedToLast(regexp.QuoteMeta(w)) }
+
+	return g
+}
+
+func TestAddWordList(t *testing.T) {
+	var g *Gorex
+	var e error
+
+	g, _ = GolangExpression()
+	if e = g.AddWordList([]string{ "sing", "ring", "rings", "bring" }); e != nil { t.Fatalf("AddWordList() unexpected error: %s\n", e) }
+	o, _ := g.Output()
+	if o != "((?:br|s)ing|rings?)" { t.Fatalf("AddWordList() output %s\n", o) }
+
+	// the same strings as the flat alternation, metacharacters included
+	words := append(testWords(500), "a.b", "(x)", "")
+	g, _ = GolangExpression()
+	if e = g.AddWordList(words); e != nil { t.Fatalf("AddWordList() unexpected error: %s\n", e) }
+	if ok, x, e := Equivalent(g, naiveWords(words)); !ok { t.Fatalf("AddWordList() differs on %q: %v\n", x, e) }
+
+	// words need not be ascii
+	g, _ = GolangExpression()
+	if e = g.AddWordList([]string{ "ünï", "un" }); e != nil { t.Fatalf("AddWordList() unexpected error: %s\n", e) }
+	g.ShouldMatch("ünï", "un")
+	g.ShouldNotMatch("uni")
+	if e = g.Verify(); e != nil { t.Fatalf("AddWordList() %s\n", e) }
+
+	// case folding
+	g, _ = GolangExpression()
+	g.AddWordList([]string{ "Foo", "FOOBAR" }, FoldCase)
+	g.ShouldMatch("foo", "FoObAr")
+	g.ShouldNotMatch("fooba")
+	if e = g.Verify(); e != nil { t.Fatalf("AddWordList(FoldCase) %s\n", e) }
+
+	if e = g.AddWordList(nil); e == nil { t.Fatalf("AddWordList(nil) expected error\n") }
+	if e = g.AddWordList([]string{ "a" }, "nope"); e == nil { t.Fatalf("AddWordList(\"nope\") expected error\n") }
+}
+
+func TestReadWordList(t *testing.T) {
+	g, _ := GolangExpression()
+	if e := g.ReadWordList(strings.NewReader("cat\n\n  car \ncart\n")); e != nil { t.Fatalf("ReadWordList() unexpected error: %s\n", e) }
+	o, _ := g.Output()
+	if o != "(ca(?:rt?|t))" { t.Fatalf("ReadWordList() output %s\n", o) }
+}
+
+func benchmarkCompile(b *testing.B, g *Gorex) {
+	o, _ := g.Output()
+	re, _ := syntax.Parse(o, syntax.Perl)
+	p, _ := syntax.Compile(re.Simplify())
+	b.ReportMetric(float64(len(p.Inst)), "insts")
+	for i := 0; i < b.N; i++ { regexp.MustCompile(o) }
+}
+
+func benchmarkMatch(b *testing.B, g *Gorex, words []string) {
+	o, _ := g.Output()
+	rex := regexp.MustCompile("^(?:" + o + ")$")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ { rex.MatchString(words[i % len(words)]) }
+}
+
+func BenchmarkWordListCompile(b *testing.B) {
+	g, _ := GolangExpression()
+	g.AddWordList(testWords(2000))
+	benchmarkCompile(b, g)
+}
+
+func BenchmarkNaiveListCompile(b *testing.B) {
+	benchmarkCompile(b, naiveWords(testWords(2000)))
+}
+
+func BenchmarkWordListMatch(b *testing.B) {
+	words := testWords(2000)
+	g, _ := GolangExpression()
+	g.AddWordList(words)
+	benchmarkMatch(b, g, words)
+}
+
+func BenchmarkNaiveListMatch(b *testing.B) {
+	words := testWords(2000)
+	benchmarkMatch(b, naiveWords(words), words)
+}